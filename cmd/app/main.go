@@ -1,6 +1,10 @@
 package main
 
-import "github.com/kolibriee/trade-metrics/internal/app"
+import (
+	"os"
+
+	"github.com/kolibriee/trade-metrics/internal/app"
+)
 
 const (
 	configName = "config"
@@ -8,5 +12,21 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		app.Import(configsDir, configName, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		app.Export(configsDir, configName, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		app.Migrate(configsDir, configName, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		app.APIKey(os.Args[2:])
+		return
+	}
 	app.Run(configsDir, configName)
 }
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/kolibriee/trade-metrics/internal/auth"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKey prints a new API key and the auth.keys entry with its hash. The key
+// itself is stored nowhere.
+func APIKey(args []string) {
+	flags := flag.NewFlagSet("apikey", flag.ExitOnError)
+	name := flags.String("name", "", "name of the key")
+	client := flags.String("client", "", "client whose orders the key may access")
+	admin := flags.Bool("admin", false, "give the key access to every client")
+	flags.Parse(args)
+	if *name == "" || *admin == (*client != "") {
+		logrus.Fatal("usage: app apikey -name <name> (-client <client> | -admin)")
+	}
+
+	key, err := auth.Generate()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	fmt.Printf("key: %s\n\n", key)
+	fmt.Printf("  - name: %q\n    hash: %q\n", *name, auth.Hash(key))
+	if *admin {
+		fmt.Println("    admin: true")
+	} else {
+		fmt.Printf("    client: %q\n", *client)
+	}
+}
@@ -2,14 +2,36 @@ package app
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
+	"reflect"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/kolibriee/trade-metrics/internal/auth"
+	"github.com/kolibriee/trade-metrics/internal/broker"
 	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/connector"
+	"github.com/kolibriee/trade-metrics/internal/consumer"
+	"github.com/kolibriee/trade-metrics/internal/controller/graphql"
+	grpcv1 "github.com/kolibriee/trade-metrics/internal/controller/grpc/v1"
+	v1 "github.com/kolibriee/trade-metrics/internal/controller/http/v1"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/kolibriee/trade-metrics/internal/fix"
+	"github.com/kolibriee/trade-metrics/internal/metrics"
+	"github.com/kolibriee/trade-metrics/internal/openapi"
+	"github.com/kolibriee/trade-metrics/internal/ratelimit"
 	"github.com/kolibriee/trade-metrics/internal/repository"
 	"github.com/kolibriee/trade-metrics/internal/server"
+	"github.com/kolibriee/trade-metrics/internal/spool"
+	"github.com/kolibriee/trade-metrics/internal/stream"
+	"github.com/kolibriee/trade-metrics/internal/tracing"
+	"github.com/kolibriee/trade-metrics/internal/webhook"
+	trademetricsv1 "github.com/kolibriee/trade-metrics/pkg/api/trademetrics/v1"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
 	"github.com/kolibriee/trade-metrics/internal/controller"
 )
@@ -22,27 +44,316 @@ func Run(configsDir string, configName string) {
 		logrus.Fatal(err)
 	}
 
-	db, err := repository.NewClickHouseDB(&config.ClickHouse)
+	settings := repository.NewSettings(config.ClickHouse.Settings)
+	db, repo, err := newRepository(config, settings)
 	if err != nil {
-		logrus.Fatalf("failed to connect to ClickHouse: %v", err)
+		logrus.Fatal(err)
+	}
+	migrator, err := newMigrator(config, db)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := checkSchema(config, migrator); err != nil {
+		logrus.Fatal(err)
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var (
+		workers     sync.WaitGroup
+		handlerOpts []v1.Option
+		dispatcher  *webhook.Dispatcher
+		spl         *spool.Spool
+	)
+
+	provider, err := tracing.New(&config.Tracing)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if provider != nil {
+		repo = tracing.Instrument(repo, backendName(config))
+		handlerOpts = append(handlerOpts, v1.WithTracing(tracing.Middleware(config.Tracing.ServiceName)))
+	}
+	var m *metrics.Metrics
+	if config.Metrics.Enabled {
+		m = metrics.New()
+		m.RegisterDB(db)
+		repo = metrics.Instrument(repo, m)
+		handlerOpts = append(handlerOpts, v1.WithMetrics(m))
+	}
+
+	if config.Spool.Enabled {
+		spl, err = spool.Open(config.Spool.Dir, config.Spool.MaxAttempts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		base := repo
+		repo = spool.Wrap(base, spl, db.Ping)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			spl.Run(workersCtx, base, db.Ping, config.Spool.ReplayInterval)
+		}()
+		handlerOpts = append(handlerOpts, v1.WithSpool(spl))
+	}
+	bus := event.NewBus()
+	repo = event.Publish(repo, bus)
+	if m != nil {
+		bus.Subscribe(m.Handle)
+	}
+	var keys *auth.Keys
+	if config.Auth.Enabled {
+		keys, err = auth.New(&config.Auth)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		repo = auth.Scope(repo)
+	}
+	if config.Stream.Enabled {
+		orderBooks := stream.NewOrderBooks(repo.Orderbook, &config.Stream)
+		bus.Subscribe(orderBooks.Handle)
+		handlerOpts = append(handlerOpts, v1.WithOrderBookStream(orderBooks))
+		orders := stream.NewOrders(&config.Stream)
+		bus.Subscribe(orders.Handle)
+		handlerOpts = append(handlerOpts, v1.WithOrderStream(orders))
 	}
 
-	repo := repository.NewRepository(db)
-	controller := controller.NewController(repo)
+	handlerOpts = append(handlerOpts, v1.WithTrustedProxies(config.Server.TrustedProxies))
+	var spec *openapi.Spec
+	if config.OpenAPI.Enabled {
+		spec, err = openapi.New(&config.OpenAPI)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+	}
+	if config.Export.Enabled {
+		handlerOpts = append(handlerOpts, v1.WithExport(&config.Export))
+	}
+	if config.GraphQL.Enabled {
+		graphqlHandler, err := graphql.NewHandler(repo, &config.GraphQL)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		handlerOpts = append(handlerOpts, v1.WithGraphQL(graphqlHandler))
+	}
+	if config.Webhooks.Enabled {
+		store, err := webhook.NewFileStore(config.Webhooks.StoreFile)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		dispatcher, err = webhook.NewDispatcher(store, &config.Webhooks)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		dispatcher.Start()
+		bus.Subscribe(dispatcher.Handle)
+		handlerOpts = append(handlerOpts, v1.WithWebhooks(store, dispatcher))
+	}
+
+	var (
+		msgBroker broker.Broker
+		cons      *consumer.Consumer
+	)
+	if config.Consumer.Enabled {
+		msgBroker, err = newBroker(&config.Consumer)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		cons = consumer.New(msgBroker, repo, &config.Consumer)
+		if err := cons.Start(); err != nil {
+			logrus.Fatalf("failed to start consumer: %v", err)
+		}
+	}
+
+	for i := range config.Connectors {
+		conn, err := connector.New(&config.Connectors[i], repo.Orderbook)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			conn.Run(workersCtx)
+		}()
+	}
+	for i := range config.FIX.Sessions {
+		engine, err := fix.New(&config.FIX.Sessions[i], repo.Orderhistory)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if err := engine.Run(workersCtx); err != nil && workersCtx.Err() == nil {
+				logrus.Errorf("fix session stopped: %v", err)
+			}
+		}()
+	}
+
+	checker := newHealthChecker(config, db, migrator)
+	handlerOpts = append(handlerOpts, v1.WithHealth(checker))
+
+	limiter, err := ratelimit.New(&config.RateLimit)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	if err := watchConfig(workersCtx, reload, config, limiter, settings); err != nil {
+		logrus.Fatal(err)
+	}
+
+	controller := controller.NewController(repo, keys, limiter, spec, handlerOpts...)
 	var srv server.Server
 	go func() {
 		if err := srv.Run(&config.Server, controller.Handler); err != nil {
 			logrus.Fatalf("failed to start server: %v", err)
 		}
 	}()
+	var grpcSrv *server.GRPCServer
+	if config.Server.GRPCPort != "" {
+		var grpcOpts []grpc.ServerOption
+		if keys != nil {
+			grpcOpts = append(grpcOpts,
+				grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(keys)),
+				grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(keys)))
+		}
+		grpcSrv = server.NewGRPCServer(func(s *grpc.Server) {
+			trademetricsv1.RegisterTradeMetricsServiceServer(s, grpcv1.NewServer(repo))
+		}, grpcOpts...)
+		go func() {
+			if err := grpcSrv.Run(&config.Server); err != nil {
+				logrus.Fatalf("failed to start grpc server: %v", err)
+			}
+		}()
+	}
 	logrus.Info("App started")
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 	<-quit
+	// readiness fails first, so that the service is taken out of rotation
+	// while it still serves requests
+	checker.Drain()
+	time.Sleep(config.Health.DrainDelay)
 	if err := srv.Shutdown(context.Background()); err != nil {
 		logrus.Errorf("error occured on server shutting down: %s", err.Error())
 	}
+	if grpcSrv != nil {
+		if err := grpcSrv.Shutdown(context.Background()); err != nil {
+			logrus.Errorf("error occured on grpc server shutting down: %s", err.Error())
+		}
+	}
+	stopWorkers()
+	workers.Wait()
+	if cons != nil {
+		if err := cons.Stop(); err != nil {
+			logrus.Errorf("error occured on consumer stop: %s", err.Error())
+		}
+		if err := msgBroker.Close(); err != nil {
+			logrus.Errorf("error occured on broker close: %s", err.Error())
+		}
+	}
+	if dispatcher != nil {
+		if err := dispatcher.Stop(); err != nil {
+			logrus.Errorf("error occured on webhook dispatcher stop: %s", err.Error())
+		}
+	}
+	if spl != nil {
+		if err := spl.Close(); err != nil {
+			logrus.Errorf("error occured on spool close: %s", err.Error())
+		}
+	}
 	if err := db.Close(); err != nil {
 		logrus.Errorf("error occured on db connection close: %s", err.Error())
 	}
+	if provider != nil {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			logrus.Errorf("error occured on tracer provider shutdown: %s", err.Error())
+		}
+	}
+}
+
+// watchConfig applies the settings that can change at runtime, the rate
+// limits and ClickHouse query settings, whenever the config file changes or
+// on reload. An invalid config is logged and the running settings are kept.
+// Changed connection settings only take effect after a restart.
+func watchConfig(ctx context.Context, reload <-chan os.Signal, running *config.Config, limiter *ratelimit.Limiter, settings *repository.Settings) error {
+	return config.Watch(ctx, reload, func(cfg *config.Config, err error) {
+		if err != nil {
+			logrus.Errorf("failed to reload config: %s", err.Error())
+			return
+		}
+		if err := limiter.Update(&cfg.RateLimit); err != nil {
+			logrus.Errorf("failed to reload rate limits: %s", err.Error())
+			return
+		}
+		settings.Set(cfg.ClickHouse.Settings)
+		if backendName(running) == repository.BackendClickHouse && clickHouseConnectionChanged(&running.ClickHouse, &cfg.ClickHouse) {
+			logrus.Warn("ClickHouse connection settings changed, restart to apply them")
+		}
+		logrus.Info("Config reloaded")
+	})
+}
+
+// clickHouseConnectionChanged tells if b connects differently than a,
+// query settings aside.
+func clickHouseConnectionChanged(a, b *config.ClickHouse) bool {
+	x, y := *a, *b
+	x.Settings, y.Settings = nil, nil
+	return !reflect.DeepEqual(x, y)
+}
+
+// backendName returns the configured repository backend, ClickHouse when
+// none is set.
+func backendName(cfg *config.Config) string {
+	if cfg.Repository.Backend == "" {
+		return repository.BackendClickHouse
+	}
+	return cfg.Repository.Backend
+}
+
+// newRepository returns the database of the configured backend and the
+// repository on it, with the configured operation timeouts. ClickHouse
+// queries are sent with settings.
+func newRepository(cfg *config.Config, settings *repository.Settings) (repository.DB, *repository.Repository, error) {
+	var (
+		db   repository.DB
+		repo *repository.Repository
+	)
+	switch cfg.Repository.Backend {
+	case "", repository.BackendClickHouse:
+		conn, err := repository.NewClickHouseDB(&cfg.ClickHouse)
+		if err != nil {
+			return nil, nil, err
+		}
+		db, repo = conn, repository.NewRepository(conn, settings)
+	case repository.BackendSQLite:
+		sqlDB, err := repository.NewSQLiteDB(&cfg.Repository.SQLite)
+		if err != nil {
+			return nil, nil, err
+		}
+		db, repo = sqlDB, repository.NewSQLRepository(sqlDB)
+	case repository.BackendPostgres:
+		sqlDB, err := repository.NewPostgresDB(&cfg.Repository.Postgres)
+		if err != nil {
+			return nil, nil, err
+		}
+		db, repo = sqlDB, repository.NewSQLRepository(sqlDB)
+	case repository.BackendMemory:
+		m := repository.NewMemory()
+		db, repo = m, repository.NewMemoryRepository(m)
+	default:
+		return nil, nil, errors.New("unknown repository backend: " + cfg.Repository.Backend)
+	}
+	return db, repository.WithTimeouts(repo, &cfg.Repository.Timeouts), nil
+}
+
+func newBroker(cfg *config.Consumer) (broker.Broker, error) {
+	switch cfg.Broker {
+	case "nats":
+		return broker.NewNATS(cfg.URL, cfg.AckWait)
+	case "memory":
+		return broker.NewMemory(cfg.AckWait, cfg.RedeliveryDelay), nil
+	default:
+		return nil, errors.New("unknown broker: " + cfg.Broker)
+	}
 }
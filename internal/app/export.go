@@ -0,0 +1,75 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/export"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+func Export(configsDir string, configName string, args []string) {
+	logrus.SetFormatter(new(logrus.JSONFormatter))
+
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	kind := flags.String("kind", "orders", "what to export: orders or orderbooks")
+	formatName := flags.String("format", string(export.FormatCSV), "csv, parquet or arrow")
+	out := flags.String("out", "", "output file, stdout by default")
+	var filter domain.Client
+	flags.StringVar(&filter.ClientName, "client-name", "", "export only orders of this client")
+	flags.StringVar(&filter.ExchangeName, "exchange-name", "", "export only this exchange")
+	flags.StringVar(&filter.Label, "label", "", "export only orders with this label")
+	flags.StringVar(&filter.Pair, "pair", "", "export only this pair")
+	flags.Parse(args)
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if *kind != "orders" && *kind != "orderbooks" {
+		logrus.Fatalf("unknown export kind: %s", *kind)
+	}
+
+	config, err := config.New(configsDir, configName)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	db, repo, err := newRepository(config, repository.NewSettings(config.ClickHouse.Settings))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			logrus.Fatalf("failed to create %s: %v", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriterSize(w, 1<<20)
+
+	if *kind == "orders" {
+		err = export.Orders(context.Background(), bw, format, config.Export.ChunkSize, repo, &filter)
+	} else {
+		err = export.OrderBooks(context.Background(), bw, format, config.Export.ChunkSize, repo, filter.ExchangeName, filter.Pair)
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		if *out != "" {
+			os.Remove(*out)
+		}
+		logrus.Fatalf("failed to export %s: %v", *kind, err)
+	}
+}
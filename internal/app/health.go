@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/health"
+	"github.com/kolibriee/trade-metrics/internal/migrate"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+type databaseDetails struct {
+	Backend string       `json:"backend"`
+	Pool    *health.Pool `json:"pool,omitempty"`
+}
+
+type migrationsDetails struct {
+	Version  int64 `json:"version"`
+	Dirty    bool  `json:"dirty"`
+	Expected int64 `json:"expected"`
+}
+
+// newHealthChecker returns the readiness checks of the database and, for
+// backends with a schema, of its migration version.
+func newHealthChecker(cfg *config.Config, db repository.DB, m *migrate.Migrator) *health.Checker {
+	backend := backendName(cfg)
+	checks := []health.Check{{
+		Name: "database",
+		Check: func(ctx context.Context) (any, error) {
+			details := databaseDetails{Backend: backend, Pool: poolStats(db)}
+			return details, db.Ping(ctx)
+		},
+	}}
+	if m != nil {
+		checks = append(checks, health.Check{
+			Name: "migrations",
+			Check: func(ctx context.Context) (any, error) {
+				status, err := m.ReadStatus(ctx)
+				if err != nil {
+					return nil, err
+				}
+				details := migrationsDetails{Version: status.Version, Dirty: status.Dirty, Expected: status.Latest}
+				return details, status.Err()
+			},
+		})
+	}
+	return health.NewChecker(cfg.Health.Timeout, checks...)
+}
+
+func poolStats(db repository.DB) *health.Pool {
+	switch db := db.(type) {
+	case driver.Conn:
+		stats := db.Stats()
+		return &health.Pool{Open: stats.Open, Idle: stats.Idle, InUse: stats.Open - stats.Idle, MaxOpen: stats.MaxOpenConns}
+	case *repository.SQLDB:
+		stats := db.Stats()
+		return &health.Pool{Open: stats.OpenConnections, Idle: stats.Idle, InUse: stats.InUse, MaxOpen: stats.MaxOpenConnections}
+	default:
+		return nil
+	}
+}
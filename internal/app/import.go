@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"os/signal"
+	"syscall"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/importer"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+func Import(configsDir string, configName string, args []string) {
+	logrus.SetFormatter(new(logrus.JSONFormatter))
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	kind := flags.String("kind", importer.KindOrders, "what the files contain: orders or orderbooks")
+	format := flags.String("format", "", "csv or ndjson, detected from the file extension by default")
+	restart := flags.Bool("restart", false, "ignore checkpoints and import files from the beginning")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		logrus.Fatal("no files to import")
+	}
+
+	config, err := config.New(configsDir, configName)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	db, repo, err := newRepository(config, repository.NewSettings(config.ClickHouse.Settings))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer db.Close()
+
+	imp, err := importer.New(repo, importer.Options{
+		Kind:       *kind,
+		Format:     *format,
+		BatchSize:  config.Import.BatchSize,
+		TimeLayout: config.Import.TimeLayout,
+		Columns:    config.Import.Columns,
+		Restart:    *restart,
+	})
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	// an interrupted import stops with the batch in flight and resumes from it
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	for _, path := range flags.Args() {
+		stats, err := imp.ImportFile(ctx, path)
+		if err != nil {
+			logrus.Fatalf("failed to import %s: %v", path, err)
+		}
+		logrus.WithFields(logrus.Fields{
+			"file":     path,
+			"imported": stats.Imported,
+			"rejected": stats.Rejected,
+		}).Info("import finished")
+	}
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/migrate"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/kolibriee/trade-metrics/migrations"
+	"github.com/sirupsen/logrus"
+)
+
+const migrateUsage = `usage: app migrate up | down [-steps n] [-all] | status | force <version>`
+
+func Migrate(configsDir string, configName string, args []string) {
+	logrus.SetFormatter(new(logrus.JSONFormatter))
+	if len(args) == 0 {
+		logrus.Fatal(migrateUsage)
+	}
+
+	config, err := config.New(configsDir, configName)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	db, _, err := newRepository(config, repository.NewSettings(config.ClickHouse.Settings))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer db.Close()
+	m, err := newMigrator(config, db)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if m == nil {
+		logrus.Fatalf("the %s backend has no migrations", config.Repository.Backend)
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("applied %d migrations", n)
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+		all := flags.Bool("all", false, "revert every migration")
+		flags.Parse(args[1:])
+		if *all {
+			*steps = math.MaxInt
+		}
+		n, err := m.Down(ctx, *steps)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("reverted %d migrations", n)
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		fmt.Printf("version: %s, dirty: %t, expected: %s\n", migrate.FormatVersion(status.Version), status.Dirty, migrate.FormatVersion(status.Latest))
+		for _, migration := range status.Pending {
+			fmt.Printf("pending: %d_%s\n", migration.Version, migration.Name)
+		}
+	case "force":
+		if len(args) != 2 {
+			logrus.Fatal(migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			logrus.Fatalf("invalid version %q", args[1])
+		}
+		if err := m.Force(ctx, version); err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("forced version %d", version)
+	default:
+		logrus.Fatal(migrateUsage)
+	}
+}
+
+// newMigrator returns the migrator of the configured backend, nil for the
+// memory backend, which has no schema.
+func newMigrator(cfg *config.Config, db repository.DB) (*migrate.Migrator, error) {
+	var (
+		dir string
+		drv migrate.Driver
+	)
+	switch cfg.Repository.Backend {
+	case "", repository.BackendClickHouse:
+		dir, drv = "clickhouse", migrate.NewClickHouse(db.(driver.Conn))
+	case repository.BackendSQLite:
+		dir, drv = "sqlite", migrate.NewSQLite(db.(*repository.SQLDB).DB)
+	case repository.BackendPostgres:
+		dir, drv = "postgres", migrate.NewPostgres(db.(*repository.SQLDB).DB)
+	default:
+		return nil, nil
+	}
+	list, err := migrate.Load(migrations.FS, dir)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(drv, list), nil
+}
+
+// checkSchema applies pending migrations if cfg.Migrations.Auto is set and
+// returns an error unless the schema then has the expected version.
+func checkSchema(cfg *config.Config, m *migrate.Migrator) error {
+	if m == nil {
+		return nil
+	}
+	ctx := context.Background()
+	if cfg.Migrations.Auto {
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logrus.Infof("applied %d migrations", n)
+		}
+	}
+	return m.Check(ctx)
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []config.APIKey
+		expectedError string
+	}{
+		{
+			name: "OK",
+			keys: []config.APIKey{
+				{Name: "misha", Hash: Hash("tm_misha"), Client: "Misha"},
+				{Name: "ops", Hash: strings.ToUpper(Hash("tm_ops")), Admin: true},
+			},
+		},
+		{
+			name:          "Invalid hash",
+			keys:          []config.APIKey{{Name: "misha", Hash: "tm_misha", Client: "Misha"}},
+			expectedError: "API key misha: hash must be a hex SHA-256",
+		},
+		{
+			name:          "Neither client nor admin",
+			keys:          []config.APIKey{{Name: "misha", Hash: Hash("tm_misha")}},
+			expectedError: "API key misha: needs either a client or admin",
+		},
+		{
+			name:          "Client and admin",
+			keys:          []config.APIKey{{Name: "misha", Hash: Hash("tm_misha"), Client: "Misha", Admin: true}},
+			expectedError: "API key misha: needs either a client or admin",
+		},
+		{
+			name: "Duplicate hash",
+			keys: []config.APIKey{
+				{Name: "misha", Hash: Hash("tm_misha"), Client: "Misha"},
+				{Name: "sasha", Hash: Hash("tm_misha"), Client: "Sasha"},
+			},
+			expectedError: "API key sasha: duplicate hash",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&config.Auth{Enabled: true, Keys: tt.keys})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKeys_Authenticate(t *testing.T) {
+	keys, err := New(&config.Auth{Keys: []config.APIKey{{Name: "ops", Hash: Hash("tm_ops"), Admin: true}}})
+	require.NoError(t, err)
+
+	key, err := keys.Authenticate(FromHeader("", "Bearer tm_ops"))
+	require.NoError(t, err)
+	assert.Equal(t, &Key{Name: "ops", Admin: true}, key)
+
+	_, err = keys.Authenticate(FromHeader("", "Basic dXNlcjpwYXNz"))
+	assert.ErrorIs(t, err, ErrMissingKey)
+
+	_, err = keys.Authenticate(FromHeader("tm_unknown", "Bearer tm_ops"))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	generated, err := Generate()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(generated, keyPrefix))
+	_, err = keys.Authenticate(generated)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestScope(t *testing.T) {
+	repo := Scope(repository.NewMemoryRepository(repository.NewMemory()))
+	misha := WithKey(context.Background(), &Key{Name: "misha", Client: "Misha"})
+	admin := WithKey(context.Background(), &Key{Name: "ops", Admin: true})
+	order := func(client string) *domain.HistoryOrder {
+		return &domain.HistoryOrder{Client: domain.Client{ClientName: client, ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"}}
+	}
+
+	require.NoError(t, repo.SaveOrder(misha, order("Misha")))
+	require.NoError(t, repo.SaveOrder(admin, order("Sasha")))
+	assert.ErrorIs(t, repo.SaveOrder(misha, order("Sasha")), repository.ErrForbidden)
+	assert.ErrorIs(t, repo.SaveOrders(misha, []*domain.HistoryOrder{order("Misha"), order("Sasha")}), repository.ErrForbidden)
+
+	_, err := repo.GetOrderHistory(misha, &domain.Client{ClientName: "Sasha"})
+	assert.ErrorIs(t, err, repository.ErrForbidden)
+	_, err = repo.GetOrderHistory(context.Background(), &domain.Client{ClientName: "Sasha"})
+	assert.NoError(t, err)
+
+	var clients []string
+	err = repo.ExportOrders(misha, &domain.Client{}, func(order *domain.HistoryOrder) error {
+		clients = append(clients, order.Client.ClientName)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Misha"}, clients)
+
+	clients = nil
+	err = repo.ExportOrders(admin, &domain.Client{}, func(order *domain.HistoryOrder) error {
+		clients = append(clients, order.Client.ClientName)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Misha", "Sasha"}, clients)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	keys, err := New(&config.Auth{Keys: []config.APIKey{{Name: "misha", Hash: Hash("tm_misha"), Client: "Misha"}}})
+	require.NoError(t, err)
+	interceptor := UnaryServerInterceptor(keys)
+	handler := func(ctx context.Context, req any) (any, error) {
+		return FromContext(ctx), nil
+	}
+
+	tests := []struct {
+		name         string
+		md           metadata.MD
+		expectedCode codes.Code
+	}{
+		{name: "API key", md: metadata.Pairs("x-api-key", "tm_misha"), expectedCode: codes.OK},
+		{name: "Bearer", md: metadata.Pairs("authorization", "Bearer tm_misha"), expectedCode: codes.OK},
+		{name: "Missing", md: metadata.MD{}, expectedCode: codes.Unauthenticated},
+		{name: "Invalid", md: metadata.Pairs("x-api-key", "tm_sasha"), expectedCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			res, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, "Misha", res.(*Key).Client)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+// Scope wraps repo so that callers with a client key can only read and
+// write orders of their client, others get repository.ErrForbidden. Order
+// books are market data and stay open to every key.
+func Scope(repo *repository.Repository) *repository.Repository {
+	return &repository.Repository{
+		Orderbook:    repo.Orderbook,
+		Orderhistory: &orderHistoryScope{Orderhistory: repo.Orderhistory},
+	}
+}
+
+type orderHistoryScope struct {
+	repository.Orderhistory
+}
+
+func (o *orderHistoryScope) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
+	if !Allowed(ctx, client.ClientName) {
+		return nil, repository.ErrForbidden
+	}
+	return o.Orderhistory.GetOrderHistory(ctx, client)
+}
+
+func (o *orderHistoryScope) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	for _, client := range clients {
+		if !Allowed(ctx, client.ClientName) {
+			return nil, repository.ErrForbidden
+		}
+	}
+	return o.Orderhistory.GetOrderHistories(ctx, clients, limit)
+}
+
+func (o *orderHistoryScope) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	if !Allowed(ctx, order.Client.ClientName) {
+		return repository.ErrForbidden
+	}
+	return o.Orderhistory.SaveOrder(ctx, order)
+}
+
+func (o *orderHistoryScope) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	for _, order := range orders {
+		if !Allowed(ctx, order.Client.ClientName) {
+			return repository.ErrForbidden
+		}
+	}
+	return o.Orderhistory.SaveOrders(ctx, orders)
+}
+
+// ExportOrders limits exports of client keys without a client filter to
+// their client.
+func (o *orderHistoryScope) ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+	if key := FromContext(ctx); key != nil && !key.Admin && filter.ClientName == "" {
+		scoped := *filter
+		scoped.ClientName = key.Client
+		filter = &scoped
+	}
+	if !Allowed(ctx, filter.ClientName) {
+		return repository.ErrForbidden
+	}
+	return o.Orderhistory.ExportOrders(ctx, filter, fn)
+}
@@ -0,0 +1,26 @@
+package broker
+
+import "errors"
+
+var ErrClosed = errors.New("broker is closed")
+
+// Message is a single delivery from a subject. A message that is neither
+// acked nor nacked within the broker's ack wait is delivered again.
+type Message interface {
+	Subject() string
+	Data() []byte
+	Ack() error
+	Nack() error
+}
+
+type Handler func(msg Message)
+
+type Subscription interface {
+	Unsubscribe() error
+}
+
+type Broker interface {
+	Publish(subject string, data []byte) error
+	Subscribe(subject, group string, handler Handler) (Subscription, error)
+	Close() error
+}
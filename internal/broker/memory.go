@@ -0,0 +1,196 @@
+package broker
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory is an in-process broker. Every group subscribed to a subject gets
+// its own copy of each message, and members of one group share the work.
+// Messages published to a subject without subscribers are dropped.
+type Memory struct {
+	ackWait         time.Duration
+	redeliveryDelay time.Duration
+
+	mu     sync.Mutex
+	groups map[string]map[string]*memoryGroup
+	closed bool
+}
+
+func NewMemory(ackWait, redeliveryDelay time.Duration) *Memory {
+	return &Memory{
+		ackWait:         ackWait,
+		redeliveryDelay: redeliveryDelay,
+		groups:          make(map[string]map[string]*memoryGroup),
+	}
+}
+
+func (b *Memory) Publish(subject string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	for _, g := range b.groups[subject] {
+		g.push(&memoryEntry{subject: subject, data: append([]byte(nil), data...)})
+	}
+	return nil
+}
+
+func (b *Memory) Subscribe(subject, group string, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	groups, ok := b.groups[subject]
+	if !ok {
+		groups = make(map[string]*memoryGroup)
+		b.groups[subject] = groups
+	}
+	g, ok := groups[group]
+	if !ok {
+		g = newMemoryGroup()
+		groups[group] = g
+	}
+	sub := &memorySubscription{broker: b, group: g, handler: handler, done: make(chan struct{})}
+	go sub.run()
+	return sub, nil
+}
+
+func (b *Memory) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for _, groups := range b.groups {
+		for _, g := range groups {
+			g.close()
+		}
+	}
+	return nil
+}
+
+type memoryEntry struct {
+	subject string
+	data    []byte
+}
+
+type memoryGroup struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*memoryEntry
+	closed  bool
+}
+
+func newMemoryGroup() *memoryGroup {
+	g := &memoryGroup{}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+func (g *memoryGroup) push(e *memoryEntry) {
+	g.mu.Lock()
+	g.pending = append(g.pending, e)
+	g.mu.Unlock()
+	g.cond.Signal()
+}
+
+// pop blocks until an entry is available, the group is closed or stopped
+// returns true.
+func (g *memoryGroup) pop(stopped func() bool) (*memoryEntry, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for len(g.pending) == 0 && !g.closed && !stopped() {
+		g.cond.Wait()
+	}
+	if g.closed || stopped() {
+		return nil, false
+	}
+	e := g.pending[0]
+	g.pending = g.pending[1:]
+	return e, true
+}
+
+func (g *memoryGroup) close() {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+type memorySubscription struct {
+	broker  *Memory
+	group   *memoryGroup
+	handler Handler
+	once    sync.Once
+	done    chan struct{}
+}
+
+func (s *memorySubscription) stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *memorySubscription) run() {
+	for {
+		e, ok := s.group.pop(s.stopped)
+		if !ok {
+			return
+		}
+		d := &memoryDelivery{entry: e, result: make(chan bool, 1)}
+		s.handler(d)
+		var acked bool
+		select {
+		case acked = <-d.result:
+		case <-time.After(s.broker.ackWait):
+		case <-s.done:
+		}
+		if !acked {
+			s.redeliver(e)
+		}
+	}
+}
+
+func (s *memorySubscription) redeliver(e *memoryEntry) {
+	if s.broker.redeliveryDelay <= 0 {
+		s.group.push(e)
+		return
+	}
+	time.AfterFunc(s.broker.redeliveryDelay, func() { s.group.push(e) })
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.group.mu.Lock()
+		close(s.done)
+		s.group.mu.Unlock()
+		s.group.cond.Broadcast()
+	})
+	return nil
+}
+
+type memoryDelivery struct {
+	entry  *memoryEntry
+	once   sync.Once
+	result chan bool
+}
+
+func (d *memoryDelivery) Subject() string { return d.entry.subject }
+func (d *memoryDelivery) Data() []byte    { return d.entry.data }
+
+func (d *memoryDelivery) Ack() error {
+	d.once.Do(func() { d.result <- true })
+	return nil
+}
+
+func (d *memoryDelivery) Nack() error {
+	d.once.Do(func() { d.result <- false })
+	return nil
+}
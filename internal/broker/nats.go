@@ -0,0 +1,112 @@
+package broker
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATS consumes from JetStream streams that already exist on the server.
+// Each subject of a group maps to a durable queue consumer, so restarts resume
+// from the last acknowledged message. The consumers are created here and bound to,
+// never by the subscription, so unsubscribing and closing keep them.
+type NATS struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	ackWait time.Duration
+}
+
+func NewNATS(url string, ackWait time.Duration) (*NATS, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, errors.New("failed to connect to NATS: " + err.Error())
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, errors.New("failed to get JetStream context: " + err.Error())
+	}
+	return &NATS{
+		conn:    conn,
+		js:      js,
+		ackWait: ackWait,
+	}, nil
+}
+
+func (b *NATS) Publish(subject string, data []byte) error {
+	if _, err := b.js.Publish(subject, data); err != nil {
+		return errors.New("failed to publish message: " + err.Error())
+	}
+	return nil
+}
+
+func (b *NATS) Subscribe(subject, group string, handler Handler) (Subscription, error) {
+	stream, err := b.js.StreamNameBySubject(subject)
+	if err != nil {
+		return nil, errors.New("failed to find stream of " + subject + ": " + err.Error())
+	}
+	durable := consumerName(subject, group)
+	if err := b.addConsumer(stream, subject, group, durable); err != nil {
+		return nil, err
+	}
+	sub, err := b.js.QueueSubscribe(subject, group, func(m *nats.Msg) {
+		handler(natsMessage{msg: m})
+	}, nats.Bind(stream, durable), nats.ManualAck())
+	if err != nil {
+		return nil, errors.New("failed to subscribe to " + subject + ": " + err.Error())
+	}
+	return sub, nil
+}
+
+// consumerName is the durable of group for subject. Subjects of one group
+// may share a stream, so each gets its own consumer.
+func consumerName(subject, group string) string {
+	return group + "-" + strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t':
+			return '_'
+		}
+		return r
+	}, subject)
+}
+
+// addConsumer creates the durable consumer on stream, or updates the ack
+// wait of the one created by an earlier run. Its deliver subject is derived
+// from the names, so instances of a group share the consumer.
+func (b *NATS) addConsumer(stream, subject, group, durable string) error {
+	cfg := &nats.ConsumerConfig{
+		Durable:        durable,
+		DeliverSubject: "_deliver." + stream + "." + durable,
+		DeliverGroup:   group,
+		FilterSubject:  subject,
+		AckPolicy:      nats.AckExplicitPolicy,
+		AckWait:        b.ackWait,
+	}
+	info, err := b.js.ConsumerInfo(stream, durable)
+	switch {
+	case errors.Is(err, nats.ErrConsumerNotFound):
+		_, err = b.js.AddConsumer(stream, cfg)
+	case err == nil:
+		cfg.DeliverSubject = info.Config.DeliverSubject
+		_, err = b.js.UpdateConsumer(stream, cfg)
+	}
+	if err != nil {
+		return errors.New("failed to create consumer " + durable + " on " + stream + ": " + err.Error())
+	}
+	return nil
+}
+
+func (b *NATS) Close() error {
+	return b.conn.Drain()
+}
+
+type natsMessage struct {
+	msg *nats.Msg
+}
+
+func (m natsMessage) Subject() string { return m.msg.Subject }
+func (m natsMessage) Data() []byte    { return m.msg.Data }
+func (m natsMessage) Ack() error      { return m.msg.Ack() }
+func (m natsMessage) Nack() error     { return m.msg.Nak() }
@@ -0,0 +1,121 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runJetStream(t *testing.T) *server.Server {
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func TestNATS_ResumesAfterRestart(t *testing.T) {
+	s := runJetStream(t)
+	conn, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+	js, err := conn.JetStream()
+	require.NoError(t, err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"orders"}})
+	require.NoError(t, err)
+
+	received := make(chan string, 10)
+	handler := func(msg Message) {
+		received <- string(msg.Data())
+		msg.Ack()
+	}
+
+	b, err := NewNATS(s.ClientURL(), time.Second)
+	require.NoError(t, err)
+	sub, err := b.Subscribe("orders", "writer", handler)
+	require.NoError(t, err)
+	require.NoError(t, b.Publish("orders", []byte("1")))
+	select {
+	case data := <-received:
+		assert.Equal(t, "1", data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not delivered")
+	}
+	require.NoError(t, sub.Unsubscribe())
+	require.NoError(t, b.Close())
+
+	// published while no instance runs
+	_, err = js.Publish("orders", []byte("2"))
+	require.NoError(t, err)
+	info, err := js.ConsumerInfo("ORDERS", "writer-orders")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.AckFloor.Stream)
+
+	b, err = NewNATS(s.ClientURL(), time.Second)
+	require.NoError(t, err)
+	defer b.Close()
+	_, err = b.Subscribe("orders", "writer", handler)
+	require.NoError(t, err)
+	select {
+	case data := <-received:
+		assert.Equal(t, "2", data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not delivered after restart")
+	}
+	select {
+	case data := <-received:
+		t.Fatalf("message %s delivered twice", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNATS_SubjectsOfOneStream(t *testing.T) {
+	s := runJetStream(t)
+	conn, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+	js, err := conn.JetStream()
+	require.NoError(t, err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+	require.NoError(t, err)
+
+	b, err := NewNATS(s.ClientURL(), time.Second)
+	require.NoError(t, err)
+	defer b.Close()
+	received := map[string]chan string{"orders.history": make(chan string, 10), "orders.book": make(chan string, 10)}
+	for subject, ch := range received {
+		ch := ch
+		_, err := b.Subscribe(subject, "trade-metrics", func(msg Message) {
+			ch <- msg.Subject()
+			msg.Ack()
+		})
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Publish("orders.history", []byte("order")))
+		require.NoError(t, b.Publish("orders.book", []byte("book")))
+	}
+	for subject, ch := range received {
+		for i := 0; i < 3; i++ {
+			select {
+			case got := <-ch:
+				assert.Equal(t, subject, got)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("message of %s not delivered", subject)
+			}
+		}
+	}
+	for subject, ch := range received {
+		select {
+		case got := <-ch:
+			t.Fatalf("handler of %s received %s", subject, got)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
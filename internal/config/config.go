@@ -1,49 +1,353 @@
 package config
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	ClickHouse ClickHouse
-	Server     Server `mapstructure:"server"`
+	Repository Repository  `mapstructure:"repository"`
+	Migrations Migrations  `mapstructure:"migrations"`
+	ClickHouse ClickHouse  `mapstructure:"clickhouse"`
+	Server     Server      `mapstructure:"server"`
+	Health     Health      `mapstructure:"health"`
+	Metrics    Metrics     `mapstructure:"metrics"`
+	Tracing    Tracing     `mapstructure:"tracing"`
+	Auth       Auth        `mapstructure:"auth"`
+	RateLimit  RateLimit   `mapstructure:"rateLimit"`
+	Consumer   Consumer    `mapstructure:"consumer"`
+	Import     Import      `mapstructure:"import"`
+	Connectors []Connector `mapstructure:"connectors"`
+	FIX        FIX         `mapstructure:"fix"`
+	Webhooks   Webhooks    `mapstructure:"webhooks"`
+	Spool      Spool       `mapstructure:"spool"`
+	Stream     Stream      `mapstructure:"stream"`
+	GraphQL    GraphQL     `mapstructure:"graphql"`
+	OpenAPI    OpenAPI     `mapstructure:"openapi"`
+	Export     Export      `mapstructure:"export"`
 }
 
+type Repository struct {
+	Backend  string   `mapstructure:"backend"`
+	Timeouts Timeouts `mapstructure:"timeouts"`
+	SQLite   SQLite   `mapstructure:"sqlite"`
+	Postgres Postgres `mapstructure:"postgres"`
+}
+
+// Migrations with Auto are applied on startup, otherwise the app refuses to
+// start until the schema has the version of the embedded migrations.
+type Migrations struct {
+	Auto bool `mapstructure:"auto"`
+}
+
+// Timeouts limit single repository operations, 0 means no limit.
+type Timeouts struct {
+	Read   time.Duration `mapstructure:"read"`
+	Write  time.Duration `mapstructure:"write"`
+	Export time.Duration `mapstructure:"export"`
+}
+
+type SQLite struct {
+	Path string `mapstructure:"path"`
+}
+
+// Postgres is overridden by POSTGRES_DSN, to keep the password out of the
+// config file.
+type Postgres struct {
+	DSN string `mapstructure:"dsn" envconfig:"DSN"`
+}
+
+// Server trusts X-Forwarded-For for the client IP only from TrustedProxies,
+// IPs or CIDRs, by default from none.
 type Server struct {
-	Port         string        `mapstructure:"port"`
-	ReadTimeout  time.Duration `mapstructure:"readTimeout"`
-	WriteTimeout time.Duration `mapstructure:"writeTimeout"`
+	Port           string        `mapstructure:"port"`
+	ReadTimeout    time.Duration `mapstructure:"readTimeout"`
+	WriteTimeout   time.Duration `mapstructure:"writeTimeout"`
+	GRPCPort       string        `mapstructure:"grpcPort"`
+	TrustedProxies []string      `mapstructure:"trustedProxies"`
+}
+
+// Health limits the readiness checks to Timeout. On shutdown /readyz fails
+// for DrainDelay before the server stops accepting connections.
+type Health struct {
+	Timeout    time.Duration `mapstructure:"timeout"`
+	DrainDelay time.Duration `mapstructure:"drainDelay"`
+}
+
+type Metrics struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// Tracing exports spans to an OTLP gRPC collector at Endpoint with Exporter
+// "otlp" or to standard output with "stdout", an empty Exporter disables
+// tracing.
+type Tracing struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"serviceName"`
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
+
+// Auth with Enabled requires an API key on every route except the probes,
+// metrics and docs.
+type Auth struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Keys    []APIKey `mapstructure:"keys"`
+}
+
+// APIKey is stored as the hex SHA-256 of the key. A key with a Client only
+// has access to the orders of that client, an Admin key to everything.
+type APIKey struct {
+	Name   string `mapstructure:"name"`
+	Hash   string `mapstructure:"hash"`
+	Client string `mapstructure:"client"`
+	Admin  bool   `mapstructure:"admin"`
+}
+
+// RateLimit gives every client, its API key or without one its IP, a token
+// bucket of Burst requests refilled with Rate requests per second for each
+// group of routes. Routes of no group share a bucket with the top-level
+// limits. A zero Rate means no limit.
+type RateLimit struct {
+	Enabled bool             `mapstructure:"enabled"`
+	Rate    float64          `mapstructure:"rate"`
+	Burst   int              `mapstructure:"burst"`
+	Groups  []RateLimitGroup `mapstructure:"groups"`
+}
+
+// RateLimitGroup matches requests with one of Methods, all when empty, to a
+// route starting with one of Paths, e.g. /orderbook for
+// /orderbook/:exchangeName/:pair/. The first matching group counts.
+type RateLimitGroup struct {
+	Name    string   `mapstructure:"name"`
+	Methods []string `mapstructure:"methods"`
+	Paths   []string `mapstructure:"paths"`
+	Rate    float64  `mapstructure:"rate"`
+	Burst   int      `mapstructure:"burst"`
+}
+
+type Consumer struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	Broker            string        `mapstructure:"broker"`
+	URL               string        `mapstructure:"url"`
+	Group             string        `mapstructure:"group"`
+	OrdersSubject     string        `mapstructure:"ordersSubject"`
+	OrderBooksSubject string        `mapstructure:"orderBooksSubject"`
+	AckWait           time.Duration `mapstructure:"ackWait"`
+	RedeliveryDelay   time.Duration `mapstructure:"redeliveryDelay"`
+}
+
+type Import struct {
+	BatchSize  int               `mapstructure:"batchSize"`
+	TimeLayout string            `mapstructure:"timeLayout"`
+	Columns    map[string]string `mapstructure:"columns"`
+}
+
+type Export struct {
+	Enabled   bool `mapstructure:"enabled"`
+	ChunkSize int  `mapstructure:"chunkSize"`
+}
+
+type Connector struct {
+	Exchange     string        `mapstructure:"exchange"`
+	Type         string        `mapstructure:"type"`
+	WSURL        string        `mapstructure:"wsURL"`
+	RESTURL      string        `mapstructure:"restURL"`
+	Stream       string        `mapstructure:"stream"`
+	Pairs        []string      `mapstructure:"pairs"`
+	Depth        int           `mapstructure:"depth"`
+	SaveInterval time.Duration `mapstructure:"saveInterval"`
+	MinBackoff   time.Duration `mapstructure:"minBackoff"`
+	MaxBackoff   time.Duration `mapstructure:"maxBackoff"`
+}
+
+type FIX struct {
+	Sessions []FIXSession `mapstructure:"sessions"`
+}
+
+type FIXSession struct {
+	Name              string        `mapstructure:"name"`
+	Mode              string        `mapstructure:"mode"`
+	Address           string        `mapstructure:"address"`
+	SenderCompID      string        `mapstructure:"senderCompID"`
+	TargetCompID      string        `mapstructure:"targetCompID"`
+	HeartBtInt        time.Duration `mapstructure:"heartBtInt"`
+	ReconnectInterval time.Duration `mapstructure:"reconnectInterval"`
+	ResetOnLogon      bool          `mapstructure:"resetOnLogon"`
+	MaxBodyLength     int           `mapstructure:"maxBodyLength"`
+	SeqStore          string        `mapstructure:"seqStore"`
+	Exchange          string        `mapstructure:"exchange"`
+	Label             string        `mapstructure:"label"`
+	Tags              FIXTags       `mapstructure:"tags"`
+}
+
+// FIXTags maps order fields to ExecutionReport tags. A zero tag falls back
+// to the session's Exchange and Label or leaves the field empty.
+type FIXTags struct {
+	Client    int `mapstructure:"client"`
+	Exchange  int `mapstructure:"exchange"`
+	Label     int `mapstructure:"label"`
+	Pair      int `mapstructure:"pair"`
+	Algorithm int `mapstructure:"algorithm"`
+}
+
+type Webhooks struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	StoreFile      string        `mapstructure:"storeFile"`
+	DeadLetterFile string        `mapstructure:"deadLetterFile"`
+	Workers        int           `mapstructure:"workers"`
+	QueueSize      int           `mapstructure:"queueSize"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+	MaxAttempts    int           `mapstructure:"maxAttempts"`
+	RetryInitial   time.Duration `mapstructure:"retryInitial"`
+	RetryMax       time.Duration `mapstructure:"retryMax"`
+}
+
+type Spool struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Dir            string        `mapstructure:"dir"`
+	ReplayInterval time.Duration `mapstructure:"replayInterval"`
+	MaxAttempts    int           `mapstructure:"maxAttempts"`
+}
+
+type Stream struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	SendBuffer       int           `mapstructure:"sendBuffer"`
+	ReplayBuffer     int           `mapstructure:"replayBuffer"`
+	MaxSubscriptions int           `mapstructure:"maxSubscriptions"`
+	PingInterval     time.Duration `mapstructure:"pingInterval"`
+	PongWait         time.Duration `mapstructure:"pongWait"`
+	WriteTimeout     time.Duration `mapstructure:"writeTimeout"`
+}
+
+// GraphQL limits are checked before a query runs, DefaultListSize is the
+// assumed length of lists the query does not bound.
+type GraphQL struct {
+	Enabled         bool `mapstructure:"enabled"`
+	MaxComplexity   int  `mapstructure:"maxComplexity"`
+	MaxDepth        int  `mapstructure:"maxDepth"`
+	DefaultListSize int  `mapstructure:"defaultListSize"`
 }
 
+type OpenAPI struct {
+	Enabled           bool `mapstructure:"enabled"`
+	ValidateResponses bool `mapstructure:"validateResponses"`
+	StrictResponses   bool `mapstructure:"strictResponses"`
+}
+
+// ClickHouse is read from the clickhouse section and overridden by DB_*
+// variables, e.g. DB_HOST, DB_ADDRESSES=ch1:9000,ch2:9000 or
+// DB_TLS_CA_FILE. Zero values leave the driver defaults.
 type ClickHouse struct {
-	Host     string
-	Port     string
-	Username string
-	Password string
-	DBName   string
+	Host string `mapstructure:"host"`
+	// Port defaults to 9000, 9440 with TLS.
+	Port string `mapstructure:"port"`
+	// Addresses of replicas as host:port, used instead of Host and Port and
+	// connected to in the order of ConnOpenStrategy.
+	Addresses []string `mapstructure:"addresses"`
+	Username  string   `mapstructure:"username"`
+	Password  string   `mapstructure:"password"`
+	DBName    string   `mapstructure:"dbName"`
+	// ConnOpenStrategy is in_order (default), round_robin or random.
+	ConnOpenStrategy string        `mapstructure:"connOpenStrategy" split_words:"true"`
+	DialTimeout      time.Duration `mapstructure:"dialTimeout" split_words:"true"`
+	ReadTimeout      time.Duration `mapstructure:"readTimeout" split_words:"true"`
+	MaxOpenConns     int           `mapstructure:"maxOpenConns" split_words:"true"`
+	MaxIdleConns     int           `mapstructure:"maxIdleConns" split_words:"true"`
+	ConnMaxLifetime  time.Duration `mapstructure:"connMaxLifetime" split_words:"true"`
+	// Compression of blocks is none (default), lz4 or zstd.
+	Compression string        `mapstructure:"compression"`
+	TLS         ClickHouseTLS `mapstructure:"tls"`
+	// Settings are sent with every query, see
+	// https://clickhouse.com/docs/en/operations/settings/settings
+	Settings map[string]any `mapstructure:"settings" ignored:"true"`
+}
+
+// ClickHouseTLS verifies the server against the system roots, or CAFile if
+// set. CertFile and KeyFile are the client certificate.
+type ClickHouseTLS struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"caFile" split_words:"true"`
+	CertFile           string `mapstructure:"certFile" split_words:"true"`
+	KeyFile            string `mapstructure:"keyFile" split_words:"true"`
+	ServerName         string `mapstructure:"serverName" split_words:"true"`
+	InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify" split_words:"true"`
 }
 
 func New(path string, fileName string) (*Config, error) {
-	var cfg *Config
 	godotenv.Load()
 	viper.SetConfigName(fileName)
 	viper.AddConfigPath(path)
+	return read()
+}
+
+// Watch calls fn with the config read again whenever the config file
+// changes or reload receives, e.g. on SIGHUP, until ctx ends. Connections
+// are not reopened, so only settings which are looked up while running can
+// take effect.
+func Watch(ctx context.Context, reload <-chan os.Signal, fn func(cfg *Config, err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.New("failed to watch config file: " + err.Error())
+	}
+	file := filepath.Clean(viper.ConfigFileUsed())
+	// the directory is watched, since editors and Kubernetes replace the
+	// file instead of writing to it
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return errors.New("failed to watch config file: " + err.Error())
+	}
+	target, _ := filepath.EvalSymlinks(file)
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+			case e := <-watcher.Events:
+				current, _ := filepath.EvalSymlinks(file)
+				changed := filepath.Clean(e.Name) == file && e.Has(fsnotify.Write|fsnotify.Create)
+				if !changed && current == target {
+					continue
+				}
+				target = current
+			case err := <-watcher.Errors:
+				fn(nil, errors.New("failed to watch config file: "+err.Error()))
+				continue
+			}
+			fn(read())
+		}
+	}()
+	return nil
+}
+
+func read() (*Config, error) {
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, errors.New("failed to read config file: " + err.Error())
 	}
+	return load()
+}
+
+func load() (*Config, error) {
+	var cfg *Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, errors.New("failed to unmarshal config: " + err.Error())
 	}
 	if err := envconfig.Process("DB", &cfg.ClickHouse); err != nil {
 		return nil, errors.New("failed to process env variables: " + err.Error())
 	}
-	fmt.Println(os.Getenv("DB_HOST"))
+	if err := envconfig.Process("POSTGRES", &cfg.Repository.Postgres); err != nil {
+		return nil, errors.New("failed to process env variables: " + err.Error())
+	}
 	return cfg, nil
 }
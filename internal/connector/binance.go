@@ -0,0 +1,235 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const binanceReadTimeout = time.Minute
+
+// binanceDepth covers both partial book depth snapshots and depthUpdate diff
+// events of the Binance websocket API.
+type binanceDepth struct {
+	Event         string      `json:"e"`
+	EventTime     int64       `json:"E"`
+	FirstUpdateID int64       `json:"U"`
+	FinalUpdateID int64       `json:"u"`
+	LastUpdateID  int64       `json:"lastUpdateId"`
+	Asks          [][2]string `json:"asks"`
+	Bids          [][2]string `json:"bids"`
+	DiffAsks      [][2]string `json:"a"`
+	DiffBids      [][2]string `json:"b"`
+}
+
+type binance struct {
+	cfg    *config.Connector
+	repo   repository.Orderbook
+	client *http.Client
+}
+
+func newBinance(cfg *config.Connector, repo repository.Orderbook) *binance {
+	return &binance{
+		cfg:    cfg,
+		repo:   repo,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (b *binance) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, pair := range b.cfg.Pairs {
+		wg.Add(1)
+		go func(pair string) {
+			defer wg.Done()
+			b.runPair(ctx, pair)
+		}(pair)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (b *binance) runPair(ctx context.Context, pair string) {
+	bo := newBackoff(b.cfg.MinBackoff, b.cfg.MaxBackoff)
+	for {
+		err := b.stream(ctx, pair, bo.reset)
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.Errorf("%s %s depth stream failed: %s", b.cfg.Exchange, pair, err.Error())
+		if err := bo.wait(ctx); err != nil {
+			return
+		}
+	}
+}
+
+type binanceStream struct {
+	*binance
+	pair     string
+	book     *book
+	synced   bool
+	lastID   int64
+	lastSave time.Time
+	// flush fires when the interval of a throttled update is over, it is nil
+	// while nothing waits to be saved
+	flush <-chan time.Time
+}
+
+func (b *binance) stream(ctx context.Context, pair string, connected func()) error {
+	url := strings.TrimSuffix(b.cfg.WSURL, "/") + "/" + strings.ToLower(pair) + "@" + b.cfg.Stream
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return errors.New("failed to connect: " + err.Error())
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			conn.SetReadDeadline(time.Now().Add(binanceReadTimeout))
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- data:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	s := &binanceStream{binance: b, pair: pair, book: newBook()}
+	for {
+		select {
+		case data := <-messages:
+			if err := s.handle(ctx, data); err != nil {
+				return err
+			}
+			connected()
+		case <-s.flush:
+			s.save(ctx)
+		case err := <-readErr:
+			// the book is still the latest one received, keep it
+			if s.flush != nil && ctx.Err() == nil {
+				s.lastSave = time.Time{}
+				s.save(ctx)
+			}
+			return errors.New("failed to read message: " + err.Error())
+		}
+	}
+}
+
+func (s *binanceStream) handle(ctx context.Context, data []byte) error {
+	var msg binanceDepth
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return errors.New("invalid depth message: " + err.Error())
+	}
+	switch {
+	case msg.Event == "depthUpdate":
+		applied, err := s.applyDiff(ctx, &msg)
+		if err != nil || !applied {
+			return err
+		}
+	case msg.LastUpdateID != 0:
+		s.book.reset()
+		if err := s.book.apply(msg.Asks, msg.Bids); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	s.save(ctx)
+	return nil
+}
+
+// applyDiff follows the Binance procedure for a local order book: diffs
+// older than the REST snapshot are dropped and any gap in update ids forces
+// a new snapshot.
+func (s *binanceStream) applyDiff(ctx context.Context, msg *binanceDepth) (bool, error) {
+	if !s.synced {
+		if err := s.loadSnapshot(ctx); err != nil {
+			return false, err
+		}
+	}
+	if msg.FinalUpdateID <= s.lastID {
+		return false, nil
+	}
+	if msg.FirstUpdateID > s.lastID+1 {
+		s.synced = false
+		return false, fmt.Errorf("update id gap: expected %d, got %d", s.lastID+1, msg.FirstUpdateID)
+	}
+	if err := s.book.apply(msg.DiffAsks, msg.DiffBids); err != nil {
+		return false, err
+	}
+	s.lastID = msg.FinalUpdateID
+	return true, nil
+}
+
+func (s *binanceStream) loadSnapshot(ctx context.Context) error {
+	url := strings.TrimSuffix(s.cfg.RESTURL, "/") + "/api/v3/depth?limit=1000&symbol=" + strings.ToUpper(s.pair)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.New("failed to create snapshot request: " + err.Error())
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.New("failed to get snapshot: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("failed to get snapshot: " + resp.Status)
+	}
+	var snapshot binanceDepth
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return errors.New("invalid snapshot: " + err.Error())
+	}
+	s.book.reset()
+	if err := s.book.apply(snapshot.Asks, snapshot.Bids); err != nil {
+		return err
+	}
+	s.lastID = snapshot.LastUpdateID
+	s.synced = true
+	return nil
+}
+
+// save stores the book at most every SaveInterval. Updates within the
+// interval are saved once it is over, so the stored book catches up with a
+// pair that goes quiet.
+func (s *binanceStream) save(ctx context.Context) {
+	if wait := s.cfg.SaveInterval - time.Since(s.lastSave); wait > 0 {
+		if s.flush == nil {
+			s.flush = time.After(wait)
+		}
+		return
+	}
+	s.flush = nil
+	s.lastSave = time.Now()
+	asksBids := s.book.snapshot(s.cfg.Depth)
+	asksBids.Id = uuid.New().ID()
+	if err := s.repo.SaveOrderBook(ctx, s.cfg.Exchange, s.pair, &asksBids); err != nil {
+		logrus.Errorf("%s %s: %s", s.cfg.Exchange, s.pair, err.Error())
+	}
+}
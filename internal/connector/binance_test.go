@@ -0,0 +1,225 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// mockBinance serves the depth websocket stream and the REST snapshot. Every
+// websocket connection gets all messages and is then closed by the server,
+// unless keepOpen is set.
+type mockBinance struct {
+	server      *httptest.Server
+	messages    []string
+	snapshot    string
+	keepOpen    bool
+	connections atomic.Int32
+}
+
+func newMockBinance(messages []string, snapshot string) *mockBinance {
+	m := &mockBinance{messages: messages, snapshot: snapshot}
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/btcusdt@depth", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		m.connections.Add(1)
+		for _, msg := range m.messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				return
+			}
+		}
+		if m.keepOpen {
+			// until the client closes the connection
+			conn.ReadMessage()
+			return
+		}
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	})
+	mux.HandleFunc("/api/v3/depth", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") != "BTCUSDT" {
+			http.Error(w, "invalid symbol", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(m.snapshot))
+	})
+	m.server = httptest.NewServer(mux)
+	return m
+}
+
+func (m *mockBinance) config() *config.Connector {
+	return &config.Connector{
+		Exchange:   "binance",
+		Type:       "binance",
+		WSURL:      "ws" + strings.TrimPrefix(m.server.URL, "http") + "/ws",
+		RESTURL:    m.server.URL,
+		Stream:     "depth",
+		Pairs:      []string{"BTCUSDT"},
+		Depth:      2,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	}
+}
+
+func TestBinance_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		snapshot string
+		expected []domain.AsksBids
+	}{
+		{
+			name: "Snapshots",
+			messages: []string{
+				`{"lastUpdateId":160,"bids":[["0.0024","10"],["0.0025","1"],["0.0022","3"]],"asks":[["0.0026","100"],["0.0027","5"]]}`,
+				`{"lastUpdateId":161,"bids":[["0.0024","9"]],"asks":[["0.0026","99"]]}`,
+			},
+			expected: []domain.AsksBids{
+				{
+					Asks: []domain.DepthOrder{{Price: 0.0026, BaseQty: 100}, {Price: 0.0027, BaseQty: 5}},
+					Bids: []domain.DepthOrder{{Price: 0.0025, BaseQty: 1}, {Price: 0.0024, BaseQty: 10}},
+				},
+				{
+					Asks: []domain.DepthOrder{{Price: 0.0026, BaseQty: 99}},
+					Bids: []domain.DepthOrder{{Price: 0.0024, BaseQty: 9}},
+				},
+			},
+		},
+		{
+			name: "Diffs on top of REST snapshot",
+			messages: []string{
+				`{"e":"depthUpdate","E":1,"s":"BTCUSDT","U":95,"u":100,"b":[["0.0024","1"]],"a":[]}`,
+				`{"e":"depthUpdate","E":2,"s":"BTCUSDT","U":99,"u":101,"b":[["0.0024","0"],["0.0023","7"]],"a":[["0.0026","1"]]}`,
+				`{"e":"depthUpdate","E":3,"s":"BTCUSDT","U":102,"u":102,"b":[],"a":[["0.0025","2"]]}`,
+			},
+			snapshot: `{"lastUpdateId":100,"bids":[["0.0024","10"]],"asks":[["0.0026","100"],["0.0027","5"]]}`,
+			expected: []domain.AsksBids{
+				{
+					Asks: []domain.DepthOrder{{Price: 0.0026, BaseQty: 1}, {Price: 0.0027, BaseQty: 5}},
+					Bids: []domain.DepthOrder{{Price: 0.0023, BaseQty: 7}},
+				},
+				{
+					Asks: []domain.DepthOrder{{Price: 0.0025, BaseQty: 2}, {Price: 0.0026, BaseQty: 1}},
+					Bids: []domain.DepthOrder{{Price: 0.0023, BaseQty: 7}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			mock := newMockBinance(tt.messages, tt.snapshot)
+			defer mock.server.Close()
+
+			saved := make(chan domain.AsksBids, 100)
+			repo := mock_repository.NewMockOrderbook(c)
+			repo.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).Return(nil).AnyTimes().
+				Do(func(_ context.Context, exchangeName, pair string, asksBids *domain.AsksBids) {
+					assert.NotZero(t, asksBids.Id)
+					asksBids.Id = 0
+					select {
+					case saved <- *asksBids:
+					default:
+					}
+				})
+
+			conn, err := New(mock.config(), repo)
+			assert.NoError(t, err)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				conn.Run(ctx)
+				close(done)
+			}()
+
+			// the server closes every connection after the last message, so the
+			// same books are saved again after each reconnect
+			for i := 0; i < 2*len(tt.expected); i++ {
+				select {
+				case asksBids := <-saved:
+					assert.Equal(t, tt.expected[i%len(tt.expected)], asksBids)
+				case <-time.After(time.Second):
+					t.Fatal("order book was not saved")
+				}
+			}
+			cancel()
+			<-done
+			assert.GreaterOrEqual(t, mock.connections.Load(), int32(2))
+		})
+	}
+}
+
+func TestBinance_SaveInterval(t *testing.T) {
+	c := gomock.NewController(t)
+	mock := newMockBinance([]string{
+		`{"lastUpdateId":160,"bids":[["0.0024","10"]],"asks":[["0.0026","100"]]}`,
+		`{"lastUpdateId":161,"bids":[["0.0024","9"]],"asks":[["0.0026","99"]]}`,
+		`{"lastUpdateId":162,"bids":[["0.0024","8"]],"asks":[["0.0026","98"]]}`,
+	}, "")
+	mock.keepOpen = true
+	defer mock.server.Close()
+
+	saved := make(chan domain.AsksBids, 10)
+	repo := mock_repository.NewMockOrderbook(c)
+	repo.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).Return(nil).AnyTimes().
+		Do(func(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) {
+			// the context of Run, cancelled on shutdown
+			assert.NotNil(t, ctx.Done())
+			asksBids.Id = 0
+			saved <- *asksBids
+		})
+
+	cfg := mock.config()
+	cfg.SaveInterval = 200 * time.Millisecond
+	conn, err := New(cfg, repo)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		conn.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// the first book is saved right away, the last one once the interval is
+	// over although no update follows
+	for _, qty := range []float64{10, 8} {
+		select {
+		case asksBids := <-saved:
+			assert.Equal(t, []domain.DepthOrder{{Price: 0.0024, BaseQty: qty}}, asksBids.Bids)
+		case <-time.After(time.Second):
+			t.Fatal("order book was not saved")
+		}
+	}
+	select {
+	case asksBids := <-saved:
+		t.Fatalf("order book saved again: %v", asksBids)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestNew_UnknownType(t *testing.T) {
+	_, err := New(&config.Connector{Exchange: "kraken"}, nil)
+	assert.EqualError(t, err, "unknown connector type: kraken")
+}
@@ -0,0 +1,79 @@
+package connector
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// book is a local copy of an exchange order book keyed by price.
+type book struct {
+	asks map[float64]float64
+	bids map[float64]float64
+}
+
+func newBook() *book {
+	return &book{
+		asks: make(map[float64]float64),
+		bids: make(map[float64]float64),
+	}
+}
+
+func (b *book) reset() {
+	clear(b.asks)
+	clear(b.bids)
+}
+
+// apply sets the quantity of every level, a zero quantity removes it.
+func (b *book) apply(asks, bids [][2]string) error {
+	if err := applyLevels(b.asks, asks); err != nil {
+		return err
+	}
+	return applyLevels(b.bids, bids)
+}
+
+func applyLevels(side map[float64]float64, levels [][2]string) error {
+	for _, level := range levels {
+		price, err := strconv.ParseFloat(level[0], 64)
+		if err != nil {
+			return errors.New("invalid price: " + level[0])
+		}
+		qty, err := strconv.ParseFloat(level[1], 64)
+		if err != nil {
+			return errors.New("invalid quantity: " + level[1])
+		}
+		if qty == 0 {
+			delete(side, price)
+		} else {
+			side[price] = qty
+		}
+	}
+	return nil
+}
+
+// snapshot returns up to depth best levels of each side, asks ascending and
+// bids descending. A non-positive depth returns the whole book.
+func (b *book) snapshot(depth int) domain.AsksBids {
+	return domain.AsksBids{
+		Asks: sortedLevels(b.asks, depth, func(a, b float64) bool { return a < b }),
+		Bids: sortedLevels(b.bids, depth, func(a, b float64) bool { return a > b }),
+	}
+}
+
+func sortedLevels(side map[float64]float64, depth int, less func(a, b float64) bool) []domain.DepthOrder {
+	prices := make([]float64, 0, len(side))
+	for price := range side {
+		prices = append(prices, price)
+	}
+	sort.Slice(prices, func(i, j int) bool { return less(prices[i], prices[j]) })
+	if depth > 0 && len(prices) > depth {
+		prices = prices[:depth]
+	}
+	levels := make([]domain.DepthOrder, len(prices))
+	for i, price := range prices {
+		levels[i] = domain.DepthOrder{Price: price, BaseQty: side[price]}
+	}
+	return levels
+}
@@ -0,0 +1,60 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+// Connector collects order books from an exchange and saves them through the
+// repository. Run blocks until ctx is cancelled and reconnects on its own
+// when the exchange drops the connection.
+type Connector interface {
+	Run(ctx context.Context) error
+}
+
+func New(cfg *config.Connector, repo repository.Orderbook) (Connector, error) {
+	kind := cfg.Type
+	if kind == "" {
+		kind = cfg.Exchange
+	}
+	switch kind {
+	case "binance":
+		return newBinance(cfg, repo), nil
+	default:
+		return nil, errors.New("unknown connector type: " + kind)
+	}
+}
+
+type backoff struct {
+	min, max, next time.Duration
+}
+
+func newBackoff(min, max time.Duration) *backoff {
+	if min <= 0 {
+		min = time.Second
+	}
+	if max < min {
+		max = min
+	}
+	return &backoff{min: min, max: max, next: min}
+}
+
+func (b *backoff) reset() {
+	b.next = b.min
+}
+
+func (b *backoff) wait(ctx context.Context) error {
+	t := time.NewTimer(b.next)
+	defer t.Stop()
+	b.next = min(b.next*2, b.max)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
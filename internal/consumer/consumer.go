@@ -0,0 +1,127 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/broker"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Consumer writes orders and order books received from the broker through
+// the repository. Messages are acked only after they are stored, so a failed
+// write is redelivered. Messages that can't be decoded are acked and dropped
+// because redelivering them would never succeed.
+type Consumer struct {
+	broker broker.Broker
+	repo   *repository.Repository
+	cfg    *config.Consumer
+	subs   []broker.Subscription
+}
+
+func New(b broker.Broker, repo *repository.Repository, cfg *config.Consumer) *Consumer {
+	return &Consumer{
+		broker: b,
+		repo:   repo,
+		cfg:    cfg,
+	}
+}
+
+func (c *Consumer) Start() error {
+	handlers := map[string]broker.Handler{
+		c.cfg.OrdersSubject:     c.handleOrder,
+		c.cfg.OrderBooksSubject: c.handleOrderBook,
+	}
+	for subject, handler := range handlers {
+		if subject == "" {
+			continue
+		}
+		sub, err := c.broker.Subscribe(subject, c.cfg.Group, handler)
+		if err != nil {
+			c.Stop()
+			return err
+		}
+		c.subs = append(c.subs, sub)
+	}
+	return nil
+}
+
+func (c *Consumer) Stop() error {
+	var errs []error
+	for _, sub := range c.subs {
+		if err := sub.Unsubscribe(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.subs = nil
+	return errors.Join(errs...)
+}
+
+func (c *Consumer) handleOrder(msg broker.Message) {
+	var order domain.HistoryOrder
+	if err := decode(msg.Data(), &order); err != nil {
+		c.drop(msg, err)
+		return
+	}
+	if order.TimePlaced.IsZero() {
+		order.TimePlaced = time.Now()
+	}
+	if err := c.repo.SaveOrder(context.Background(), &order); err != nil {
+		c.retry(msg, err)
+		return
+	}
+	c.ack(msg)
+}
+
+func (c *Consumer) handleOrderBook(msg broker.Message) {
+	var orderBook domain.OrderBook
+	if err := decode(msg.Data(), &orderBook); err != nil {
+		c.drop(msg, err)
+		return
+	}
+	asksBids := domain.AsksBids{
+		Id:   uuid.New().ID(),
+		Asks: orderBook.Asks,
+		Bids: orderBook.Bids,
+	}
+	if err := c.repo.SaveOrderBook(context.Background(), orderBook.Exchange, orderBook.Pair, &asksBids); err != nil {
+		c.retry(msg, err)
+		return
+	}
+	c.ack(msg)
+}
+
+func decode(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("invalid message body: " + err.Error())
+	}
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		return errors.New("invalid message body: " + err.Error())
+	}
+	return nil
+}
+
+func (c *Consumer) ack(msg broker.Message) {
+	if err := msg.Ack(); err != nil {
+		logrus.Errorf("failed to ack message on %s: %s", msg.Subject(), err.Error())
+	}
+}
+
+func (c *Consumer) drop(msg broker.Message, err error) {
+	logrus.Errorf("dropping message on %s: %s", msg.Subject(), err.Error())
+	c.ack(msg)
+}
+
+func (c *Consumer) retry(msg broker.Message, err error) {
+	logrus.Errorf("failed to store message on %s: %s", msg.Subject(), err.Error())
+	if err := msg.Nack(); err != nil {
+		logrus.Errorf("failed to nack message on %s: %s", msg.Subject(), err.Error())
+	}
+}
@@ -0,0 +1,139 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/broker"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testConfig = config.Consumer{
+	Group:             "test",
+	OrdersSubject:     "orders",
+	OrderBooksSubject: "books",
+}
+
+func TestConsumer_Orders(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockOrderhistory, stored chan<- *domain.HistoryOrder)
+
+	tests := []struct {
+		name          string
+		messages      []string
+		mockBehavior  mockBehavior
+		expectedSaved int
+	}{
+		{
+			name: "OK",
+			messages: []string{`{
+				"client": {"client_name": "Misha", "exchange_name": "binance", "label": "test", "pair": "BTCUSDT"},
+				"side": "buy", "type": "limit", "base_qty": 1.0, "price": 50000.0, "algorithm_name_placed": "alg1",
+				"lowest_sell_prc": 49900.0, "highest_buy_prc": 50100.0, "commission_quote_qty": 0.1
+			}`},
+			mockBehavior: func(r *mock_repository.MockOrderhistory, stored chan<- *domain.HistoryOrder) {
+				r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, order *domain.HistoryOrder) {
+					stored <- order
+				})
+			},
+			expectedSaved: 1,
+		},
+		{
+			name: "Invalid message is dropped",
+			messages: []string{
+				`{"client": {"client_name": "Misha"}}`,
+				`{"client": {"client_name": "Misha", "exchange_name": "binance", "label": "test", "pair": "BTCUSDT"},
+				"side": "sell", "type": "market", "base_qty": 2.0, "price": 50000.0, "algorithm_name_placed": "alg1",
+				"lowest_sell_prc": 49900.0, "highest_buy_prc": 50100.0, "commission_quote_qty": 0.1}`,
+			},
+			mockBehavior: func(r *mock_repository.MockOrderhistory, stored chan<- *domain.HistoryOrder) {
+				r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, order *domain.HistoryOrder) {
+					stored <- order
+				})
+			},
+			expectedSaved: 1,
+		},
+		{
+			name: "Failed write is redelivered",
+			messages: []string{`{
+				"client": {"client_name": "Misha", "exchange_name": "binance", "label": "test", "pair": "BTCUSDT"},
+				"side": "buy", "type": "limit", "base_qty": 1.0, "price": 50000.0, "algorithm_name_placed": "alg1",
+				"lowest_sell_prc": 49900.0, "highest_buy_prc": 50100.0, "commission_quote_qty": 0.1
+			}`},
+			mockBehavior: func(r *mock_repository.MockOrderhistory, stored chan<- *domain.HistoryOrder) {
+				gomock.InOrder(
+					r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(errors.New("server error")),
+					r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, order *domain.HistoryOrder) {
+						stored <- order
+					}),
+				)
+			},
+			expectedSaved: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			stored := make(chan *domain.HistoryOrder, len(tt.messages))
+			repo := mock_repository.NewMockOrderhistory(c)
+			tt.mockBehavior(repo, stored)
+
+			b := broker.NewMemory(time.Second, 0)
+			defer b.Close()
+			consumer := New(b, &repository.Repository{Orderhistory: repo}, &testConfig)
+			assert.NoError(t, consumer.Start())
+			defer consumer.Stop()
+
+			for _, msg := range tt.messages {
+				assert.NoError(t, b.Publish(testConfig.OrdersSubject, []byte(msg)))
+			}
+			for i := 0; i < tt.expectedSaved; i++ {
+				select {
+				case order := <-stored:
+					assert.Equal(t, "Misha", order.Client.ClientName)
+					assert.False(t, order.TimePlaced.IsZero())
+				case <-time.After(time.Second):
+					t.Fatal("order was not stored")
+				}
+			}
+		})
+	}
+}
+
+func TestConsumer_OrderBooks(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	stored := make(chan *domain.AsksBids, 1)
+	repo := mock_repository.NewMockOrderbook(c)
+	repo.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).Return(nil).Do(func(_ context.Context, exchangeName, pair string, asksBids *domain.AsksBids) {
+		stored <- asksBids
+	})
+
+	b := broker.NewMemory(time.Second, 0)
+	defer b.Close()
+	consumer := New(b, &repository.Repository{Orderbook: repo}, &testConfig)
+	assert.NoError(t, consumer.Start())
+	defer consumer.Stop()
+
+	msg := `{"exchange":"binance","pair":"BTCUSDT","asks":[{"price":100,"base_qty":1}],"bids":[{"price":99,"base_qty":2}]}`
+	assert.NoError(t, b.Publish(testConfig.OrderBooksSubject, []byte(msg)))
+
+	select {
+	case asksBids := <-stored:
+		assert.NotZero(t, asksBids.Id)
+		assert.Equal(t, []domain.DepthOrder{{Price: 100, BaseQty: 1}}, asksBids.Asks)
+		assert.Equal(t, []domain.DepthOrder{{Price: 99, BaseQty: 2}}, asksBids.Bids)
+	case <-time.After(time.Second):
+		t.Fatal("order book was not stored")
+	}
+}
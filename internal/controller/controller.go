@@ -3,7 +3,11 @@ package controller
 import (
 	"net/http"
 
+	"github.com/kolibriee/trade-metrics/internal/auth"
 	v1 "github.com/kolibriee/trade-metrics/internal/controller/http/v1"
+	v2 "github.com/kolibriee/trade-metrics/internal/controller/http/v2"
+	"github.com/kolibriee/trade-metrics/internal/openapi"
+	"github.com/kolibriee/trade-metrics/internal/ratelimit"
 	"github.com/kolibriee/trade-metrics/internal/repository"
 )
 
@@ -11,8 +15,26 @@ type Controller struct {
 	Handler http.Handler
 }
 
-func NewController(repo *repository.Repository) *Controller {
+// NewController serves v1 and v2 on one router. With keys every API route
+// needs an API key, with limiter its requests are rate limited, with spec
+// they are validated against the OpenAPI document.
+func NewController(repo *repository.Repository, keys *auth.Keys, limiter *ratelimit.Limiter, spec *openapi.Spec, opts ...v1.Option) *Controller {
+	var v2Opts []v2.Option
+	if keys != nil {
+		opts = append(opts, v1.WithAuth(keys))
+		v2Opts = append(v2Opts, v2.WithAuth(keys))
+	}
+	if limiter != nil {
+		opts = append(opts, v1.WithRateLimit(limiter))
+		v2Opts = append(v2Opts, v2.WithRateLimit(limiter))
+	}
+	if spec != nil {
+		opts = append(opts, v1.WithOpenAPI(spec))
+		v2Opts = append(v2Opts, v2.WithOpenAPI(spec))
+	}
+	router := v1.NewHandler(repo, opts...).InitRouterGin()
+	v2.NewHandler(repo, v2Opts...).InitRoutes(router.Group("/v2"))
 	return &Controller{
-		Handler: v1.NewHandler(repo).InitRouterGin(),
+		Handler: router,
 	}
 }
@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/auth"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	v1 "github.com/kolibriee/trade-metrics/internal/controller/http/v1"
+	"github.com/kolibriee/trade-metrics/internal/openapi"
+	"github.com/kolibriee/trade-metrics/internal/ratelimit"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_Memory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewMemoryRepository(repository.NewMemory())
+	handler := NewController(repo, nil, nil, nil, v1.WithExport(&config.Export{Enabled: true, ChunkSize: 10})).Handler
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/v2/orderbook/binance/BTCUSDT", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"not_found"`)
+
+	w = do(http.MethodPost, "/orderbook/binance/BTCUSDT/", `{"asks":[{"price":50000,"base_qty":1}],"bids":[{"price":49900,"base_qty":2}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var saved struct {
+		ID uint32 `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &saved))
+
+	w = do(http.MethodGet, "/v2/orderbook/binance/BTCUSDT", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var book struct {
+		Data struct {
+			ID   uint32 `json:"id"`
+			Asks []struct {
+				Price float64 `json:"price"`
+			} `json:"asks"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &book))
+	assert.Equal(t, saved.ID, book.Data.ID)
+	require.Len(t, book.Data.Asks, 1)
+	assert.Equal(t, float64(50000), book.Data.Asks[0].Price)
+
+	order := `{"client":{"client_name":"Misha","exchange_name":"binance","label":"test","pair":"BTCUSDT"},` +
+		`"side":"buy","type":"limit","base_qty":1,"price":50000,"algorithm_name_placed":"twap",` +
+		`"lowest_sell_prc":50001,"highest_buy_prc":49999,"commission_quote_qty":0.5}`
+	w = do(http.MethodPost, "/orderhistory/", order)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/orderhistory/?client-name=Misha&exchange-name=binance&label=test&pair=BTCUSDT", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var orders []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orders))
+	require.Len(t, orders, 1)
+	assert.Equal(t, "twap", orders[0]["algorithm_name_placed"])
+
+	w = do(http.MethodGet, "/orderhistory/?client-name=Sasha&exchange-name=binance&label=test&pair=BTCUSDT", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[]`, w.Body.String())
+
+	w = do(http.MethodGet, "/export/orders?client-name=Misha", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "Misha")
+}
+
+func TestController_Auth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.New(&config.Auth{Enabled: true, Keys: []config.APIKey{
+		{Name: "misha", Hash: auth.Hash("tm_misha"), Client: "Misha"},
+		{Name: "ops", Hash: auth.Hash("tm_ops"), Admin: true},
+	}})
+	require.NoError(t, err)
+	repo := auth.Scope(repository.NewMemoryRepository(repository.NewMemory()))
+	handler := NewController(repo, keys, nil, nil, v1.WithExport(&config.Export{Enabled: true, ChunkSize: 10})).Handler
+
+	order := func(client string) string {
+		return `{"client":{"client_name":"` + client + `","exchange_name":"binance","label":"test","pair":"BTCUSDT"},` +
+			`"side":"buy","type":"limit","base_qty":1,"price":50000,"algorithm_name_placed":"twap",` +
+			`"lowest_sell_prc":50001,"highest_buy_prc":49999,"commission_quote_qty":0.5}`
+	}
+
+	tests := []struct {
+		name               string
+		method             string
+		target             string
+		body               string
+		header             []string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{name: "probe without key", method: "GET", target: "/healthz", expectedStatusCode: 200},
+		{name: "missing key", method: "GET", target: "/orderbook/binance/BTCUSDT/", expectedStatusCode: 401, expectedBody: `{"message":"API key is required"}`},
+		{name: "invalid key", method: "GET", target: "/orderbook/binance/BTCUSDT/", header: []string{"X-API-Key", "tm_unknown"}, expectedStatusCode: 401, expectedBody: `{"message":"invalid API key"}`},
+		{name: "v2 missing key", method: "GET", target: "/v2/orderbook/binance/BTCUSDT", header: []string{"X-Request-ID", "req-1"}, expectedStatusCode: 401, expectedBody: `{"error":{"code":"unauthenticated","message":"API key is required","request_id":"req-1"}}`},
+		{name: "order books are open to clients", method: "POST", target: "/orderbook/binance/BTCUSDT/", body: `{"asks":[],"bids":[]}`, header: []string{"X-API-Key", "tm_misha"}, expectedStatusCode: 200},
+		{name: "own orders", method: "POST", target: "/orderhistory/", body: order("Misha"), header: []string{"X-API-Key", "tm_misha"}, expectedStatusCode: 200},
+		{name: "orders of another client", method: "POST", target: "/orderhistory/", body: order("Sasha"), header: []string{"X-API-Key", "tm_misha"}, expectedStatusCode: 403, expectedBody: `{"message":"forbidden"}`},
+		{name: "admin", method: "POST", target: "/orderhistory/", body: order("Sasha"), header: []string{"Authorization", "Bearer tm_ops"}, expectedStatusCode: 200},
+		{name: "read own orders", method: "GET", target: "/v2/orderhistory?client_name=Misha&exchange_name=binance&label=test&pair=BTCUSDT", header: []string{"Authorization", "Bearer tm_misha"}, expectedStatusCode: 200},
+		{name: "read orders of another client", method: "GET", target: "/v2/orderhistory?client_name=Sasha&exchange_name=binance&label=test&pair=BTCUSDT", header: []string{"X-API-Key", "tm_misha", "X-Request-ID", "req-2"}, expectedStatusCode: 403, expectedBody: `{"error":{"code":"forbidden","message":"access to the client is not allowed","request_id":"req-2"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for i := 0; i < len(tt.header); i += 2 {
+				req.Header.Set(tt.header[i], tt.header[i+1])
+			}
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code, w.Body.String())
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	// exports of a client key are limited to its client
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/export/orders", nil)
+	req.Header.Set("X-API-Key", "tm_misha")
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "Misha")
+}
+
+func TestController_RateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, err := ratelimit.New(&config.RateLimit{Enabled: true, Groups: []config.RateLimitGroup{
+		{Name: "orderbookWrites", Methods: []string{"POST"}, Paths: []string{"/orderbook", "/v2/orderbook"}, Rate: 0.1, Burst: 1},
+	}})
+	require.NoError(t, err)
+	repo := repository.NewMemoryRepository(repository.NewMemory())
+	handler := NewController(repo, nil, limiter, nil).Handler
+
+	do := func(method, target, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(`{"asks":[],"bids":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "req-1")
+		req.RemoteAddr = ip + ":1234"
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/orderbook/binance/BTCUSDT/", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodPost, "/orderbook/binance/BTCUSDT/", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+	assert.Equal(t, `{"message":"rate limit exceeded"}`, w.Body.String())
+
+	w = do(http.MethodPost, "/v2/orderbook/binance/BTCUSDT", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, `{"error":{"code":"rate_limited","message":"rate limit exceeded","request_id":"req-1"}}`, w.Body.String())
+
+	// reads and other clients have their own budget
+	w = do(http.MethodGet, "/orderbook/binance/BTCUSDT/", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodPost, "/orderbook/binance/BTCUSDT/", "10.0.0.2")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestController_OpenAPIAfterAuth checks that requests are validated only
+// after they are authenticated and rate limited, so anonymous or throttled
+// clients can't probe the validator.
+func TestController_OpenAPIAfterAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.New(&config.Auth{Enabled: true, Keys: []config.APIKey{
+		{Name: "ops", Hash: auth.Hash("tm_ops"), Admin: true},
+	}})
+	require.NoError(t, err)
+	limiter, err := ratelimit.New(&config.RateLimit{Enabled: true, Rate: 0.1, Burst: 1})
+	require.NoError(t, err)
+	spec, err := openapi.New(&config.OpenAPI{Enabled: true})
+	require.NoError(t, err)
+	repo := repository.NewMemoryRepository(repository.NewMemory())
+	handler := NewController(repo, keys, limiter, spec).Handler
+
+	do := func(target, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(`{"asks":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, target := range []string{"/orderbook/binance/BTCUSDT/", "/v2/orderbook/binance/BTCUSDT"} {
+		assert.Equal(t, http.StatusUnauthorized, do(target, "").Code, target)
+	}
+	assert.Equal(t, http.StatusBadRequest, do("/orderbook/binance/BTCUSDT/", "tm_ops").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("/orderbook/binance/BTCUSDT/", "tm_ops").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("/v2/orderbook/binance/BTCUSDT", "tm_ops").Code)
+}
+
+func TestController_RateLimitForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name                string
+		proxies             []string
+		remoteAddr          string
+		expectedStatusCodes []int
+	}{
+		{name: "no trusted proxies", remoteAddr: "10.0.0.1", expectedStatusCodes: []int{http.StatusNotFound, http.StatusTooManyRequests}},
+		{name: "untrusted remote", proxies: []string{"10.0.1.0/24"}, remoteAddr: "10.0.0.1", expectedStatusCodes: []int{http.StatusNotFound, http.StatusTooManyRequests}},
+		{name: "trusted proxy", proxies: []string{"10.0.1.0/24"}, remoteAddr: "10.0.1.5", expectedStatusCodes: []int{http.StatusNotFound, http.StatusNotFound}},
+		{name: "invalid proxies trust none", proxies: []string{"proxy.local"}, remoteAddr: "10.0.1.5", expectedStatusCodes: []int{http.StatusNotFound, http.StatusTooManyRequests}},
+	}
+	// allowed requests find no order book
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := ratelimit.New(&config.RateLimit{Enabled: true, Rate: 0.1, Burst: 1})
+			require.NoError(t, err)
+			repo := repository.NewMemoryRepository(repository.NewMemory())
+			handler := NewController(repo, nil, limiter, nil, v1.WithTrustedProxies(tt.proxies)).Handler
+
+			for i, expected := range tt.expectedStatusCodes {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "/v2/orderbook/binance/BTCUSDT", nil)
+				req.RemoteAddr = tt.remoteAddr + ":1234"
+				// every request claims to come from another client
+				req.Header.Set("X-Forwarded-For", "192.0.2."+strconv.Itoa(i+1))
+				handler.ServeHTTP(w, req)
+				assert.Equal(t, expected, w.Code, "request %d", i+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// analyzer estimates the cost of an operation before it is executed. Every
+// field costs 1 and the cost of the fields selected below a list is
+// multiplied by the expected list size: the number of requested clients, the
+// order limit, or listSize when the query does not bound the list.
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	listSize  int
+}
+
+func analyze(doc *ast.Document, operationName string, variables map[string]any, listSize int) (complexity, depth int) {
+	a := &analyzer{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		listSize:  listSize,
+	}
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			a.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operation == nil || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return 0, 0
+	}
+	return a.selectionSet(operation.SelectionSet, 1)
+}
+
+func (a *analyzer) selectionSet(set *ast.SelectionSet, depth int) (complexity, maxDepth int) {
+	if set == nil {
+		return 0, depth - 1
+	}
+	maxDepth = depth - 1
+	for _, selection := range set.Selections {
+		var cost, d int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.Name.Value == "__typename" {
+				continue
+			}
+			childCost, childDepth := a.selectionSet(s.SelectionSet, depth+1)
+			cost, d = 1+a.multiplier(s)*childCost, max(depth, childDepth)
+		case *ast.InlineFragment:
+			cost, d = a.selectionSet(s.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if fragment, ok := a.fragments[s.Name.Value]; ok {
+				cost, d = a.selectionSet(fragment.SelectionSet, depth)
+			}
+		}
+		complexity += cost
+		maxDepth = max(maxDepth, d)
+	}
+	return complexity, maxDepth
+}
+
+func (a *analyzer) multiplier(field *ast.Field) int {
+	switch field.Name.Value {
+	case "clients":
+		if n, ok := a.listLen(argument(field, "clients")); ok {
+			return max(n, 1)
+		}
+		return a.listSize
+	case "orders", "orderHistory":
+		if n, ok := a.intValue(argument(field, "limit")); ok && n > 0 {
+			return n
+		}
+		return a.listSize
+	case "asks", "bids":
+		return a.listSize
+	default:
+		return 1
+	}
+}
+
+func argument(field *ast.Field, name string) ast.Value {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value == name {
+			return arg.Value
+		}
+	}
+	return nil
+}
+
+func (a *analyzer) listLen(v ast.Value) (int, bool) {
+	switch v := v.(type) {
+	case *ast.ListValue:
+		return len(v.Values), true
+	case *ast.Variable:
+		list, ok := a.variables[v.Name.Value].([]any)
+		return len(list), ok
+	}
+	return 0, false
+}
+
+func (a *analyzer) intValue(v ast.Value) (int, bool) {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := a.variables[v.Name.Value].(type) {
+		case int:
+			return n, true
+		case float64:
+			return int(n), true
+		}
+	}
+	return 0, false
+}
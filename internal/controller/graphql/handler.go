@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type Handler struct {
+	repo   *repository.Repository
+	cfg    *config.GraphQL
+	schema graphql.Schema
+}
+
+func NewHandler(repo *repository.Repository, cfg *config.GraphQL) (*Handler, error) {
+	schema, err := newSchema()
+	if err != nil {
+		return nil, errors.New("failed to build graphql schema: " + err.Error())
+	}
+	return &Handler{
+		repo:   repo,
+		cfg:    cfg,
+		schema: schema,
+	}, nil
+}
+
+// ServeHTTP accepts queries as a JSON body or, for GET requests, as query,
+// operationName and variables parameters.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if v := query.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeResult(w, http.StatusBadRequest, errorResult("invalid variables: "+err.Error()))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeResult(w, http.StatusBadRequest, errorResult("invalid input body"))
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		writeResult(w, http.StatusBadRequest, errorResult("query is required"))
+		return
+	}
+	result, ok := h.Do(r.Context(), &req)
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusBadRequest
+	}
+	writeResult(w, status, result)
+}
+
+// Do runs a query. It returns false when the query was rejected before
+// execution.
+func (h *Handler) Do(ctx context.Context, req *Request) (*graphql.Result, bool) {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}, false
+	}
+	complexity, depth := analyze(doc, req.OperationName, req.Variables, max(h.cfg.DefaultListSize, 1))
+	if h.cfg.MaxDepth > 0 && depth > h.cfg.MaxDepth {
+		return errorResult(fmt.Sprintf("query depth %d exceeds the limit of %d", depth, h.cfg.MaxDepth)), false
+	}
+	if h.cfg.MaxComplexity > 0 && complexity > h.cfg.MaxComplexity {
+		return errorResult(fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, h.cfg.MaxComplexity)), false
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, h.repo),
+	}), true
+}
+
+func errorResult(message string) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(message)}}
+}
+
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,170 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	misha = domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"}
+	sasha = domain.Client{ClientName: "Sasha", ExchangeName: "binance", Label: "test", Pair: "ETHUSDT"}
+)
+
+func order(client domain.Client, side string, qty, price float64, placed time.Time) *domain.HistoryOrder {
+	return &domain.HistoryOrder{Client: client, Side: side, BaseQty: qty, Price: price, TimePlaced: placed}
+}
+
+func do(t *testing.T, h *Handler, query string, variables map[string]any) (int, map[string]any) {
+	t.Helper()
+	body, err := json.Marshal(Request{Query: query, Variables: variables})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", bytes.NewReader(body)))
+	var res map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return w.Code, res
+}
+
+func TestHandler_Clients(t *testing.T) {
+	c := gomock.NewController(t)
+	orderBook := mock_repository.NewMockOrderbook(c)
+	orderHistory := mock_repository.NewMockOrderhistory(c)
+	h, err := NewHandler(&repository.Repository{Orderbook: orderBook, Orderhistory: orderHistory},
+		&config.GraphQL{MaxComplexity: 1000, MaxDepth: 5, DefaultListSize: 10})
+	require.NoError(t, err)
+
+	now := time.Now()
+	// one query for both clients' latest orders, limited by the repository,
+	// and one for all orders of their stats
+	orderHistory.EXPECT().GetOrderHistories(gomock.Any(), gomock.Len(2), 1).Return([]*domain.HistoryOrder{
+		order(misha, "sell", 3, 200, now),
+		order(sasha, "buy", 2, 10, now),
+	}, nil)
+	orderHistory.EXPECT().GetOrderHistories(gomock.Any(), gomock.Len(2), 0).Return([]*domain.HistoryOrder{
+		order(misha, "buy", 1, 100, now.Add(-time.Minute)),
+		order(misha, "sell", 3, 200, now),
+		order(sasha, "buy", 2, 10, now),
+	}, nil)
+	// one query per distinct book
+	orderBook.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(&domain.AsksBids{
+		Id:   1,
+		Asks: []domain.DepthOrder{{Price: 101, BaseQty: 1}, {Price: 102, BaseQty: 1}},
+		Bids: []domain.DepthOrder{{Price: 99, BaseQty: 1}},
+	}, nil)
+	orderBook.EXPECT().GetOrderBook(gomock.Any(), "binance", "ETHUSDT").Return(&domain.AsksBids{Id: 2}, nil)
+
+	code, res := do(t, h, `query($clients: [ClientInput!]!) {
+		clients(clients: $clients) {
+			clientName
+			orders(limit: 1) { side price client { orderBook { id } } }
+			stats { count buyCount sellCount baseVolume averagePrice }
+			orderBook { id spread }
+		}
+	}`, map[string]any{"clients": []any{
+		map[string]any{"clientName": "Misha", "exchangeName": "binance", "label": "test", "pair": "BTCUSDT"},
+		map[string]any{"clientName": "Sasha", "exchangeName": "binance", "label": "test", "pair": "ETHUSDT"},
+	}})
+	require.Equal(t, 200, code, res)
+	require.Nil(t, res["errors"])
+
+	clients := res["data"].(map[string]any)["clients"].([]any)
+	require.Len(t, clients, 2)
+	first := clients[0].(map[string]any)
+	assert.Equal(t, "Misha", first["clientName"])
+	assert.Equal(t, []any{map[string]any{
+		"side": "sell", "price": 200.0, "client": map[string]any{"orderBook": map[string]any{"id": 1.0}},
+	}}, first["orders"])
+	assert.Equal(t, map[string]any{
+		"count": 2.0, "buyCount": 1.0, "sellCount": 1.0, "baseVolume": 4.0, "averagePrice": 175.0,
+	}, first["stats"])
+	assert.Equal(t, map[string]any{"id": 1.0, "spread": 2.0}, first["orderBook"])
+	second := clients[1].(map[string]any)
+	assert.Equal(t, map[string]any{"id": 2.0, "spread": nil}, second["orderBook"])
+}
+
+func TestHandler_Limits(t *testing.T) {
+	h, err := NewHandler(&repository.Repository{}, &config.GraphQL{MaxComplexity: 100, MaxDepth: 4, DefaultListSize: 50})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedMessage string
+	}{
+		{
+			name:            "Complexity",
+			query:           `{ orderHistory(client: {clientName: "a", exchangeName: "b", label: "c", pair: "d"}) { side price baseQty } }`,
+			expectedMessage: "query complexity 151 exceeds the limit of 100",
+		},
+		{
+			name:            "Depth",
+			query:           `{ clients(clients: []) { orders(limit: 1) { client { orderBook { asks { price } } } } } }`,
+			expectedMessage: "query depth 6 exceeds the limit of 4",
+		},
+		{
+			name:            "Unknown field",
+			query:           `{ orderBook(exchange: "binance", pair: "BTCUSDT") { depth } }`,
+			expectedMessage: `Cannot query field "depth" on type "AsksBids".`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, res := do(t, h, tt.query, nil)
+			assert.Equal(t, 400, code)
+			errs := res["errors"].([]any)
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.expectedMessage, errs[0].(map[string]any)["message"])
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		variables          map[string]any
+		expectedComplexity int
+		expectedDepth      int
+	}{
+		{
+			name:               "Limit",
+			query:              `{ orderHistory(client: {}, limit: 3) { side price } }`,
+			expectedComplexity: 7,
+			expectedDepth:      2,
+		},
+		{
+			name:               "Fragments",
+			query:              `{ orderHistory(client: {}) { ...fields } } fragment fields on HistoryOrder { side ... on HistoryOrder { price } }`,
+			expectedComplexity: 11,
+			expectedDepth:      2,
+		},
+		{
+			name:               "Clients variable",
+			query:              `query($c: [ClientInput!]!) { clients(clients: $c) { stats { count } } }`,
+			variables:          map[string]any{"c": []any{map[string]any{}, map[string]any{}, map[string]any{}}},
+			expectedComplexity: 7,
+			expectedDepth:      3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			require.NoError(t, err)
+			complexity, depth := analyze(doc, "", tt.variables, 5)
+			assert.Equal(t, tt.expectedComplexity, complexity)
+			assert.Equal(t, tt.expectedDepth, depth)
+		})
+	}
+}
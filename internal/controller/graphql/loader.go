@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+type loaderKey struct{}
+
+// historyLoader batches order history lookups of one request. Clients are
+// registered with prime when a list of them is resolved, the first load of
+// a limit then fetches the orders of every registered client in a single
+// query instead of one query per client. The limit is applied by the query,
+// a limit of 0 loads all orders.
+type historyLoader struct {
+	ctx  context.Context
+	repo repository.Orderhistory
+
+	mu      sync.Mutex
+	clients map[domain.Client]struct{}
+	loaded  map[historyKey][]*domain.HistoryOrder
+}
+
+type historyKey struct {
+	client domain.Client
+	limit  int
+}
+
+func newHistoryLoader(ctx context.Context, repo repository.Orderhistory) *historyLoader {
+	return &historyLoader{
+		ctx:     ctx,
+		repo:    repo,
+		clients: make(map[domain.Client]struct{}),
+		loaded:  make(map[historyKey][]*domain.HistoryOrder),
+	}
+}
+
+func (l *historyLoader) prime(clients []domain.Client) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, client := range clients {
+		l.clients[client] = struct{}{}
+	}
+}
+
+func (l *historyLoader) load(client domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if orders, ok := l.loaded[historyKey{client: client, limit: limit}]; ok {
+		return orders, nil
+	}
+	l.clients[client] = struct{}{}
+	clients := make([]*domain.Client, 0, len(l.clients))
+	for registered := range l.clients {
+		if _, ok := l.loaded[historyKey{client: registered, limit: limit}]; !ok {
+			registered := registered
+			clients = append(clients, &registered)
+		}
+	}
+	orders, err := l.repo.GetOrderHistories(l.ctx, clients, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clients {
+		l.loaded[historyKey{client: *c, limit: limit}] = []*domain.HistoryOrder{}
+	}
+	for _, order := range orders {
+		key := historyKey{client: order.Client, limit: limit}
+		if _, ok := l.loaded[key]; ok {
+			l.loaded[key] = append(l.loaded[key], order)
+		}
+	}
+	return l.loaded[historyKey{client: client, limit: limit}], nil
+}
+
+type bookKey struct {
+	exchange string
+	pair     string
+}
+
+// bookLoader fetches every order book at most once per request.
+type bookLoader struct {
+	ctx  context.Context
+	repo repository.Orderbook
+
+	mu    sync.Mutex
+	books map[bookKey]*domain.AsksBids
+}
+
+func newBookLoader(ctx context.Context, repo repository.Orderbook) *bookLoader {
+	return &bookLoader{
+		ctx:   ctx,
+		repo:  repo,
+		books: make(map[bookKey]*domain.AsksBids),
+	}
+}
+
+func (l *bookLoader) load(exchange, pair string) (*domain.AsksBids, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := bookKey{exchange: exchange, pair: pair}
+	if book, ok := l.books[key]; ok {
+		return book, nil
+	}
+	book, err := l.repo.GetOrderBook(l.ctx, exchange, pair)
+	if errors.Is(err, repository.ErrNotFound) {
+		book, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.books[key] = book
+	return book, nil
+}
+
+// loaders live as long as one request and query with its context.
+type loaders struct {
+	history *historyLoader
+	books   *bookLoader
+}
+
+func withLoaders(ctx context.Context, repo *repository.Repository) context.Context {
+	return context.WithValue(ctx, loaderKey{}, &loaders{
+		history: newHistoryLoader(ctx, repo.Orderhistory),
+		books:   newBookLoader(ctx, repo.Orderbook),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loaderKey{}).(*loaders)
+}
@@ -0,0 +1,295 @@
+package graphql
+
+import (
+	"sort"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// orderStats is the OrderStats type, AveragePrice is weighted by volume.
+type orderStats struct {
+	Count        int        `json:"count"`
+	BuyCount     int        `json:"buyCount"`
+	SellCount    int        `json:"sellCount"`
+	BaseVolume   float64    `json:"baseVolume"`
+	QuoteVolume  float64    `json:"quoteVolume"`
+	Commission   float64    `json:"commission"`
+	AveragePrice float64    `json:"averagePrice"`
+	FirstPlaced  *time.Time `json:"firstPlaced"`
+	LastPlaced   *time.Time `json:"lastPlaced"`
+}
+
+func newOrderStats(orders []*domain.HistoryOrder) *orderStats {
+	stats := &orderStats{Count: len(orders)}
+	for _, order := range orders {
+		switch order.Side {
+		case "buy":
+			stats.BuyCount++
+		case "sell":
+			stats.SellCount++
+		}
+		stats.BaseVolume += order.BaseQty
+		stats.QuoteVolume += order.BaseQty * order.Price
+		stats.Commission += order.CommissionQuoteQty
+		placed := order.TimePlaced
+		if stats.FirstPlaced == nil || placed.Before(*stats.FirstPlaced) {
+			stats.FirstPlaced = &placed
+		}
+		if stats.LastPlaced == nil || placed.After(*stats.LastPlaced) {
+			stats.LastPlaced = &placed
+		}
+	}
+	if stats.BaseVolume != 0 {
+		stats.AveragePrice = stats.QuoteVolume / stats.BaseVolume
+	}
+	return stats
+}
+
+// latest returns the most recent orders first, at most limit of them when
+// limit is positive.
+func latest(orders []*domain.HistoryOrder, limit int) []*domain.HistoryOrder {
+	res := make([]*domain.HistoryOrder, len(orders))
+	copy(res, orders)
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].TimePlaced.After(res[j].TimePlaced)
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+func clientArg(v any) domain.Client {
+	m, _ := v.(map[string]any)
+	str := func(key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	return domain.Client{
+		ClientName:   str("clientName"),
+		ExchangeName: str("exchangeName"),
+		Label:        str("label"),
+		Pair:         str("pair"),
+	}
+}
+
+func clientFrom(src any) domain.Client {
+	switch v := src.(type) {
+	case domain.Client:
+		return v
+	case *domain.Client:
+		return *v
+	}
+	return domain.Client{}
+}
+
+func newSchema() (graphql.Schema, error) {
+	depthOrder := graphql.NewObject(graphql.ObjectConfig{
+		Name: "DepthOrder",
+		Fields: graphql.Fields{
+			"price":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"baseQty": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	bestPrice := func(levels func(b *domain.AsksBids) []domain.DepthOrder, better func(a, b float64) bool) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (any, error) {
+			book, _ := p.Source.(*domain.AsksBids)
+			if book == nil {
+				return nil, nil
+			}
+			var best *float64
+			for _, level := range levels(book) {
+				price := level.Price
+				if best == nil || better(price, *best) {
+					best = &price
+				}
+			}
+			if best == nil {
+				return nil, nil
+			}
+			return *best, nil
+		}
+	}
+	asks := func(b *domain.AsksBids) []domain.DepthOrder { return b.Asks }
+	bids := func(b *domain.AsksBids) []domain.DepthOrder { return b.Bids }
+	lower := func(a, b float64) bool { return a < b }
+	higher := func(a, b float64) bool { return a > b }
+
+	asksBids := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AsksBids",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"asks":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(depthOrder)))},
+			"bids":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(depthOrder)))},
+			"bestAsk": &graphql.Field{Type: graphql.Float, Resolve: bestPrice(asks, lower)},
+			"bestBid": &graphql.Field{Type: graphql.Float, Resolve: bestPrice(bids, higher)},
+			"spread": &graphql.Field{
+				Type: graphql.Float,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					ask, _ := bestPrice(asks, lower)(p)
+					bid, _ := bestPrice(bids, higher)(p)
+					if ask == nil || bid == nil {
+						return nil, nil
+					}
+					return ask.(float64) - bid.(float64), nil
+				},
+			},
+		},
+	})
+
+	stats := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "OrderStats",
+		Description: "Aggregates over a client's orders, averagePrice is weighted by base volume.",
+		Fields: graphql.Fields{
+			"count":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"buyCount":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"sellCount":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"baseVolume":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"quoteVolume":  &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"commission":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"averagePrice": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"firstPlaced":  &graphql.Field{Type: graphql.DateTime},
+			"lastPlaced":   &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	client := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Client",
+		Fields: graphql.Fields{
+			"clientName":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"exchangeName": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"label":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"pair":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	historyOrder := graphql.NewObject(graphql.ObjectConfig{
+		Name: "HistoryOrder",
+		Fields: graphql.Fields{
+			"client":              &graphql.Field{Type: graphql.NewNonNull(client)},
+			"side":                &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"type":                &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"baseQty":             &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"price":               &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"algorithmNamePlaced": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"lowestSellPrice":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"highestBuyPrice":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"commissionQuoteQty":  &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"timePlaced":          &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	limitArg := graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Return only the most recent orders.",
+		},
+	}
+	client.AddFieldConfig("orders", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(historyOrder))),
+		Args: limitArg,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			limit, _ := p.Args["limit"].(int)
+			orders, err := loadersFrom(p.Context).history.load(clientFrom(p.Source), max(limit, 0))
+			if err != nil {
+				return nil, err
+			}
+			return latest(orders, limit), nil
+		},
+	})
+	client.AddFieldConfig("stats", &graphql.Field{
+		Type: graphql.NewNonNull(stats),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			orders, err := loadersFrom(p.Context).history.load(clientFrom(p.Source), 0)
+			if err != nil {
+				return nil, err
+			}
+			return newOrderStats(orders), nil
+		},
+	})
+	client.AddFieldConfig("orderBook", &graphql.Field{
+		Type:        asksBids,
+		Description: "The latest order book of the client's exchange and pair.",
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			c := clientFrom(p.Source)
+			return loadersFrom(p.Context).books.load(c.ExchangeName, c.Pair)
+		},
+	})
+
+	clientInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ClientInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"clientName":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"exchangeName": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"label":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"pair":         &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"orderBook": &graphql.Field{
+				Type: asksBids,
+				Args: graphql.FieldConfigArgument{
+					"exchange": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"pair":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					exchange, _ := p.Args["exchange"].(string)
+					pair, _ := p.Args["pair"].(string)
+					return loadersFrom(p.Context).books.load(exchange, pair)
+				},
+			},
+			"orderHistory": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(historyOrder))),
+				Args: graphql.FieldConfigArgument{
+					"client": &graphql.ArgumentConfig{Type: graphql.NewNonNull(clientInput)},
+					"limit":  limitArg["limit"],
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					limit, _ := p.Args["limit"].(int)
+					orders, err := loadersFrom(p.Context).history.load(clientArg(p.Args["client"]), max(limit, 0))
+					if err != nil {
+						return nil, err
+					}
+					return latest(orders, limit), nil
+				},
+			},
+			"clients": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(client))),
+				Description: "Clients with their orders, stats and order books. Orders of all listed clients are loaded in one query.",
+				Args: graphql.FieldConfigArgument{
+					"clients": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(clientInput)))},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					args, _ := p.Args["clients"].([]any)
+					clients := make([]domain.Client, 0, len(args))
+					for _, arg := range args {
+						clients = append(clients, clientArg(arg))
+					}
+					loadersFrom(p.Context).history.prime(clients)
+					return clients, nil
+				},
+			},
+			"orderStats": &graphql.Field{
+				Type: graphql.NewNonNull(stats),
+				Args: graphql.FieldConfigArgument{
+					"client": &graphql.ArgumentConfig{Type: graphql.NewNonNull(clientInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					orders, err := loadersFrom(p.Context).history.load(clientArg(p.Args["client"]), 0)
+					if err != nil {
+						return nil, err
+					}
+					return newOrderStats(orders), nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
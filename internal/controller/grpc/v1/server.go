@@ -0,0 +1,219 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	pb "github.com/kolibriee/trade-metrics/pkg/api/trademetrics/v1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements the gRPC API on the same repository as the HTTP
+// handlers. Requests are converted to domain types and validated with their
+// binding tags, like the HTTP request bodies.
+type Server struct {
+	pb.UnimplementedTradeMetricsServiceServer
+	repo *repository.Repository
+}
+
+func NewServer(repo *repository.Repository) *Server {
+	return &Server{
+		repo: repo,
+	}
+}
+
+func (s *Server) GetOrderBook(ctx context.Context, req *pb.GetOrderBookRequest) (*pb.OrderBook, error) {
+	if req.GetExchangeName() == "" || req.GetPair() == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid input")
+	}
+	orderBook, err := s.repo.GetOrderBook(ctx, req.GetExchangeName(), req.GetPair())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "order book not found")
+	}
+	if err != nil {
+		return nil, serverError(err)
+	}
+	return toOrderBook(orderBook), nil
+}
+
+func (s *Server) SaveOrderBook(ctx context.Context, req *pb.SaveOrderBookRequest) (*pb.SaveOrderBookResponse, error) {
+	if req.GetExchangeName() == "" || req.GetPair() == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid input")
+	}
+	orderBook := fromOrderBook(req.GetOrderBook())
+	if err := binding.Validator.ValidateStruct(orderBook); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid input body")
+	}
+	orderBook.Id = uuid.New().ID()
+	if err := s.repo.SaveOrderBook(ctx, req.GetExchangeName(), req.GetPair(), orderBook); err != nil {
+		return nil, serverError(err)
+	}
+	return &pb.SaveOrderBookResponse{Id: orderBook.Id}, nil
+}
+
+func (s *Server) GetOrderHistory(ctx context.Context, req *pb.GetOrderHistoryRequest) (*pb.GetOrderHistoryResponse, error) {
+	orders, err := s.orderHistory(ctx, req.GetClient())
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.GetOrderHistoryResponse{Orders: make([]*pb.HistoryOrder, 0, len(orders))}
+	for _, order := range orders {
+		res.Orders = append(res.Orders, toHistoryOrder(order))
+	}
+	return res, nil
+}
+
+// StreamOrderHistory sends the orders while they are read, so a long
+// history isn't held in memory.
+func (s *Server) StreamOrderHistory(req *pb.StreamOrderHistoryRequest, stream pb.TradeMetricsService_StreamOrderHistoryServer) error {
+	client, err := validClient(req.GetClient())
+	if err != nil {
+		return err
+	}
+	var sendErr error
+	err = s.repo.ExportOrders(stream.Context(), client, func(order *domain.HistoryOrder) error {
+		sendErr = stream.Send(toHistoryOrder(order))
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return serverError(err)
+	}
+	return nil
+}
+
+func (s *Server) SaveOrder(ctx context.Context, req *pb.SaveOrderRequest) (*pb.SaveOrderResponse, error) {
+	order := fromHistoryOrder(req.GetOrder())
+	if err := binding.Validator.ValidateStruct(order); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid input body")
+	}
+	order.TimePlaced = time.Now()
+	if err := s.repo.SaveOrder(ctx, order); err != nil {
+		return nil, serverError(err)
+	}
+	return &pb.SaveOrderResponse{}, nil
+}
+
+func (s *Server) orderHistory(ctx context.Context, c *pb.Client) ([]*domain.HistoryOrder, error) {
+	client, err := validClient(c)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.repo.GetOrderHistory(ctx, client)
+	if err != nil {
+		return nil, serverError(err)
+	}
+	return orders, nil
+}
+
+func validClient(c *pb.Client) (*domain.Client, error) {
+	client := fromClient(c)
+	if err := binding.Validator.ValidateStruct(&client); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid input")
+	}
+	return &client, nil
+}
+
+func serverError(err error) error {
+	if errors.Is(err, repository.ErrForbidden) {
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+	logrus.Error(err.Error())
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, "canceled")
+	}
+	return status.Error(codes.Internal, "server error")
+}
+
+func toOrderBook(orderBook *domain.AsksBids) *pb.OrderBook {
+	return &pb.OrderBook{
+		Id:   orderBook.Id,
+		Asks: toDepthOrders(orderBook.Asks),
+		Bids: toDepthOrders(orderBook.Bids),
+	}
+}
+
+func fromOrderBook(orderBook *pb.OrderBook) *domain.AsksBids {
+	if orderBook == nil {
+		return &domain.AsksBids{}
+	}
+	return &domain.AsksBids{
+		Asks: fromDepthOrders(orderBook.GetAsks()),
+		Bids: fromDepthOrders(orderBook.GetBids()),
+	}
+}
+
+func toDepthOrders(orders []domain.DepthOrder) []*pb.DepthOrder {
+	res := make([]*pb.DepthOrder, 0, len(orders))
+	for _, order := range orders {
+		res = append(res, &pb.DepthOrder{Price: order.Price, BaseQty: order.BaseQty})
+	}
+	return res
+}
+
+func fromDepthOrders(orders []*pb.DepthOrder) []domain.DepthOrder {
+	if orders == nil {
+		return nil
+	}
+	res := make([]domain.DepthOrder, 0, len(orders))
+	for _, order := range orders {
+		res = append(res, domain.DepthOrder{Price: order.GetPrice(), BaseQty: order.GetBaseQty()})
+	}
+	return res
+}
+
+func fromClient(client *pb.Client) domain.Client {
+	return domain.Client{
+		ClientName:   client.GetClientName(),
+		ExchangeName: client.GetExchangeName(),
+		Label:        client.GetLabel(),
+		Pair:         client.GetPair(),
+	}
+}
+
+func toHistoryOrder(order *domain.HistoryOrder) *pb.HistoryOrder {
+	return &pb.HistoryOrder{
+		Client: &pb.Client{
+			ClientName:   order.Client.ClientName,
+			ExchangeName: order.Client.ExchangeName,
+			Label:        order.Client.Label,
+			Pair:         order.Client.Pair,
+		},
+		Side:                order.Side,
+		Type:                order.Type,
+		BaseQty:             order.BaseQty,
+		Price:               order.Price,
+		AlgorithmNamePlaced: order.AlgorithmNamePlaced,
+		LowestSellPrc:       order.LowestSellPrice,
+		HighestBuyPrc:       order.HighestBuyPrice,
+		CommissionQuoteQty:  order.CommissionQuoteQty,
+		TimePlaced:          timestamppb.New(order.TimePlaced),
+	}
+}
+
+func fromHistoryOrder(order *pb.HistoryOrder) *domain.HistoryOrder {
+	return &domain.HistoryOrder{
+		Client:              fromClient(order.GetClient()),
+		Side:                order.GetSide(),
+		Type:                order.GetType(),
+		BaseQty:             order.GetBaseQty(),
+		Price:               order.GetPrice(),
+		AlgorithmNamePlaced: order.GetAlgorithmNamePlaced(),
+		LowestSellPrice:     order.GetLowestSellPrc(),
+		HighestBuyPrice:     order.GetHighestBuyPrc(),
+		CommissionQuoteQty:  order.GetCommissionQuoteQty(),
+	}
+}
@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	pb "github.com/kolibriee/trade-metrics/pkg/api/trademetrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T, repo *repository.Repository) pb.TradeMetricsServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterTradeMetricsServiceServer(s, NewServer(repo))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTradeMetricsServiceClient(conn)
+}
+
+var testClient = &pb.Client{ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"}
+
+func TestServer_SaveOrder(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockOrderhistory)
+
+	tests := []struct {
+		name         string
+		order        *pb.HistoryOrder
+		mockBehavior mockBehavior
+		expectedCode codes.Code
+	}{
+		{
+			name: "OK",
+			order: &pb.HistoryOrder{
+				Client: testClient, Side: "buy", Type: "limit", BaseQty: 1, Price: 50000, AlgorithmNamePlaced: "alg1",
+				LowestSellPrc: 49900, HighestBuyPrc: 50100, CommissionQuoteQty: 0.1,
+			},
+			mockBehavior: func(r *mock_repository.MockOrderhistory) {
+				r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *domain.HistoryOrder) error {
+					assert.Equal(t, "Misha", order.Client.ClientName)
+					assert.False(t, order.TimePlaced.IsZero())
+					return nil
+				})
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Invalid order",
+			order:        &pb.HistoryOrder{Client: testClient, Side: "buy"},
+			mockBehavior: func(r *mock_repository.MockOrderhistory) {},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Server error",
+			order: &pb.HistoryOrder{
+				Client: testClient, Side: "buy", Type: "limit", BaseQty: 1, Price: 50000, AlgorithmNamePlaced: "alg1",
+				LowestSellPrc: 49900, HighestBuyPrc: 50100, CommissionQuoteQty: 0.1,
+			},
+			mockBehavior: func(r *mock_repository.MockOrderhistory) {
+				r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(errors.New("server error"))
+			},
+			expectedCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			orderHistory := mock_repository.NewMockOrderhistory(c)
+			tt.mockBehavior(orderHistory)
+			client := newClient(t, &repository.Repository{Orderhistory: orderHistory})
+
+			_, err := client.SaveOrder(context.Background(), &pb.SaveOrderRequest{Order: tt.order})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestServer_OrderBook(t *testing.T) {
+	c := gomock.NewController(t)
+	orderBook := mock_repository.NewMockOrderbook(c)
+	client := newClient(t, &repository.Repository{Orderbook: orderBook})
+
+	var saved uint32
+	orderBook.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).DoAndReturn(
+		func(_ context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+			saved = asksBids.Id
+			return nil
+		})
+	res, err := client.SaveOrderBook(context.Background(), &pb.SaveOrderBookRequest{
+		ExchangeName: "binance",
+		Pair:         "BTCUSDT",
+		OrderBook: &pb.OrderBook{
+			Asks: []*pb.DepthOrder{{Price: 50100, BaseQty: 1}},
+			Bids: []*pb.DepthOrder{{Price: 49900, BaseQty: 2}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, saved, res.GetId())
+
+	_, err = client.SaveOrderBook(context.Background(), &pb.SaveOrderBookRequest{ExchangeName: "binance", Pair: "BTCUSDT"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	orderBook.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(&domain.AsksBids{
+		Id:   saved,
+		Asks: []domain.DepthOrder{{Price: 50100, BaseQty: 1}},
+	}, nil)
+	book, err := client.GetOrderBook(context.Background(), &pb.GetOrderBookRequest{ExchangeName: "binance", Pair: "BTCUSDT"})
+	require.NoError(t, err)
+	assert.Equal(t, saved, book.GetId())
+	assert.Equal(t, 50100.0, book.GetAsks()[0].GetPrice())
+}
+
+func TestServer_StreamOrderHistory(t *testing.T) {
+	c := gomock.NewController(t)
+	orderHistory := mock_repository.NewMockOrderhistory(c)
+	client := newClient(t, &repository.Repository{Orderhistory: orderHistory})
+
+	// the first order reaches the client before the second is read
+	received := make(chan struct{})
+	orderHistory.EXPECT().ExportOrders(gomock.Any(), &domain.Client{
+		ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT",
+	}, gomock.Any()).DoAndReturn(func(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+		if err := fn(&domain.HistoryOrder{Price: 1}); err != nil {
+			return err
+		}
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+			return errors.New("first order not received")
+		}
+		for _, price := range []float64{2, 3} {
+			if err := fn(&domain.HistoryOrder{Price: price}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	stream, err := client.StreamOrderHistory(context.Background(), &pb.StreamOrderHistoryRequest{Client: testClient})
+	require.NoError(t, err)
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	close(received)
+	prices := []float64{first.GetPrice()}
+	for {
+		order, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		prices = append(prices, order.GetPrice())
+	}
+	assert.Equal(t, []float64{1, 2, 3}, prices)
+
+	stream, err = client.StreamOrderHistory(context.Background(), &pb.StreamOrderHistoryRequest{Client: &pb.Client{ClientName: "Misha"}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
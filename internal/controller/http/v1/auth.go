@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/auth"
+)
+
+// authenticate answers 401 to requests without a valid API key and passes
+// the key on in the request context, where the repository checks the client
+// of every order against it.
+func (h *Handler) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := h.auth.Authenticate(auth.FromHeader(c.GetHeader("X-API-Key"), c.GetHeader("Authorization")))
+		if err != nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse{Message: err.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(auth.WithKey(c.Request.Context(), key))
+		c.Next()
+	}
+}
+
+// requireAdmin answers 403 to client keys.
+func requireAdmin(c *gin.Context) {
+	if !auth.IsAdmin(c.Request.Context()) {
+		c.AbortWithStatusJSON(http.StatusForbidden, errorResponse{Message: "forbidden"})
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/export"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportOrders streams the orders matching the filters of GetOrderHistory,
+// which are optional here.
+func (h *Handler) ExportOrders(c *gin.Context) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := domain.Client{
+		ClientName:   c.Query("client-name"),
+		ExchangeName: c.Query("exchange-name"),
+		Label:        c.Query("label"),
+		Pair:         c.Query("pair"),
+	}
+	w := h.exportWriter(c, "orders", format)
+	h.writeExport(c, export.Orders(c.Request.Context(), w, format, h.export.ChunkSize, h.repo, &filter))
+}
+
+func (h *Handler) ExportOrderBooks(c *gin.Context) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	w := h.exportWriter(c, "orderbooks", format)
+	h.writeExport(c, export.OrderBooks(c.Request.Context(), w, format, h.export.ChunkSize, h.repo, c.Query("exchange-name"), c.Query("pair")))
+}
+
+// exportWriter returns a writer that sets the export headers with the first
+// write, so a query failing before any row can still be answered with a 500.
+// Exports may take longer than the server's write timeout, which is lifted.
+func (h *Handler) exportWriter(c *gin.Context, name string, format export.Format) *exportWriter {
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	return &exportWriter{c: c, name: name, format: format}
+}
+
+func (h *Handler) writeExport(c *gin.Context, err error) {
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		repositoryError(c, err)
+		return
+	}
+	logrus.Errorf("export %s aborted: %s", c.Request.URL.Path, err.Error())
+	c.Abort()
+	panic(http.ErrAbortHandler)
+}
+
+type exportWriter struct {
+	c      *gin.Context
+	name   string
+	format export.Format
+}
+
+func (w *exportWriter) Write(b []byte) (int, error) {
+	if !w.c.Writer.Written() {
+		header := w.c.Writer.Header()
+		header.Set("Content-Type", w.format.ContentType())
+		header.Set("Content-Disposition", `attachment; filename="`+w.name+w.format.Extension()+`"`)
+		w.c.Writer.WriteHeader(http.StatusOK)
+	}
+	return w.c.Writer.Write(b)
+}
+
+func (w *exportWriter) Flush() {
+	w.c.Writer.Flush()
+}
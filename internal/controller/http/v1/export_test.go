@@ -0,0 +1,88 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_ExportOrders(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockOrderhistory)
+
+	tests := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			query: "?client-name=Misha&pair=BTCUSDT",
+			mockBehavior: func(r *mock_repository.MockOrderhistory) {
+				r.EXPECT().ExportOrders(gomock.Any(), &domain.Client{ClientName: "Misha", Pair: "BTCUSDT"}, gomock.Any()).DoAndReturn(
+					func(_ context.Context, filter *domain.Client, fn func(*domain.HistoryOrder) error) error {
+						return fn(&domain.HistoryOrder{
+							Client:     *filter,
+							Side:       "sell",
+							Type:       "market",
+							BaseQty:    2,
+							Price:      100,
+							TimePlaced: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+						})
+					})
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedResponseBody: "client_name,exchange_name,label,pair,side,type,base_qty,price,algorithm_name_placed,lowest_sell_prc,highest_buy_prc,commission_quote_qty,time_placed\n" +
+				"Misha,,,BTCUSDT,sell,market,2,100,,0,0,0,2024-05-01T00:00:00Z\n",
+		},
+		{
+			name:                 "unknown format",
+			query:                "?format=xlsx",
+			mockBehavior:         func(r *mock_repository.MockOrderhistory) {},
+			expectedStatusCode:   400,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"unknown export format: xlsx"}`,
+		},
+		{
+			name:  "server error",
+			query: "?format=parquet",
+			mockBehavior: func(r *mock_repository.MockOrderhistory) {
+				r.EXPECT().ExportOrders(gomock.Any(), &domain.Client{}, gomock.Any()).Return(errors.New("connection refused"))
+			},
+			expectedStatusCode:   500,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			repo := mock_repository.NewMockOrderhistory(c)
+			tt.mockBehavior(repo)
+			handler := NewHandler(&repository.Repository{Orderhistory: repo}, WithExport(&config.Export{ChunkSize: 100}))
+			r := gin.New()
+			r.GET("/export/orders", handler.ExportOrders)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/export/orders"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedResponseBody, w.Body.String())
+		})
+	}
+}
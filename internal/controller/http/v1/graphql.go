@@ -0,0 +1,7 @@
+package v1
+
+import "github.com/gin-gonic/gin"
+
+func (h *Handler) GraphQL(c *gin.Context) {
+	h.graphql.ServeHTTP(c.Writer, c.Request)
+}
@@ -1,15 +1,134 @@
 package v1
 
 import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/auth"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/health"
+	"github.com/kolibriee/trade-metrics/internal/metrics"
+	"github.com/kolibriee/trade-metrics/internal/openapi"
+	"github.com/kolibriee/trade-metrics/internal/ratelimit"
 	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/kolibriee/trade-metrics/internal/spool"
+	"github.com/kolibriee/trade-metrics/internal/stream"
+	"github.com/kolibriee/trade-metrics/internal/webhook"
 )
 
 type Handler struct {
-	repo *repository.Repository
+	repo       *repository.Repository
+	webhooks   webhook.Store
+	dispatcher *webhook.Dispatcher
+	spool      *spool.Spool
+	orderBooks *stream.OrderBooks
+	orders     *stream.Orders
+	graphql    http.Handler
+	openapi    *openapi.Spec
+	export     *config.Export
+	health     *health.Checker
+	metrics    *metrics.Metrics
+	tracing    gin.HandlerFunc
+	auth       *auth.Keys
+	limiter    *ratelimit.Limiter
+	proxies    []string
+}
+
+type Option func(h *Handler)
+
+func WithWebhooks(store webhook.Store, dispatcher *webhook.Dispatcher) Option {
+	return func(h *Handler) {
+		h.webhooks = store
+		h.dispatcher = dispatcher
+	}
+}
+
+func WithSpool(spool *spool.Spool) Option {
+	return func(h *Handler) {
+		h.spool = spool
+	}
+}
+
+func WithOrderBookStream(orderBooks *stream.OrderBooks) Option {
+	return func(h *Handler) {
+		h.orderBooks = orderBooks
+	}
+}
+
+func WithOrderStream(orders *stream.Orders) Option {
+	return func(h *Handler) {
+		h.orders = orders
+	}
+}
+
+func WithGraphQL(graphql http.Handler) Option {
+	return func(h *Handler) {
+		h.graphql = graphql
+	}
+}
+
+func WithOpenAPI(spec *openapi.Spec) Option {
+	return func(h *Handler) {
+		h.openapi = spec
+	}
+}
+
+func WithExport(cfg *config.Export) Option {
+	return func(h *Handler) {
+		h.export = cfg
+	}
+}
+
+func WithHealth(checker *health.Checker) Option {
+	return func(h *Handler) {
+		h.health = checker
+	}
+}
+
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *Handler) {
+		h.metrics = m
+	}
+}
+
+// WithTracing runs every request in the span started by middleware.
+func WithTracing(middleware gin.HandlerFunc) Option {
+	return func(h *Handler) {
+		h.tracing = middleware
+	}
 }
 
-func NewHandler(repo *repository.Repository) *Handler {
-	return &Handler{
+// WithAuth requires one of keys on every route except the probes, metrics
+// and docs.
+func WithAuth(keys *auth.Keys) Option {
+	return func(h *Handler) {
+		h.auth = keys
+	}
+}
+
+// WithTrustedProxies trusts the X-Forwarded-For and X-Real-IP headers of
+// requests from proxies, IPs or CIDRs, for the client IP. Without it the
+// headers are ignored and the client IP is the remote address.
+func WithTrustedProxies(proxies []string) Option {
+	return func(h *Handler) {
+		h.proxies = proxies
+	}
+}
+
+// WithRateLimit limits the requests of every client on every route except
+// the probes, metrics and docs.
+func WithRateLimit(limiter *ratelimit.Limiter) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+	}
+}
+
+func NewHandler(repo *repository.Repository, opts ...Option) *Handler {
+	h := &Handler{
 		repo: repo,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/health"
+	"github.com/kolibriee/trade-metrics/internal/spool"
+)
+
+type healthResponse struct {
+	Status string       `json:"status"`
+	Spool  *spool.Stats `json:"spool,omitempty"`
+}
+
+func (h *Handler) Health(c *gin.Context) {
+	res := healthResponse{Status: "ok"}
+	if h.spool != nil {
+		stats := h.spool.Stats()
+		res.Spool = &stats
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Healthz is the liveness probe, it only tells that the process serves
+// requests.
+func (h *Handler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// Readyz is the readiness probe. It answers 503 when a dependency check
+// fails or the server is draining, with ?verbose the results of the checks
+// and their latency are included.
+func (h *Handler) Readyz(c *gin.Context) {
+	report := &health.Report{Status: health.StatusOK}
+	if h.health != nil {
+		report = h.health.Ready(c.Request.Context())
+	}
+	code := http.StatusOK
+	if report.Status != health.StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	if _, verbose := c.GetQuery("verbose"); !verbose {
+		report = &health.Report{Status: report.Status}
+	}
+	c.JSON(code, report)
+}
@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/health"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/kolibriee/trade-metrics/internal/spool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Health(t *testing.T) {
+	sp, err := spool.Open(t.TempDir(), 0)
+	require.NoError(t, err)
+	defer sp.Close()
+	require.NoError(t, sp.Append(&spool.Record{Kind: spool.KindOrder, Order: &domain.HistoryOrder{}}))
+
+	tests := []struct {
+		name                 string
+		opts                 []Option
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Without spool",
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "With spool",
+			opts:                 []Option{WithSpool(sp)},
+			expectedResponseBody: fmt.Sprintf(`{"status":"ok","spool":{"records":1,"bytes":%d}}`, sp.Stats().Bytes),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&repository.Repository{}, tt.opts...)
+			r := gin.New()
+			r.GET("/health", handler.Health)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, tt.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_Healthz(t *testing.T) {
+	checker := health.NewChecker(0)
+	checker.Drain()
+	r := NewHandler(&repository.Repository{}, WithHealth(checker)).InitRouterGin()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestHandler_Readyz(t *testing.T) {
+	database := health.Check{Name: "database", Check: func(ctx context.Context) (any, error) {
+		return health.Pool{Open: 1, Idle: 1, MaxOpen: 10}, nil
+	}}
+	migrations := health.Check{Name: "migrations", Check: func(ctx context.Context) (any, error) {
+		return nil, errors.New("database schema version none doesn't match the expected version 1, run migrate up")
+	}}
+
+	tests := []struct {
+		name               string
+		checker            *health.Checker
+		drain              bool
+		target             string
+		expectedStatusCode int
+		expectedStatus     string
+		expectedChecks     []string
+	}{
+		{
+			name:               "Without checker",
+			target:             "/readyz",
+			expectedStatusCode: 200,
+			expectedStatus:     "ok",
+		},
+		{
+			name:               "Ready",
+			checker:            health.NewChecker(0, database),
+			target:             "/readyz",
+			expectedStatusCode: 200,
+			expectedStatus:     "ok",
+		},
+		{
+			name:               "Ready verbose",
+			checker:            health.NewChecker(0, database),
+			target:             "/readyz?verbose",
+			expectedStatusCode: 200,
+			expectedStatus:     "ok",
+			expectedChecks:     []string{"database:ok"},
+		},
+		{
+			name:               "Failing check",
+			checker:            health.NewChecker(0, database, migrations),
+			target:             "/readyz?verbose=1",
+			expectedStatusCode: 503,
+			expectedStatus:     "unavailable",
+			expectedChecks:     []string{"database:ok", "migrations:unavailable"},
+		},
+		{
+			name:               "Draining",
+			checker:            health.NewChecker(0, database),
+			drain:              true,
+			target:             "/readyz",
+			expectedStatusCode: 503,
+			expectedStatus:     "draining",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.checker != nil {
+				opts = append(opts, WithHealth(tt.checker))
+				if tt.drain {
+					tt.checker.Drain()
+				}
+			}
+			r := NewHandler(&repository.Repository{}, opts...).InitRouterGin()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			var report health.Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, tt.expectedStatus, report.Status)
+			var checks []string
+			for _, check := range report.Checks {
+				checks = append(checks, check.Name+":"+check.Status)
+			}
+			assert.Equal(t, tt.expectedChecks, checks)
+		})
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
-	"github.com/kolibriee/trade-metrics/internal/domain"
 )
 
 func (h *Handler) GetOrderBook(c *gin.Context) {
@@ -16,12 +16,23 @@ func (h *Handler) GetOrderBook(c *gin.Context) {
 		newErrorResponse(c, http.StatusBadRequest, errors.New("invalid input").Error())
 		return
 	}
-	orderBook, err := h.repo.GetOrderBook(exchange, pair)
+	orderBook, err := h.repo.GetOrderBook(c.Request.Context(), exchange, pair)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error()+err.Error())
+		repositoryError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, orderBook)
+	codec := responseBookCodec(c.GetHeader("Accept"))
+	c.Header("Vary", "Accept")
+	if codec == jsonBookCodec {
+		c.JSON(http.StatusOK, orderBook)
+		return
+	}
+	b, err := codec.encode(orderBook)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+		return
+	}
+	c.Data(http.StatusOK, codec.contentType(), b)
 }
 
 func (h *Handler) SaveOrderBook(c *gin.Context) {
@@ -31,18 +42,38 @@ func (h *Handler) SaveOrderBook(c *gin.Context) {
 		newErrorResponse(c, http.StatusBadRequest, errors.New("invalid input").Error())
 		return
 	}
-	var orderBook domain.AsksBids
-	if err := c.BindJSON(&orderBook); err != nil {
+	body, err := c.GetRawData()
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, errors.New("invalid input body").Error())
+		return
+	}
+	orderBook, err := requestBookCodec(c.GetHeader("Content-Type")).decode(body)
+	if err != nil || binding.Validator.ValidateStruct(orderBook) != nil {
 		newErrorResponse(c, http.StatusBadRequest, errors.New("invalid input body").Error())
 		return
 	}
 	id := uuid.New().ID()
 	orderBook.Id = id
-	if err := h.repo.SaveOrderBook(exchange, pair, &orderBook); err != nil {
+	if err := h.repo.SaveOrderBook(c.Request.Context(), exchange, pair, orderBook); err != nil {
+		repositoryError(c, err)
+		return
+	}
+	codec := responseBookCodec(c.GetHeader("Accept"))
+	c.Header("Vary", "Accept")
+	if codec.mediaType == mediaJSON {
+		c.JSON(http.StatusOK, map[string]any{
+			"id": id,
+		})
+		return
+	}
+	b, err := codec.encodeID(id)
+	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
 		return
 	}
-	c.JSON(http.StatusOK, map[string]any{
-		"id": id,
-	})
+	c.Data(http.StatusOK, codec.contentType(), b)
+}
+
+func (h *Handler) StreamOrderBooks(c *gin.Context) {
+	h.orderBooks.ServeHTTP(c.Writer, c.Request)
 }
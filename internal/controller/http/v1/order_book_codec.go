@@ -0,0 +1,263 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	pb "github.com/kolibriee/trade-metrics/pkg/api/trademetrics/v1"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	mediaJSON     = "application/json"
+	mediaProtobuf = "application/x-protobuf"
+	mediaMsgPack  = "application/msgpack"
+)
+
+var mediaAliases = map[string]string{
+	mediaJSON:                 mediaJSON,
+	mediaProtobuf:             mediaProtobuf,
+	"application/protobuf":    mediaProtobuf,
+	mediaMsgPack:              mediaMsgPack,
+	"application/x-msgpack":   mediaMsgPack,
+	"application/vnd.msgpack": mediaMsgPack,
+}
+
+// packedAsksBids is the packed layout of domain.AsksBids: prices and
+// quantities of the levels as parallel arrays.
+type packedAsksBids struct {
+	Id          uint32    `json:"id" codec:"id"`
+	AskPrices   []float64 `json:"ask_prices" codec:"ask_prices"`
+	AskBaseQtys []float64 `json:"ask_base_qtys" codec:"ask_base_qtys"`
+	BidPrices   []float64 `json:"bid_prices" codec:"bid_prices"`
+	BidBaseQtys []float64 `json:"bid_base_qtys" codec:"bid_base_qtys"`
+}
+
+// bookCodec encodes order books as JSON, Protobuf or MessagePack, with the
+// levels as objects or, with the layout=packed parameter, packed.
+type bookCodec struct {
+	mediaType string
+	packed    bool
+}
+
+var jsonBookCodec = bookCodec{mediaType: mediaJSON}
+
+// parseBookCodec returns the codec of a Content-Type or Accept media range,
+// and false for unsupported ones.
+func parseBookCodec(value string) (bookCodec, bool) {
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return bookCodec{}, false
+	}
+	var c bookCodec
+	switch mediaType {
+	case "*/*", "application/*":
+		c.mediaType = mediaJSON
+	default:
+		if c.mediaType = mediaAliases[mediaType]; c.mediaType == "" {
+			return bookCodec{}, false
+		}
+	}
+	switch params["layout"] {
+	case "", "levels":
+	case "packed":
+		c.packed = true
+	default:
+		return bookCodec{}, false
+	}
+	return c, true
+}
+
+// requestBookCodec returns the codec of a request body. Bodies without a
+// Protobuf or MessagePack Content-Type are read as JSON, like before
+// negotiation was supported.
+func requestBookCodec(contentType string) bookCodec {
+	if c, ok := parseBookCodec(contentType); ok {
+		return c
+	}
+	return jsonBookCodec
+}
+
+// responseBookCodec picks the codec of the most preferred supported media
+// range of an Accept header, JSON when none is supported.
+func responseBookCodec(accept string) bookCodec {
+	type mediaRange struct {
+		value string
+		q     float64
+	}
+	var ranges []mediaRange
+	for _, value := range strings.Split(accept, ",") {
+		q := 1.0
+		if _, params, err := mime.ParseMediaType(value); err == nil && params["q"] != "" {
+			if v, err := strconv.ParseFloat(params["q"], 64); err == nil {
+				q = v
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{value: value, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	for _, r := range ranges {
+		if c, ok := parseBookCodec(r.value); ok {
+			return c
+		}
+	}
+	return jsonBookCodec
+}
+
+func (c bookCodec) contentType() string {
+	res := c.mediaType
+	if c.packed {
+		res += "; layout=packed"
+	}
+	if c.mediaType == mediaJSON {
+		res += "; charset=utf-8"
+	}
+	return res
+}
+
+func (c bookCodec) encode(book *domain.AsksBids) ([]byte, error) {
+	switch {
+	case c.mediaType == mediaProtobuf && c.packed:
+		packed := packBook(book)
+		return proto.Marshal(&pb.PackedOrderBook{
+			Id:          packed.Id,
+			AskPrices:   packed.AskPrices,
+			AskBaseQtys: packed.AskBaseQtys,
+			BidPrices:   packed.BidPrices,
+			BidBaseQtys: packed.BidBaseQtys,
+		})
+	case c.mediaType == mediaProtobuf:
+		return proto.Marshal(&pb.OrderBook{
+			Id:   book.Id,
+			Asks: toDepthOrders(book.Asks),
+			Bids: toDepthOrders(book.Bids),
+		})
+	case c.packed:
+		return c.marshal(packBook(book))
+	}
+	return c.marshal(book)
+}
+
+// encodeID encodes the response of a saved order book.
+func (c bookCodec) encodeID(id uint32) ([]byte, error) {
+	if c.mediaType == mediaProtobuf {
+		return proto.Marshal(&pb.SaveOrderBookResponse{Id: id})
+	}
+	return c.marshal(map[string]any{"id": id})
+}
+
+// decode reads an order book. Levels missing in the body decode as empty,
+// not nil, so they pass validation like an empty JSON array.
+func (c bookCodec) decode(body []byte) (*domain.AsksBids, error) {
+	var packed packedAsksBids
+	switch {
+	case c.mediaType == mediaProtobuf && c.packed:
+		var msg pb.PackedOrderBook
+		if err := proto.Unmarshal(body, &msg); err != nil {
+			return nil, err
+		}
+		packed = packedAsksBids{
+			AskPrices:   msg.AskPrices,
+			AskBaseQtys: msg.AskBaseQtys,
+			BidPrices:   msg.BidPrices,
+			BidBaseQtys: msg.BidBaseQtys,
+		}
+	case c.mediaType == mediaProtobuf:
+		var msg pb.OrderBook
+		if err := proto.Unmarshal(body, &msg); err != nil {
+			return nil, err
+		}
+		return &domain.AsksBids{
+			Asks: fromDepthOrders(msg.Asks),
+			Bids: fromDepthOrders(msg.Bids),
+		}, nil
+	case c.packed:
+		if err := c.unmarshal(body, &packed); err != nil {
+			return nil, err
+		}
+	default:
+		var book domain.AsksBids
+		if err := c.unmarshal(body, &book); err != nil {
+			return nil, err
+		}
+		return &book, nil
+	}
+	return unpackBook(&packed)
+}
+
+func (c bookCodec) marshal(v any) ([]byte, error) {
+	if c.mediaType == mediaMsgPack {
+		var b []byte
+		err := codec.NewEncoderBytes(&b, new(codec.MsgpackHandle)).Encode(v)
+		return b, err
+	}
+	return json.Marshal(v)
+}
+
+func (c bookCodec) unmarshal(body []byte, v any) error {
+	if c.mediaType == mediaMsgPack {
+		return codec.NewDecoderBytes(body, new(codec.MsgpackHandle)).Decode(v)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func packBook(book *domain.AsksBids) *packedAsksBids {
+	packed := &packedAsksBids{
+		Id:          book.Id,
+		AskPrices:   make([]float64, len(book.Asks)),
+		AskBaseQtys: make([]float64, len(book.Asks)),
+		BidPrices:   make([]float64, len(book.Bids)),
+		BidBaseQtys: make([]float64, len(book.Bids)),
+	}
+	for i, level := range book.Asks {
+		packed.AskPrices[i], packed.AskBaseQtys[i] = level.Price, level.BaseQty
+	}
+	for i, level := range book.Bids {
+		packed.BidPrices[i], packed.BidBaseQtys[i] = level.Price, level.BaseQty
+	}
+	return packed
+}
+
+func unpackBook(packed *packedAsksBids) (*domain.AsksBids, error) {
+	if len(packed.AskPrices) != len(packed.AskBaseQtys) || len(packed.BidPrices) != len(packed.BidBaseQtys) {
+		return nil, errors.New("prices and quantities differ in length")
+	}
+	book := &domain.AsksBids{
+		Id:   packed.Id,
+		Asks: make([]domain.DepthOrder, len(packed.AskPrices)),
+		Bids: make([]domain.DepthOrder, len(packed.BidPrices)),
+	}
+	for i := range book.Asks {
+		book.Asks[i] = domain.DepthOrder{Price: packed.AskPrices[i], BaseQty: packed.AskBaseQtys[i]}
+	}
+	for i := range book.Bids {
+		book.Bids[i] = domain.DepthOrder{Price: packed.BidPrices[i], BaseQty: packed.BidBaseQtys[i]}
+	}
+	return book, nil
+}
+
+func toDepthOrders(levels []domain.DepthOrder) []*pb.DepthOrder {
+	res := make([]*pb.DepthOrder, len(levels))
+	for i, level := range levels {
+		res[i] = &pb.DepthOrder{Price: level.Price, BaseQty: level.BaseQty}
+	}
+	return res
+}
+
+func fromDepthOrders(levels []*pb.DepthOrder) []domain.DepthOrder {
+	res := make([]domain.DepthOrder, len(levels))
+	for i, level := range levels {
+		res[i] = domain.DepthOrder{Price: level.GetPrice(), BaseQty: level.GetBaseQty()}
+	}
+	return res
+}
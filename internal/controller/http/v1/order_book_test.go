@@ -2,6 +2,7 @@ package v1
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http/httptest"
@@ -16,7 +17,7 @@ import (
 )
 
 func TestHandler_GetOrderBook(t *testing.T) {
-	type mockBehavior func(r *mock_repository.Mockorderbook, exchangeName, pair string)
+	type mockBehavior func(r *mock_repository.MockOrderbook, exchangeName, pair string)
 
 	tests := []struct {
 		name                 string
@@ -30,8 +31,8 @@ func TestHandler_GetOrderBook(t *testing.T) {
 			name:          "OK",
 			exchange_name: "binance",
 			pair:          "BTCUSDT",
-			mockBehavior: func(r *mock_repository.Mockorderbook, exchangeName, pair string) {
-				r.EXPECT().GetOrderBook(exchangeName, pair).Return(&domain.AsksBids{
+			mockBehavior: func(r *mock_repository.MockOrderbook, exchangeName, pair string) {
+				r.EXPECT().GetOrderBook(gomock.Any(), exchangeName, pair).Return(&domain.AsksBids{
 					Id: 0,
 					Asks: []domain.DepthOrder{
 						{
@@ -54,7 +55,7 @@ func TestHandler_GetOrderBook(t *testing.T) {
 			name:                 "empty input",
 			exchange_name:        "binance",
 			pair:                 "",
-			mockBehavior:         func(r *mock_repository.Mockorderbook, exchangeName, pair string) {},
+			mockBehavior:         func(r *mock_repository.MockOrderbook, exchangeName, pair string) {},
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input"}`,
 		},
@@ -62,19 +63,29 @@ func TestHandler_GetOrderBook(t *testing.T) {
 			name:          "server error",
 			exchange_name: "binance",
 			pair:          "BTCUSDT",
-			mockBehavior: func(r *mock_repository.Mockorderbook, exchangeName, pair string) {
-				r.EXPECT().GetOrderBook(exchangeName, pair).Return(nil, errors.New("server error"))
+			mockBehavior: func(r *mock_repository.MockOrderbook, exchangeName, pair string) {
+				r.EXPECT().GetOrderBook(gomock.Any(), exchangeName, pair).Return(nil, errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}`,
 		},
+		{
+			name:          "timeout",
+			exchange_name: "binance",
+			pair:          "BTCUSDT",
+			mockBehavior: func(r *mock_repository.MockOrderbook, exchangeName, pair string) {
+				r.EXPECT().GetOrderBook(gomock.Any(), exchangeName, pair).Return(nil, context.DeadlineExceeded)
+			},
+			expectedStatusCode:   504,
+			expectedResponseBody: `{"message":"timeout"}`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := gomock.NewController(t)
 			defer c.Finish()
-			repo := mock_repository.NewMockorderbook(c)
+			repo := mock_repository.NewMockOrderbook(c)
 			tt.mockBehavior(repo, tt.exchange_name, tt.pair)
 			handler := NewHandler(&repository.Repository{Orderbook: repo})
 			r := gin.New()
@@ -89,8 +100,26 @@ func TestHandler_GetOrderBook(t *testing.T) {
 	}
 }
 
+func TestHandler_GetOrderBookRequestContext(t *testing.T) {
+	type key struct{}
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_repository.NewMockOrderbook(c)
+	repo.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").DoAndReturn(
+		func(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+			assert.Equal(t, "value", ctx.Value(key{}))
+			return &domain.AsksBids{}, nil
+		})
+	r := gin.New()
+	r.GET("/orderbook/:exchangeName/:pair/", NewHandler(&repository.Repository{Orderbook: repo}).GetOrderBook)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/orderbook/binance/BTCUSDT/", nil)
+	r.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), key{}, "value")))
+	assert.Equal(t, 200, w.Code)
+}
+
 func TestHandler_SaveOrderBook(t *testing.T) {
-	type mockBehavior func(r *mock_repository.Mockorderbook, exchangeName, pair string, asksBids *domain.AsksBids)
+	type mockBehavior func(r *mock_repository.MockOrderbook, exchangeName, pair string, asksBids *domain.AsksBids)
 
 	tests := []struct {
 		name                 string
@@ -113,8 +142,8 @@ func TestHandler_SaveOrderBook(t *testing.T) {
 				Asks: []domain.DepthOrder{{Price: 100, BaseQty: 1}, {Price: 110, BaseQty: 5}},
 				Bids: []domain.DepthOrder{{Price: 99, BaseQty: 2}},
 			},
-			mockBehavior: func(r *mock_repository.Mockorderbook, exchangeName, pair string, asksBids *domain.AsksBids) {
-				r.EXPECT().SaveOrderBook(exchangeName, pair, gomock.Any()).Return(nil)
+			mockBehavior: func(r *mock_repository.MockOrderbook, exchangeName, pair string, asksBids *domain.AsksBids) {
+				r.EXPECT().SaveOrderBook(gomock.Any(), exchangeName, pair, gomock.Any()).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":"12345"}`,
@@ -127,7 +156,7 @@ func TestHandler_SaveOrderBook(t *testing.T) {
 			inputAsksBids: &domain.AsksBids{
 				Asks: []domain.DepthOrder{{Price: 100, BaseQty: 1}},
 			},
-			mockBehavior:         func(r *mock_repository.Mockorderbook, exchangeName, pair string, asksBids *domain.AsksBids) {},
+			mockBehavior:         func(r *mock_repository.MockOrderbook, exchangeName, pair string, asksBids *domain.AsksBids) {},
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input"}`,
 		},
@@ -137,7 +166,7 @@ func TestHandler_SaveOrderBook(t *testing.T) {
 			pair:                 "BTCETH",
 			inputBody:            `{"asks":[],"bids":[]`,
 			inputAsksBids:        &domain.AsksBids{},
-			mockBehavior:         func(r *mock_repository.Mockorderbook, exchangeName, pair string, asksBids *domain.AsksBids) {},
+			mockBehavior:         func(r *mock_repository.MockOrderbook, exchangeName, pair string, asksBids *domain.AsksBids) {},
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input body"}`,
 		},
@@ -150,8 +179,8 @@ func TestHandler_SaveOrderBook(t *testing.T) {
 				Asks: []domain.DepthOrder{{Price: 100, BaseQty: 1}, {Price: 110, BaseQty: 5}},
 				Bids: []domain.DepthOrder{{Price: 99, BaseQty: 2}},
 			},
-			mockBehavior: func(r *mock_repository.Mockorderbook, exchangeName, pair string, asksBids *domain.AsksBids) {
-				r.EXPECT().SaveOrderBook(exchangeName, pair, gomock.Any()).Return(errors.New("server error"))
+			mockBehavior: func(r *mock_repository.MockOrderbook, exchangeName, pair string, asksBids *domain.AsksBids) {
+				r.EXPECT().SaveOrderBook(gomock.Any(), exchangeName, pair, gomock.Any()).Return(errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}`,
@@ -162,7 +191,7 @@ func TestHandler_SaveOrderBook(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c := gomock.NewController(t)
 			defer c.Finish()
-			repo := mock_repository.NewMockorderbook(c)
+			repo := mock_repository.NewMockOrderbook(c)
 			tt.mockBehavior(repo, tt.exchange_name, tt.pair, tt.inputAsksBids)
 			handler := NewHandler(&repository.Repository{Orderbook: repo})
 			r := gin.New()
@@ -179,3 +208,104 @@ func TestHandler_SaveOrderBook(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_OrderBookEncodings(t *testing.T) {
+	book := &domain.AsksBids{
+		Id:   7,
+		Asks: []domain.DepthOrder{{Price: 101, BaseQty: 1}, {Price: 102, BaseQty: 3}},
+		Bids: []domain.DepthOrder{{Price: 99, BaseQty: 2}},
+	}
+
+	tests := []struct {
+		name                string
+		mediaType           string
+		expectedContentType string
+	}{
+		{
+			name:                "JSON",
+			mediaType:           "application/json",
+			expectedContentType: "application/json; charset=utf-8",
+		},
+		{
+			name:                "packed JSON",
+			mediaType:           "application/json; layout=packed",
+			expectedContentType: "application/json; layout=packed; charset=utf-8",
+		},
+		{
+			name:                "Protobuf",
+			mediaType:           "application/x-protobuf",
+			expectedContentType: "application/x-protobuf",
+		},
+		{
+			name:                "packed Protobuf",
+			mediaType:           "application/protobuf; layout=packed",
+			expectedContentType: "application/x-protobuf; layout=packed",
+		},
+		{
+			name:                "MessagePack",
+			mediaType:           "application/msgpack",
+			expectedContentType: "application/msgpack",
+		},
+		{
+			name:                "packed MessagePack",
+			mediaType:           "application/x-msgpack; layout=packed",
+			expectedContentType: "application/msgpack; layout=packed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			repo := mock_repository.NewMockOrderbook(c)
+			repo.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(book, nil)
+			repo.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).DoAndReturn(
+				func(_ context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+					assert.Equal(t, book.Asks, asksBids.Asks)
+					assert.Equal(t, book.Bids, asksBids.Bids)
+					return nil
+				})
+			handler := NewHandler(&repository.Repository{Orderbook: repo})
+			r := gin.New()
+			r.GET("/orderbook/:exchangeName/:pair/", handler.GetOrderBook)
+			r.POST("/orderbook/:exchangeName/:pair/", handler.SaveOrderBook)
+			codec, ok := parseBookCodec(tt.mediaType)
+			assert.True(t, ok)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/orderbook/binance/BTCUSDT/", nil)
+			req.Header.Set("Accept", "text/html;q=0.9, "+tt.mediaType)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			decoded, err := codec.decode(w.Body.Bytes())
+			assert.NoError(t, err)
+			assert.Equal(t, book.Asks, decoded.Asks)
+			assert.Equal(t, book.Bids, decoded.Bids)
+
+			body, err := codec.encode(book)
+			assert.NoError(t, err)
+			w = httptest.NewRecorder()
+			req = httptest.NewRequest("POST", "/orderbook/binance/BTCUSDT/", bytes.NewReader(body))
+			req.Header.Set("Content-Type", tt.mediaType)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_SaveOrderBookPacked(t *testing.T) {
+	handler := NewHandler(&repository.Repository{})
+	r := gin.New()
+	r.POST("/orderbook/:exchangeName/:pair/", handler.SaveOrderBook)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/orderbook/binance/BTCUSDT/",
+		bytes.NewBufferString(`{"ask_prices":[101,102],"ask_base_qtys":[1],"bid_prices":[],"bid_base_qtys":[]}`))
+	req.Header.Set("Content-Type", "application/json; layout=packed")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	assert.Equal(t, `{"message":"invalid input body"}`, w.Body.String())
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/auth"
 	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
 )
 
 func (h *Handler) GetOrderHistory(c *gin.Context) {
@@ -25,11 +27,14 @@ func (h *Handler) GetOrderHistory(c *gin.Context) {
 		Label:        label,
 		Pair:         pair,
 	}
-	orderHistory, err := h.repo.GetOrderHistory(&client)
+	orderHistory, err := h.repo.GetOrderHistory(c.Request.Context(), &client)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+		repositoryError(c, err)
 		return
 	}
+	if orderHistory == nil {
+		orderHistory = []*domain.HistoryOrder{}
+	}
 	c.JSON(http.StatusOK, orderHistory)
 }
 
@@ -40,11 +45,28 @@ func (h *Handler) SaveOrder(c *gin.Context) {
 		return
 	}
 	order.TimePlaced = time.Now()
-	if err := h.repo.SaveOrder(&order); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+	if err := h.repo.SaveOrder(c.Request.Context(), &order); err != nil {
+		repositoryError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, statusResponse{
 		Status: "ok",
 	})
 }
+
+// StreamOrders streams orders of the client of a client key, the client
+// filter defaults to it.
+func (h *Handler) StreamOrders(c *gin.Context) {
+	if key := auth.FromContext(c.Request.Context()); key != nil && !key.Admin {
+		query := c.Request.URL.Query()
+		if query.Get("client") == "" {
+			query.Set("client", key.Client)
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		if !auth.Allowed(c.Request.Context(), query.Get("client")) {
+			repositoryError(c, repository.ErrForbidden)
+			return
+		}
+	}
+	h.orders.ServeHTTP(c.Writer, c.Request)
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"net/http/httptest"
 	"testing"
@@ -16,7 +17,7 @@ import (
 )
 
 func TestHandler_GetOrderHistory(t *testing.T) {
-	type mockBehavior func(r *mock_repository.Mockorderhistory, client *domain.Client)
+	type mockBehavior func(r *mock_repository.MockOrderhistory, client *domain.Client)
 
 	tests := []struct {
 		name                 string
@@ -35,8 +36,8 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 				Label:        "111",
 				Pair:         "BTCUSDT",
 			},
-			mockBehavior: func(r *mock_repository.Mockorderhistory, client *domain.Client) {
-				r.EXPECT().GetOrderHistory(client).Return([]*domain.HistoryOrder{
+			mockBehavior: func(r *mock_repository.MockOrderhistory, client *domain.Client) {
+				r.EXPECT().GetOrderHistory(gomock.Any(), client).Return([]*domain.HistoryOrder{
 					{
 						Client:              *client,
 						Side:                "buy",
@@ -58,7 +59,7 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 			name:                 "Invalid Input",
 			queryParams:          "client-name=&exchange-name=&label=&pair=",
 			inputClient:          &domain.Client{},
-			mockBehavior:         func(r *mock_repository.Mockorderhistory, client *domain.Client) {},
+			mockBehavior:         func(r *mock_repository.MockOrderhistory, client *domain.Client) {},
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input"}`,
 		},
@@ -71,8 +72,8 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 				Label:        "111",
 				Pair:         "BTCUSDT",
 			},
-			mockBehavior: func(r *mock_repository.Mockorderhistory, client *domain.Client) {
-				r.EXPECT().GetOrderHistory(client).Return(nil, errors.New("server error"))
+			mockBehavior: func(r *mock_repository.MockOrderhistory, client *domain.Client) {
+				r.EXPECT().GetOrderHistory(gomock.Any(), client).Return(nil, errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}`,
@@ -84,7 +85,7 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 			c := gomock.NewController(t)
 			defer c.Finish()
 
-			repo := mock_repository.NewMockorderhistory(c)
+			repo := mock_repository.NewMockOrderhistory(c)
 			tt.mockBehavior(repo, tt.inputClient)
 
 			handler := NewHandler(&repository.Repository{Orderhistory: repo})
@@ -104,7 +105,7 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 }
 
 func TestHandler_SaveOrder(t *testing.T) {
-	type mockBehavior func(r *mock_repository.Mockorderhistory, order *domain.HistoryOrder)
+	type mockBehavior func(r *mock_repository.MockOrderhistory, order *domain.HistoryOrder)
 
 	tests := []struct {
 		name                 string
@@ -138,8 +139,8 @@ func TestHandler_SaveOrder(t *testing.T) {
 				CommissionQuoteQty:  0.1,
 				TimePlaced:          time.Time{},
 			},
-			mockBehavior: func(r *mock_repository.Mockorderhistory, order *domain.HistoryOrder) {
-				r.EXPECT().SaveOrder(gomock.Any()).Return(nil).Do(func(order *domain.HistoryOrder) {
+			mockBehavior: func(r *mock_repository.MockOrderhistory, order *domain.HistoryOrder) {
+				r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, order *domain.HistoryOrder) {
 					order.TimePlaced = time.Time{}
 				})
 			},
@@ -150,7 +151,7 @@ func TestHandler_SaveOrder(t *testing.T) {
 			name:                 "Invalid Input Body",
 			inputBody:            `{"client": {"client_name": "", "exchangeame": "binance", "label": "test", "pair": "BTCUSDT"}}`,
 			inputOrder:           &domain.HistoryOrder{},
-			mockBehavior:         func(r *mock_repository.Mockorderhistory, order *domain.HistoryOrder) {},
+			mockBehavior:         func(r *mock_repository.MockOrderhistory, order *domain.HistoryOrder) {},
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input body"}`,
 		},
@@ -178,8 +179,8 @@ func TestHandler_SaveOrder(t *testing.T) {
 				CommissionQuoteQty:  0.1,
 				TimePlaced:          time.Now(), // This will be set dynamically in the handler
 			},
-			mockBehavior: func(r *mock_repository.Mockorderhistory, order *domain.HistoryOrder) {
-				r.EXPECT().SaveOrder(gomock.Any()).Return(errors.New("server error"))
+			mockBehavior: func(r *mock_repository.MockOrderhistory, order *domain.HistoryOrder) {
+				r.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}`,
@@ -191,7 +192,7 @@ func TestHandler_SaveOrder(t *testing.T) {
 			c := gomock.NewController(t)
 			defer c.Finish()
 
-			repo := mock_repository.NewMockorderhistory(c)
+			repo := mock_repository.NewMockOrderhistory(c)
 			tt.mockBehavior(repo, tt.inputOrder)
 
 			handler := NewHandler(&repository.Repository{Orderhistory: repo})
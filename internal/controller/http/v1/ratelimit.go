@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/ratelimit"
+)
+
+// limit answers 429 with Retry-After to clients which used up the budget
+// of the route's group. It runs after authenticate to count by API key.
+func (h *Handler) limit(c *gin.Context) {
+	client := ratelimit.Client(c.Request.Context(), c.ClientIP())
+	if ok, retryAfter := h.limiter.Allow(client, c.Request.Method, c.FullPath()); !ok {
+		c.Header("Retry-After", ratelimit.RetryAfter(retryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse{Message: "rate limit exceeded"})
+		return
+	}
+	c.Next()
+}
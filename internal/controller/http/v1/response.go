@@ -1,7 +1,12 @@
 package v1
 
 import (
+	"context"
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/repository"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,3 +22,18 @@ func newErrorResponse(c *gin.Context, statusCode int, message string) {
 	logrus.Error(message)
 	c.AbortWithStatusJSON(statusCode, errorResponse{Message: message})
 }
+
+// repositoryError answers 403 to orders of other clients, 504 to operations
+// that ran out of time and 500 to any other failure of the repository.
+func repositoryError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrForbidden) {
+		c.AbortWithStatusJSON(http.StatusForbidden, errorResponse{Message: "forbidden"})
+		return
+	}
+	logrus.Error(err.Error())
+	if errors.Is(err, context.DeadlineExceeded) {
+		newErrorResponse(c, http.StatusGatewayTimeout, "timeout")
+		return
+	}
+	newErrorResponse(c, http.StatusInternalServerError, "server error")
+}
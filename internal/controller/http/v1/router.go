@@ -1,24 +1,86 @@
 package v1
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
-func (h *Handler) InitRouterGin() http.Handler {
+func (h *Handler) InitRouterGin() *gin.Engine {
 	router := gin.New()
+	// gin trusts every proxy by default, which lets clients pick their IP
+	if err := router.SetTrustedProxies(h.proxies); err != nil {
+		logrus.Errorf("invalid trusted proxies, trusting none: %s", err.Error())
+		router.SetTrustedProxies(nil)
+	}
 	router.Use(gin.Logger())
-	orderBook := router.Group("/orderbook")
+	if h.tracing != nil {
+		router.Use(h.tracing)
+	}
+	if h.metrics != nil {
+		router.Use(h.metrics.Middleware())
+		router.GET("/metrics", gin.WrapH(h.metrics.Handler()))
+	}
+	// requests are validated only once they are authenticated and allowed
+	var validate []gin.HandlerFunc
+	if h.openapi != nil {
+		router.GET("/openapi.json", h.openapi.ServeJSON)
+		router.GET("/docs", h.openapi.ServeDocs)
+		validate = append(validate, h.openapi.Middleware())
+	}
+	probes := router.Group("", validate...)
+	{
+		probes.GET("/health", h.Health)
+		probes.GET("/healthz", h.Healthz)
+		probes.GET("/readyz", h.Readyz)
+	}
+
+	api := router.Group("")
+	if h.auth != nil {
+		api.Use(h.authenticate())
+	}
+	if h.limiter != nil {
+		api.Use(h.limit)
+	}
+	api.Use(validate...)
+	orderBook := api.Group("/orderbook")
 	{
 		orderBook.GET("/:exchangeName/:pair/", h.GetOrderBook)
 		orderBook.POST("/:exchangeName/:pair/", h.SaveOrderBook)
 	}
+	if h.orderBooks != nil {
+		api.GET("/ws/orderbooks", h.StreamOrderBooks)
+	}
 
-	orderHistory := router.Group("/orderhistory")
+	orderHistory := api.Group("/orderhistory")
 	{
 		orderHistory.GET("/", h.GetOrderHistory)
 		orderHistory.POST("/", h.SaveOrder)
+		if h.orders != nil {
+			orderHistory.GET("/stream", h.StreamOrders)
+		}
+	}
+
+	if h.export != nil {
+		export := api.Group("/export")
+		{
+			export.GET("/orders", h.ExportOrders)
+			export.GET("/orderbooks", h.ExportOrderBooks)
+		}
+	}
+
+	if h.graphql != nil {
+		api.GET("/graphql", h.GraphQL)
+		api.POST("/graphql", h.GraphQL)
+	}
+
+	if h.webhooks != nil {
+		webhooks := api.Group("/webhooks", requireAdmin)
+		{
+			webhooks.POST("/", h.CreateWebhook)
+			webhooks.GET("/", h.ListWebhooks)
+			webhooks.DELETE("/:id", h.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", h.GetWebhookDeliveries)
+		}
 	}
 	return router
 }
@@ -0,0 +1,193 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	v2 "github.com/kolibriee/trade-metrics/internal/controller/http/v2"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/health"
+	"github.com/kolibriee/trade-metrics/internal/openapi"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/kolibriee/trade-metrics/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestHandler_OpenAPI runs the routes through the openapi middleware with
+// strict response validation, so a handler drifting from the spec gets a 500.
+func TestHandler_OpenAPI(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	orderBooks := mock_repository.NewMockOrderbook(c)
+	orderHistory := mock_repository.NewMockOrderhistory(c)
+	client := domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "main", Pair: "BTCUSDT"}
+	orderBooks.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(&domain.AsksBids{
+		Asks: []domain.DepthOrder{{Price: 50000, BaseQty: 1}},
+	}, nil).AnyTimes()
+	orderBooks.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).Return(nil).AnyTimes()
+	orderHistory.EXPECT().GetOrderHistory(gomock.Any(), &client).Return([]*domain.HistoryOrder{{
+		Client:     client,
+		Side:       "buy",
+		Type:       "limit",
+		BaseQty:    1,
+		Price:      50000,
+		TimePlaced: time.Now(),
+	}}, nil).AnyTimes()
+	orderHistory.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	store, err := webhook.NewFileStore(filepath.Join(t.TempDir(), "webhooks.json"))
+	require.NoError(t, err)
+	dispatcher, err := webhook.NewDispatcher(store, &config.Webhooks{})
+	require.NoError(t, err)
+	spec, err := openapi.New(&config.OpenAPI{Enabled: true, ValidateResponses: true, StrictResponses: true})
+	require.NoError(t, err)
+	repo := &repository.Repository{Orderbook: orderBooks, Orderhistory: orderHistory}
+	checker := health.NewChecker(0, health.Check{Name: "database", Check: func(ctx context.Context) (any, error) {
+		return health.Pool{Open: 1, Idle: 1, MaxOpen: 10}, nil
+	}})
+	handler := NewHandler(repo, WithWebhooks(store, dispatcher), WithOpenAPI(spec), WithHealth(checker))
+	r := handler.InitRouterGin()
+	v2.NewHandler(repo, v2.WithOpenAPI(spec)).InitRoutes(r.Group("/v2"))
+
+	do := func(method, target, body string, header ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name               string
+		method             string
+		target             string
+		body               string
+		header             []string
+		expectedStatusCode int
+	}{
+		{name: "spec", method: "GET", target: "/openapi.json", expectedStatusCode: 200},
+		{name: "docs", method: "GET", target: "/docs", expectedStatusCode: 200},
+		{name: "health", method: "GET", target: "/health", expectedStatusCode: 200},
+		{name: "liveness", method: "GET", target: "/healthz", expectedStatusCode: 200},
+		{name: "readiness", method: "GET", target: "/readyz?verbose", expectedStatusCode: 200},
+		{name: "get order book", method: "GET", target: "/orderbook/binance/BTCUSDT/", expectedStatusCode: 200},
+		{
+			name:               "save order book",
+			method:             "POST",
+			target:             "/orderbook/binance/BTCUSDT/",
+			body:               `{"asks":[{"price":100,"base_qty":1}],"bids":[{"price":99,"base_qty":2}]}`,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "get packed order book",
+			method:             "GET",
+			target:             "/orderbook/binance/BTCUSDT/",
+			header:             []string{"Accept", "application/json; layout=packed"},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "get protobuf order book",
+			method:             "GET",
+			target:             "/orderbook/binance/BTCUSDT/",
+			header:             []string{"Accept", "application/x-protobuf"},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "save packed order book as msgpack",
+			method:             "POST",
+			target:             "/orderbook/binance/BTCUSDT/",
+			body:               packedMsgPack(t),
+			header:             []string{"Content-Type", "application/msgpack; layout=packed", "Accept", "application/msgpack"},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "save order book without bids",
+			method:             "POST",
+			target:             "/orderbook/binance/BTCUSDT/",
+			body:               `{"asks":[]}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "get order history",
+			method:             "GET",
+			target:             "/orderhistory/?client-name=Misha&exchange-name=binance&label=main&pair=BTCUSDT",
+			expectedStatusCode: 200,
+		},
+		{
+			name:   "save order",
+			method: "POST",
+			target: "/orderhistory/",
+			body: `{"client":{"client_name":"Misha","exchange_name":"binance","label":"main","pair":"BTCUSDT"},` +
+				`"side":"buy","type":"limit","base_qty":1,"price":50000,"algorithm_name_placed":"twap",` +
+				`"lowest_sell_prc":50010,"highest_buy_prc":49990,"commission_quote_qty":0.5}`,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "create webhook",
+			method:             "POST",
+			target:             "/webhooks/",
+			body:               `{"url":"http://risk.local/hook","events":["order.saved"]}`,
+			expectedStatusCode: 200,
+		},
+		{name: "list webhooks", method: "GET", target: "/webhooks/", expectedStatusCode: 200},
+		{name: "v2 get order book", method: "GET", target: "/v2/orderbook/binance/BTCUSDT", expectedStatusCode: 200},
+		{
+			name:               "v2 save order book without bids",
+			method:             "POST",
+			target:             "/v2/orderbook/binance/BTCUSDT",
+			body:               `{"asks":[]}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "v2 get order history",
+			method:             "GET",
+			target:             "/v2/orderhistory?client_name=Misha&exchange_name=binance&label=main&pair=BTCUSDT",
+			expectedStatusCode: 200,
+		},
+		{
+			name:   "v2 save order",
+			method: "POST",
+			target: "/v2/orderhistory",
+			body: `{"client":{"client_name":"Misha","exchange_name":"binance","label":"main","pair":"BTCUSDT"},` +
+				`"side":"buy","type":"limit","base_qty":1,"price":50000,"algorithm_name_placed":"twap",` +
+				`"lowest_sell_prc":50010,"highest_buy_prc":49990,"commission_quote_qty":0.5}`,
+			expectedStatusCode: 201,
+		},
+		{name: "delete unknown webhook", method: "DELETE", target: "/webhooks/unknown", expectedStatusCode: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.method, tt.target, tt.body, tt.header...)
+			assert.Equal(t, tt.expectedStatusCode, w.Code, w.Body.String())
+		})
+	}
+
+	var created domain.Webhook
+	require.NoError(t, json.Unmarshal(do("POST", "/webhooks/", `{"url":"http://risk.local/hook"}`).Body.Bytes(), &created))
+	assert.Equal(t, 200, do("GET", "/webhooks/"+created.ID+"/deliveries", "").Code)
+	assert.Equal(t, 200, do("DELETE", "/webhooks/"+created.ID, "").Code)
+}
+
+func packedMsgPack(t *testing.T) string {
+	b, err := bookCodec{mediaType: mediaMsgPack, packed: true}.encode(&domain.AsksBids{
+		Asks: []domain.DepthOrder{{Price: 100, BaseQty: 1}},
+		Bids: []domain.DepthOrder{{Price: 99, BaseQty: 2}},
+	})
+	require.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/kolibriee/trade-metrics/internal/webhook"
+)
+
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var wh domain.Webhook
+	if err := c.BindJSON(&wh); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, errors.New("invalid input body").Error())
+		return
+	}
+	for _, t := range wh.Events {
+		if !slices.Contains(event.Types, t) {
+			newErrorResponse(c, http.StatusBadRequest, errors.New("unknown event type: "+t).Error())
+			return
+		}
+	}
+	wh.ID = uuid.NewString()
+	wh.CreatedAt = time.Now()
+	if wh.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+			return
+		}
+		wh.Secret = hex.EncodeToString(secret)
+	}
+	if err := h.webhooks.Create(&wh); err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+		return
+	}
+	c.JSON(http.StatusOK, wh)
+}
+
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhooks.List()
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+		return
+	}
+	res := make([]domain.Webhook, len(webhooks))
+	for i, wh := range webhooks {
+		res[i] = *wh
+		res[i].Secret = ""
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhooks.Delete(c.Param("id")); err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	if _, err := h.webhooks.Get(c.Param("id")); err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, errors.New("server error").Error())
+		return
+	}
+	c.JSON(http.StatusOK, h.dispatcher.Deliveries(c.Param("id")))
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/kolibriee/trade-metrics/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebhookRouter(t *testing.T) *gin.Engine {
+	store, err := webhook.NewFileStore(filepath.Join(t.TempDir(), "webhooks.json"))
+	require.NoError(t, err)
+	dispatcher, err := webhook.NewDispatcher(store, &config.Webhooks{})
+	require.NoError(t, err)
+	handler := NewHandler(&repository.Repository{}, WithWebhooks(store, dispatcher))
+	r := gin.New()
+	r.POST("/webhooks/", handler.CreateWebhook)
+	r.GET("/webhooks/", handler.ListWebhooks)
+	r.DELETE("/webhooks/:id", handler.DeleteWebhook)
+	r.GET("/webhooks/:id/deliveries", handler.GetWebhookDeliveries)
+	return r
+}
+
+func TestHandler_CreateWebhook(t *testing.T) {
+	tests := []struct {
+		name                 string
+		inputBody            string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:               "OK",
+			inputBody:          `{"url":"http://risk.local/hook","events":["order.saved"],"client_name":"Misha"}`,
+			expectedStatusCode: 200,
+		},
+		{
+			name:                 "Invalid URL",
+			inputBody:            `{"url":"not a url"}`,
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Unknown event type",
+			inputBody:            `{"url":"http://risk.local/hook","events":["order.deleted"]}`,
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"unknown event type: order.deleted"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newWebhookRouter(t)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/webhooks/", bytes.NewBufferString(tt.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			if tt.expectedStatusCode != 200 {
+				assert.Equal(t, tt.expectedResponseBody, w.Body.String())
+				return
+			}
+			var created domain.Webhook
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+			assert.NotEmpty(t, created.ID)
+			assert.Len(t, created.Secret, 64)
+			assert.Equal(t, "Misha", created.ClientName)
+		})
+	}
+}
+
+func TestHandler_ListAndDeleteWebhooks(t *testing.T) {
+	r := newWebhookRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/webhooks/", bytes.NewBufferString(`{"url":"http://risk.local/hook","secret":"s"}`)))
+	require.Equal(t, 200, w.Code)
+	var created domain.Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/webhooks/", nil))
+	assert.Equal(t, 200, w.Code)
+	var listed []domain.Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, created.ID, listed[0].ID)
+	assert.Empty(t, listed[0].Secret)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/webhooks/"+created.ID+"/deliveries", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `[]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/webhooks/"+created.ID, nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/webhooks/"+created.ID, nil))
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, `{"message":"webhook not found"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/webhooks/"+created.ID+"/deliveries", nil))
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, `{"message":"webhook not found"}`, w.Body.String())
+}
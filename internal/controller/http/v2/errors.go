@@ -0,0 +1,162 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Error codes are part of the API, clients may switch on them.
+const (
+	CodeInvalidArgument = "invalid_argument"
+	CodeNotFound        = "not_found"
+	CodeInternal        = "internal"
+	CodeTimeout         = "timeout"
+	CodeUnauthenticated = "unauthenticated"
+	CodeForbidden       = "forbidden"
+	CodeRateLimited     = "rate_limited"
+)
+
+// Codes of FieldError, besides the names of failed binding rules like
+// required.
+const (
+	FieldInvalidType = "invalid_type"
+)
+
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+type Error struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id"`
+}
+
+// FieldError describes an invalid field of the request by its JSON path or
+// query parameter name.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newErrorResponse(c *gin.Context, status int, code, message string, details ...FieldError) {
+	c.AbortWithStatusJSON(status, ErrorResponse{Error: Error{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: c.GetString(requestIDKey),
+	}})
+}
+
+// repositoryError answers orders of other clients with 403, operations
+// that ran out of time with 504 and every other failure like internalError.
+func repositoryError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrForbidden) {
+		newErrorResponse(c, http.StatusForbidden, CodeForbidden, "access to the client is not allowed")
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logrus.WithField("request_id", c.GetString(requestIDKey)).Error(err.Error())
+		newErrorResponse(c, http.StatusGatewayTimeout, CodeTimeout, "request timed out")
+		return
+	}
+	internalError(c, err)
+}
+
+// internalError logs err with the request id and answers without exposing it.
+func internalError(c *gin.Context, err error) {
+	logrus.WithField("request_id", c.GetString(requestIDKey)).Error(err.Error())
+	newErrorResponse(c, http.StatusInternalServerError, CodeInternal, "internal error")
+}
+
+// validate checks the binding tags of v and names invalid fields by their
+// JSON names, unlike gin's validator.
+var validate = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}()
+
+// bindJSON decodes and validates the request body into v, answering with
+// invalid_argument and returning false when that fails.
+func bindJSON(c *gin.Context, v any) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			newErrorResponse(c, http.StatusBadRequest, CodeInvalidArgument, "invalid request body", FieldError{
+				Field:   typeErr.Field,
+				Code:    FieldInvalidType,
+				Message: "must be " + jsonType(typeErr.Type),
+			})
+		case errors.Is(err, io.EOF):
+			newErrorResponse(c, http.StatusBadRequest, CodeInvalidArgument, "request body is empty")
+		default:
+			newErrorResponse(c, http.StatusBadRequest, CodeInvalidArgument, "request body is not valid JSON")
+		}
+		return false
+	}
+	var validationErrs validator.ValidationErrors
+	if err := validate.Struct(v); errors.As(err, &validationErrs) {
+		details := make([]FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			details[i] = fieldError(fe)
+		}
+		newErrorResponse(c, http.StatusBadRequest, CodeInvalidArgument, "invalid request body", details...)
+		return false
+	}
+	return true
+}
+
+func fieldError(fe validator.FieldError) FieldError {
+	// the namespace starts with the name of the validated type
+	_, field, _ := strings.Cut(fe.Namespace(), ".")
+	res := FieldError{Field: field, Code: fe.Tag()}
+	switch fe.Tag() {
+	case "required":
+		res.Message = "is required"
+	case "url":
+		res.Message = "must be a URL"
+	default:
+		res.Message = "failed the " + fe.Tag() + " rule"
+	}
+	return res
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	if t.ConvertibleTo(reflect.TypeOf(0)) {
+		return "an integer"
+	}
+	return "a " + t.String()
+}
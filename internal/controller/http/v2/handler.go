@@ -0,0 +1,81 @@
+// Package v2 is the second version of the HTTP API. It serves the same
+// resources as v1, but every response is an envelope: {"data": ...} on
+// success and {"error": {...}} with a stable code otherwise.
+package v2
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/auth"
+	"github.com/kolibriee/trade-metrics/internal/openapi"
+	"github.com/kolibriee/trade-metrics/internal/ratelimit"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+type Handler struct {
+	repo    *repository.Repository
+	auth    *auth.Keys
+	limiter *ratelimit.Limiter
+	openapi *openapi.Spec
+}
+
+type Option func(h *Handler)
+
+// WithAuth requires one of keys on every route.
+func WithAuth(keys *auth.Keys) Option {
+	return func(h *Handler) {
+		h.auth = keys
+	}
+}
+
+// WithRateLimit limits the requests of every client.
+func WithRateLimit(limiter *ratelimit.Limiter) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+	}
+}
+
+// WithOpenAPI validates requests and responses against spec.
+func WithOpenAPI(spec *openapi.Spec) Option {
+	return func(h *Handler) {
+		h.openapi = spec
+	}
+}
+
+func NewHandler(repo *repository.Repository, opts ...Option) *Handler {
+	h := &Handler{
+		repo: repo,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// InitRoutes registers the v2 routes on router, usually the /v2 group of the
+// v1 engine.
+func (h *Handler) InitRoutes(router gin.IRouter) {
+	router.Use(requestID())
+	if h.auth != nil {
+		router.Use(h.authenticate())
+	}
+	if h.limiter != nil {
+		router.Use(h.limit)
+	}
+	if h.openapi != nil {
+		router.Use(h.openapi.Middleware())
+	}
+	orderBook := router.Group("/orderbook")
+	{
+		orderBook.GET("/:exchangeName/:pair", h.GetOrderBook)
+		orderBook.POST("/:exchangeName/:pair", h.SaveOrderBook)
+	}
+	orderHistory := router.Group("/orderhistory")
+	{
+		orderHistory.GET("", h.GetOrderHistory)
+		orderHistory.POST("", h.SaveOrder)
+	}
+}
+
+type dataResponse struct {
+	Data any `json:"data"`
+}
@@ -0,0 +1,41 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+type savedOrderBook struct {
+	ID uint32 `json:"id"`
+}
+
+func (h *Handler) GetOrderBook(c *gin.Context) {
+	orderBook, err := h.repo.GetOrderBook(c.Request.Context(), c.Param("exchangeName"), c.Param("pair"))
+	if errors.Is(err, repository.ErrNotFound) {
+		newErrorResponse(c, http.StatusNotFound, CodeNotFound, "order book not found")
+		return
+	}
+	if err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dataResponse{Data: orderBook})
+}
+
+func (h *Handler) SaveOrderBook(c *gin.Context) {
+	var orderBook domain.AsksBids
+	if !bindJSON(c, &orderBook) {
+		return
+	}
+	orderBook.Id = uuid.New().ID()
+	if err := h.repo.SaveOrderBook(c.Request.Context(), c.Param("exchangeName"), c.Param("pair"), &orderBook); err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, dataResponse{Data: savedOrderBook{ID: orderBook.Id}})
+}
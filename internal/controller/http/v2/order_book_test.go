@@ -0,0 +1,150 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func newRouter(repo *repository.Repository) *gin.Engine {
+	r := gin.New()
+	NewHandler(repo).InitRoutes(r.Group("/v2"))
+	return r
+}
+
+func TestHandler_GetOrderBook(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockOrderbook)
+
+	tests := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *mock_repository.MockOrderbook) {
+				r.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(&domain.AsksBids{
+					Id:   1,
+					Asks: []domain.DepthOrder{{Price: 50000, BaseQty: 1}},
+					Bids: []domain.DepthOrder{},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":{"id":1,"asks":[{"price":50000,"base_qty":1}],"bids":[]}}`,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(r *mock_repository.MockOrderbook) {
+				r.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(nil, repository.ErrNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"error":{"code":"not_found","message":"order book not found","request_id":"req-1"}}`,
+		},
+		{
+			name: "server error",
+			mockBehavior: func(r *mock_repository.MockOrderbook) {
+				r.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(nil, errors.New("failed to get order book: dial tcp: connection refused"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":{"code":"internal","message":"internal error","request_id":"req-1"}}`,
+		},
+		{
+			name: "timeout",
+			mockBehavior: func(r *mock_repository.MockOrderbook) {
+				r.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(nil, fmt.Errorf("failed to get order book: %w", context.DeadlineExceeded))
+			},
+			expectedStatusCode:   504,
+			expectedResponseBody: `{"error":{"code":"timeout","message":"request timed out","request_id":"req-1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			repo := mock_repository.NewMockOrderbook(c)
+			tt.mockBehavior(repo)
+			r := newRouter(&repository.Repository{Orderbook: repo})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/v2/orderbook/binance/BTCUSDT", nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectedResponseBody, w.Body.String())
+			assert.Equal(t, "req-1", w.Header().Get("X-Request-ID"))
+		})
+	}
+}
+
+func TestHandler_SaveOrderBook(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockOrderbook)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"asks":[{"price":100,"base_qty":1}],"bids":[]}`,
+			mockBehavior: func(r *mock_repository.MockOrderbook) {
+				r.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).Return(nil)
+			},
+			expectedStatusCode: 201,
+		},
+		{
+			name:                 "missing fields",
+			inputBody:            `{"asks":[{"price":100,"base_qty":1}]}`,
+			mockBehavior:         func(r *mock_repository.MockOrderbook) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":{"code":"invalid_argument","message":"invalid request body","details":[{"field":"bids","code":"required","message":"is required"}],"request_id":"req-1"}}`,
+		},
+		{
+			name:                 "wrong type",
+			inputBody:            `{"asks":{"price":100},"bids":[]}`,
+			mockBehavior:         func(r *mock_repository.MockOrderbook) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":{"code":"invalid_argument","message":"invalid request body","details":[{"field":"asks","code":"invalid_type","message":"must be an array"}],"request_id":"req-1"}}`,
+		},
+		{
+			name:                 "malformed body",
+			inputBody:            `{"asks":`,
+			mockBehavior:         func(r *mock_repository.MockOrderbook) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":{"code":"invalid_argument","message":"request body is not valid JSON","request_id":"req-1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			repo := mock_repository.NewMockOrderbook(c)
+			tt.mockBehavior(repo)
+			r := newRouter(&repository.Repository{Orderbook: repo})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/v2/orderbook/binance/BTCUSDT", bytes.NewBufferString(tt.inputBody))
+			req.Header.Set("X-Request-ID", "req-1")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			if tt.expectedResponseBody != "" {
+				assert.Equal(t, tt.expectedResponseBody, w.Body.String())
+			}
+		})
+	}
+}
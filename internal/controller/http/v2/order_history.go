@@ -0,0 +1,59 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// GetOrderHistory takes the client as client_name, exchange_name, label and
+// pair query parameters, all required.
+func (h *Handler) GetOrderHistory(c *gin.Context) {
+	client := domain.Client{
+		ClientName:   c.Query("client_name"),
+		ExchangeName: c.Query("exchange_name"),
+		Label:        c.Query("label"),
+		Pair:         c.Query("pair"),
+	}
+	var details []FieldError
+	for _, param := range []struct{ name, value string }{
+		{"client_name", client.ClientName},
+		{"exchange_name", client.ExchangeName},
+		{"label", client.Label},
+		{"pair", client.Pair},
+	} {
+		if param.value == "" {
+			details = append(details, FieldError{Field: param.name, Code: "required", Message: "is required"})
+		}
+	}
+	if len(details) > 0 {
+		newErrorResponse(c, http.StatusBadRequest, CodeInvalidArgument, "invalid query parameters", details...)
+		return
+	}
+
+	orderHistory, err := h.repo.GetOrderHistory(c.Request.Context(), &client)
+	if err != nil {
+		repositoryError(c, err)
+		return
+	}
+	if orderHistory == nil {
+		orderHistory = []*domain.HistoryOrder{}
+	}
+	c.JSON(http.StatusOK, dataResponse{Data: orderHistory})
+}
+
+// SaveOrder returns the saved order, with time_placed set by the server.
+func (h *Handler) SaveOrder(c *gin.Context) {
+	var order domain.HistoryOrder
+	if !bindJSON(c, &order) {
+		return
+	}
+	order.TimePlaced = time.Now()
+	if err := h.repo.SaveOrder(c.Request.Context(), &order); err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, dataResponse{Data: order})
+}
@@ -0,0 +1,80 @@
+package v2
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_GetOrderHistory(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockOrderhistory)
+
+	tests := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			query: "?client_name=Misha&exchange_name=binance&label=main&pair=BTCUSDT",
+			mockBehavior: func(r *mock_repository.MockOrderhistory) {
+				r.EXPECT().GetOrderHistory(gomock.Any(), &domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "main", Pair: "BTCUSDT"}).Return(nil, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[]}`,
+		},
+		{
+			name:                 "missing parameters",
+			query:                "?client_name=Misha&exchange_name=binance",
+			mockBehavior:         func(r *mock_repository.MockOrderhistory) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":{"code":"invalid_argument","message":"invalid query parameters","details":[{"field":"label","code":"required","message":"is required"},{"field":"pair","code":"required","message":"is required"}],"request_id":"req-1"}}`,
+		},
+		{
+			name:  "server error",
+			query: "?client_name=Misha&exchange_name=binance&label=main&pair=BTCUSDT",
+			mockBehavior: func(r *mock_repository.MockOrderhistory) {
+				r.EXPECT().GetOrderHistory(gomock.Any(), gomock.Any()).Return(nil, errors.New("failed to get order history: timeout"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":{"code":"internal","message":"internal error","request_id":"req-1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			repo := mock_repository.NewMockOrderhistory(c)
+			tt.mockBehavior(repo)
+			r := newRouter(&repository.Repository{Orderhistory: repo})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/v2/orderhistory"+tt.query, nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	r := newRouter(&repository.Repository{})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/v2/orderhistory", nil)
+	req.Header.Set("X-Request-ID", "not a valid id\n")
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get("X-Request-ID")
+	assert.Len(t, id, 36)
+	assert.Contains(t, w.Body.String(), `"request_id":"`+id+`"`)
+}
@@ -7,11 +7,11 @@ type AsksBids struct {
 }
 
 type OrderBook struct {
-	ID       int64        `db:"id"`
-	Exchange string       `db:"exchange"`
-	Pair     string       `db:"pair"`
-	Asks     []DepthOrder `db:"asks"`
-	Bids     []DepthOrder `db:"bids"`
+	ID       int64        `db:"id" json:"id"`
+	Exchange string       `db:"exchange" json:"exchange" binding:"required"`
+	Pair     string       `db:"pair" json:"pair" binding:"required"`
+	Asks     []DepthOrder `db:"asks" json:"asks" binding:"required"`
+	Bids     []DepthOrder `db:"bids" json:"bids" binding:"required"`
 }
 
 type DepthOrder struct {
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryRetrying  = "retrying"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription to saved orders and order books. Empty filters
+// match everything, ClientName only matches order events.
+type Webhook struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url" binding:"required,url"`
+	Secret     string    `json:"secret,omitempty"`
+	Events     []string  `json:"events"`
+	ClientName string    `json:"client_name,omitempty"`
+	Exchange   string    `json:"exchange,omitempty"`
+	Pair       string    `json:"pair,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string    `json:"id"`
+	WebhookID      string    `json:"webhook_id"`
+	EventType      string    `json:"event_type"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
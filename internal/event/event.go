@@ -0,0 +1,81 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+const (
+	TypeOrderSaved     = "order.saved"
+	TypeOrderBookSaved = "orderbook.saved"
+)
+
+var Types = []string{TypeOrderSaved, TypeOrderBookSaved}
+
+type Event struct {
+	Type      string               `json:"type"`
+	Time      time.Time            `json:"time"`
+	Exchange  string               `json:"exchange"`
+	Pair      string               `json:"pair"`
+	Order     *domain.HistoryOrder `json:"order,omitempty"`
+	OrderBook *domain.AsksBids     `json:"order_book,omitempty"`
+}
+
+func OrderSaved(order *domain.HistoryOrder) Event {
+	return Event{
+		Type:     TypeOrderSaved,
+		Time:     time.Now(),
+		Exchange: order.Client.ExchangeName,
+		Pair:     order.Client.Pair,
+		Order:    order,
+	}
+}
+
+func OrderBookSaved(exchangeName, pair string, asksBids *domain.AsksBids) Event {
+	return Event{
+		Type:      TypeOrderBookSaved,
+		Time:      time.Now(),
+		Exchange:  exchangeName,
+		Pair:      pair,
+		OrderBook: asksBids,
+	}
+}
+
+type Handler func(e Event)
+
+// Bus calls every handler synchronously on the publishing goroutine, so
+// handlers must hand events off to their own queues instead of blocking.
+type Bus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[int]Handler),
+	}
+}
+
+func (b *Bus) Subscribe(handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		handler(e)
+	}
+}
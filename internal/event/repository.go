@@ -0,0 +1,66 @@
+package event
+
+import (
+	"context"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+// Publish wraps repo so that every successful save is published on bus.
+func Publish(repo *repository.Repository, bus *Bus) *repository.Repository {
+	return &repository.Repository{
+		Orderbook:    &orderBookEvents{Orderbook: repo.Orderbook, bus: bus},
+		Orderhistory: &orderHistoryEvents{Orderhistory: repo.Orderhistory, bus: bus},
+	}
+}
+
+type orderBookEvents struct {
+	repository.Orderbook
+	bus *Bus
+}
+
+func (o *orderBookEvents) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	if err := o.Orderbook.SaveOrderBook(ctx, exchangeName, pair, asksBids); err != nil {
+		return err
+	}
+	o.bus.Publish(OrderBookSaved(exchangeName, pair, asksBids))
+	return nil
+}
+
+func (o *orderBookEvents) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	if err := o.Orderbook.SaveOrderBooks(ctx, orderBooks); err != nil {
+		return err
+	}
+	for _, orderBook := range orderBooks {
+		o.bus.Publish(OrderBookSaved(orderBook.Exchange, orderBook.Pair, &domain.AsksBids{
+			Id:   uint32(orderBook.ID),
+			Asks: orderBook.Asks,
+			Bids: orderBook.Bids,
+		}))
+	}
+	return nil
+}
+
+type orderHistoryEvents struct {
+	repository.Orderhistory
+	bus *Bus
+}
+
+func (o *orderHistoryEvents) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	if err := o.Orderhistory.SaveOrder(ctx, order); err != nil {
+		return err
+	}
+	o.bus.Publish(OrderSaved(order))
+	return nil
+}
+
+func (o *orderHistoryEvents) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	if err := o.Orderhistory.SaveOrders(ctx, orders); err != nil {
+		return err
+	}
+	for _, order := range orders {
+		o.bus.Publish(OrderSaved(order))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package export writes orders and order books as CSV, Parquet or Arrow IPC
+// streams. Rows are read from the repository and written in chunks, so the
+// size of an export isn't limited by memory.
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/apache/arrow/go/v16/arrow"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+	FormatArrow   Format = "arrow"
+)
+
+const defaultChunkSize = 10000
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatParquet, FormatArrow:
+		return Format(s), nil
+	}
+	return "", errors.New("unknown export format: " + s)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	case FormatArrow:
+		return "application/vnd.apache.arrow.stream"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func (f Format) Extension() string {
+	if f == FormatArrow {
+		return ".arrows"
+	}
+	return "." + string(f)
+}
+
+var orderColumns = []column{
+	{"client_name", arrow.BinaryTypes.String},
+	{"exchange_name", arrow.BinaryTypes.String},
+	{"label", arrow.BinaryTypes.String},
+	{"pair", arrow.BinaryTypes.String},
+	{"side", arrow.BinaryTypes.String},
+	{"type", arrow.BinaryTypes.String},
+	{"base_qty", arrow.PrimitiveTypes.Float64},
+	{"price", arrow.PrimitiveTypes.Float64},
+	{"algorithm_name_placed", arrow.BinaryTypes.String},
+	{"lowest_sell_prc", arrow.PrimitiveTypes.Float64},
+	{"highest_buy_prc", arrow.PrimitiveTypes.Float64},
+	{"commission_quote_qty", arrow.PrimitiveTypes.Float64},
+	{"time_placed", &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}},
+}
+
+// Order books are exported one row per price level, with side being ask or
+// bid and level counting from 0 in the order the levels were saved.
+var orderBookColumns = []column{
+	{"id", arrow.PrimitiveTypes.Uint32},
+	{"exchange", arrow.BinaryTypes.String},
+	{"pair", arrow.BinaryTypes.String},
+	{"side", arrow.BinaryTypes.String},
+	{"level", arrow.PrimitiveTypes.Int32},
+	{"price", arrow.PrimitiveTypes.Float64},
+	{"base_qty", arrow.PrimitiveTypes.Float64},
+}
+
+// Orders writes the orders matching filter, empty filter fields match every
+// value. Nothing is written to w before the first order has been read, so a
+// failing query leaves w untouched.
+func Orders(ctx context.Context, w io.Writer, format Format, chunkSize int, repo repository.Orderhistory, filter *domain.Client) error {
+	t := newTable(w, format, chunkSize, orderColumns)
+	err := repo.ExportOrders(ctx, filter, func(order *domain.HistoryOrder) error {
+		return t.append(
+			order.Client.ClientName,
+			order.Client.ExchangeName,
+			order.Client.Label,
+			order.Client.Pair,
+			order.Side,
+			order.Type,
+			order.BaseQty,
+			order.Price,
+			order.AlgorithmNamePlaced,
+			order.LowestSellPrice,
+			order.HighestBuyPrice,
+			order.CommissionQuoteQty,
+			order.TimePlaced.UTC(),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	return t.close()
+}
+
+// OrderBooks writes the order books of the exchange and pair, empty
+// arguments match every value. Like Orders, it writes nothing before the
+// first order book has been read.
+func OrderBooks(ctx context.Context, w io.Writer, format Format, chunkSize int, repo repository.Orderbook, exchangeName, pair string) error {
+	t := newTable(w, format, chunkSize, orderBookColumns)
+	err := repo.ExportOrderBooks(ctx, exchangeName, pair, func(orderBook *domain.OrderBook) error {
+		for side, levels := range [][]domain.DepthOrder{orderBook.Asks, orderBook.Bids} {
+			sideName := "ask"
+			if side == 1 {
+				sideName = "bid"
+			}
+			for i, level := range levels {
+				err := t.append(uint32(orderBook.ID), orderBook.Exchange, orderBook.Pair, sideName, int32(i), level.Price, level.BaseQty)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return t.close()
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v16/arrow/array"
+	"github.com/apache/arrow/go/v16/arrow/ipc"
+	"github.com/apache/arrow/go/v16/arrow/memory"
+	"github.com/apache/arrow/go/v16/parquet/file"
+	"github.com/apache/arrow/go/v16/parquet/pqarrow"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func testOrders() []*domain.HistoryOrder {
+	client := domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "main", Pair: "BTCUSDT"}
+	placed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	orders := make([]*domain.HistoryOrder, 3)
+	for i := range orders {
+		orders[i] = &domain.HistoryOrder{
+			Client:              client,
+			Side:                "buy",
+			Type:                "limit",
+			BaseQty:             float64(i + 1),
+			Price:               50000.5,
+			AlgorithmNamePlaced: "twap",
+			LowestSellPrice:     50001,
+			HighestBuyPrice:     49999,
+			CommissionQuoteQty:  0.25,
+			TimePlaced:          placed.Add(time.Duration(i) * time.Second),
+		}
+	}
+	return orders
+}
+
+func mockOrders(t *testing.T, orders []*domain.HistoryOrder, err error) *mock_repository.MockOrderhistory {
+	c := gomock.NewController(t)
+	repo := mock_repository.NewMockOrderhistory(c)
+	repo.EXPECT().ExportOrders(gomock.Any(), &domain.Client{Pair: "BTCUSDT"}, gomock.Any()).DoAndReturn(
+		func(_ context.Context, filter *domain.Client, fn func(*domain.HistoryOrder) error) error {
+			if err != nil {
+				return err
+			}
+			for _, order := range orders {
+				if err := fn(order); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	return repo
+}
+
+func TestOrders_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := Orders(context.Background(), &buf, FormatCSV, 2, mockOrders(t, testOrders()[:2], nil), &domain.Client{Pair: "BTCUSDT"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "client_name,exchange_name,label,pair,side,type,base_qty,price,algorithm_name_placed,lowest_sell_prc,highest_buy_prc,commission_quote_qty,time_placed\n"+
+		"Misha,binance,main,BTCUSDT,buy,limit,1,50000.5,twap,50001,49999,0.25,2024-05-01T12:00:00Z\n"+
+		"Misha,binance,main,BTCUSDT,buy,limit,2,50000.5,twap,50001,49999,0.25,2024-05-01T12:00:01Z\n", buf.String())
+}
+
+func TestOrders_Arrow(t *testing.T) {
+	var buf bytes.Buffer
+	err := Orders(context.Background(), &buf, FormatArrow, 2, mockOrders(t, testOrders(), nil), &domain.Client{Pair: "BTCUSDT"})
+	require.NoError(t, err)
+
+	r, err := ipc.NewReader(&buf)
+	require.NoError(t, err)
+	defer r.Release()
+	var sizes []int64
+	var qty []float64
+	for r.Next() {
+		rec := r.Record()
+		sizes = append(sizes, rec.NumRows())
+		qty = append(qty, rec.Column(6).(*array.Float64).Float64Values()...)
+	}
+	require.NoError(t, r.Err())
+	assert.Equal(t, []int64{2, 1}, sizes)
+	assert.Equal(t, []float64{1, 2, 3}, qty)
+	assert.Equal(t, "time_placed", r.Schema().Field(12).Name)
+}
+
+func TestOrders_Parquet(t *testing.T) {
+	var buf bytes.Buffer
+	err := Orders(context.Background(), &buf, FormatParquet, 2, mockOrders(t, testOrders(), nil), &domain.Client{Pair: "BTCUSDT"})
+	require.NoError(t, err)
+
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer pf.Close()
+	assert.Equal(t, 2, pf.NumRowGroups())
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	tbl, err := fr.ReadTable(context.Background())
+	require.NoError(t, err)
+	defer tbl.Release()
+	assert.Equal(t, int64(3), tbl.NumRows())
+	assert.Equal(t, "client_name", tbl.Schema().Field(0).Name)
+}
+
+func TestOrders_QueryError(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatArrow, FormatParquet} {
+		var buf bytes.Buffer
+		err := Orders(context.Background(), &buf, format, 2, mockOrders(t, nil, errors.New("connection refused")), &domain.Client{Pair: "BTCUSDT"})
+
+		assert.EqualError(t, err, "connection refused")
+		assert.Zero(t, buf.Len(), format)
+	}
+}
+
+func TestOrderBooks_CSV(t *testing.T) {
+	c := gomock.NewController(t)
+	repo := mock_repository.NewMockOrderbook(c)
+	repo.EXPECT().ExportOrderBooks(gomock.Any(), "binance", "", gomock.Any()).DoAndReturn(
+		func(_ context.Context, exchangeName, pair string, fn func(*domain.OrderBook) error) error {
+			return fn(&domain.OrderBook{
+				ID:       7,
+				Exchange: "binance",
+				Pair:     "BTCUSDT",
+				Asks:     []domain.DepthOrder{{Price: 101, BaseQty: 1}, {Price: 102, BaseQty: 3}},
+				Bids:     []domain.DepthOrder{{Price: 99, BaseQty: 2}},
+			})
+		})
+
+	var buf bytes.Buffer
+	require.NoError(t, OrderBooks(context.Background(), &buf, FormatCSV, 0, repo, "binance", ""))
+	assert.Equal(t, "id,exchange,pair,side,level,price,base_qty\n"+
+		"7,binance,BTCUSDT,ask,0,101,1\n"+
+		"7,binance,BTCUSDT,ask,1,102,3\n"+
+		"7,binance,BTCUSDT,bid,0,99,2\n", buf.String())
+}
@@ -0,0 +1,212 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/apache/arrow/go/v16/arrow"
+	"github.com/apache/arrow/go/v16/arrow/array"
+	"github.com/apache/arrow/go/v16/arrow/ipc"
+	"github.com/apache/arrow/go/v16/arrow/memory"
+	"github.com/apache/arrow/go/v16/parquet"
+	"github.com/apache/arrow/go/v16/parquet/compress"
+	"github.com/apache/arrow/go/v16/parquet/pqarrow"
+)
+
+type column struct {
+	name string
+	typ  arrow.DataType
+}
+
+type encoder interface {
+	append(values []any) error
+	// flush writes the rows appended since the last flush.
+	flush() error
+	close() error
+}
+
+// table writes rows through the encoder of its format. Every chunkSize rows
+// are flushed as one CSV block, Arrow record batch or Parquet row group, and
+// w is flushed too when it's an http.Flusher. The encoder is created with the
+// first row, so nothing is written before there is data.
+type table struct {
+	w         io.Writer
+	format    Format
+	chunkSize int
+	columns   []column
+	enc       encoder
+	pending   int
+}
+
+func newTable(w io.Writer, format Format, chunkSize int, columns []column) *table {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	return &table{
+		w:         w,
+		format:    format,
+		chunkSize: chunkSize,
+		columns:   columns,
+	}
+}
+
+func (t *table) append(values ...any) error {
+	if t.enc == nil {
+		enc, err := newEncoder(t.w, t.format, t.columns)
+		if err != nil {
+			return err
+		}
+		t.enc = enc
+	}
+	if err := t.enc.append(values); err != nil {
+		return err
+	}
+	t.pending++
+	if t.pending < t.chunkSize {
+		return nil
+	}
+	return t.flush()
+}
+
+func (t *table) flush() error {
+	t.pending = 0
+	if err := t.enc.flush(); err != nil {
+		return errors.New("failed to write export: " + err.Error())
+	}
+	if f, ok := t.w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// close writes the remaining rows and the end of the file. An export
+// without rows still gets a header or schema.
+func (t *table) close() error {
+	if t.enc == nil {
+		enc, err := newEncoder(t.w, t.format, t.columns)
+		if err != nil {
+			return err
+		}
+		t.enc = enc
+	}
+	if t.pending > 0 {
+		if err := t.flush(); err != nil {
+			return err
+		}
+	}
+	if err := t.enc.close(); err != nil {
+		return errors.New("failed to finish export: " + err.Error())
+	}
+	if f, ok := t.w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func newEncoder(w io.Writer, format Format, columns []column) (encoder, error) {
+	if format == FormatCSV {
+		enc := &csvEncoder{w: csv.NewWriter(w), row: make([]string, len(columns))}
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = c.name
+		}
+		if err := enc.w.Write(header); err != nil {
+			return nil, errors.New("failed to write export: " + err.Error())
+		}
+		return enc, nil
+	}
+
+	fields := make([]arrow.Field, len(columns))
+	for i, c := range columns {
+		fields[i] = arrow.Field{Name: c.name, Type: c.typ}
+	}
+	schema := arrow.NewSchema(fields, nil)
+	enc := &arrowEncoder{builder: array.NewRecordBuilder(memory.DefaultAllocator, schema)}
+	switch format {
+	case FormatArrow:
+		wr := ipc.NewWriter(w, ipc.WithSchema(schema))
+		enc.write = wr.Write
+		enc.closeFn = wr.Close
+	case FormatParquet:
+		props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+		wr, err := pqarrow.NewFileWriter(schema, w, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+		if err != nil {
+			return nil, errors.New("failed to create parquet writer: " + err.Error())
+		}
+		enc.write = wr.Write
+		enc.closeFn = wr.Close
+	default:
+		return nil, errors.New("unknown export format: " + string(format))
+	}
+	return enc, nil
+}
+
+type csvEncoder struct {
+	w   *csv.Writer
+	row []string
+}
+
+func (e *csvEncoder) append(values []any) error {
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			e.row[i] = v
+		case float64:
+			e.row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case uint32:
+			e.row[i] = strconv.FormatUint(uint64(v), 10)
+		case int32:
+			e.row[i] = strconv.FormatInt(int64(v), 10)
+		case time.Time:
+			e.row[i] = v.Format(time.RFC3339Nano)
+		}
+	}
+	return e.w.Write(e.row)
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) close() error {
+	return e.flush()
+}
+
+type arrowEncoder struct {
+	builder *array.RecordBuilder
+	write   func(rec arrow.Record) error
+	closeFn func() error
+}
+
+func (e *arrowEncoder) append(values []any) error {
+	for i, v := range values {
+		switch b := e.builder.Field(i).(type) {
+		case *array.StringBuilder:
+			b.Append(v.(string))
+		case *array.Float64Builder:
+			b.Append(v.(float64))
+		case *array.Uint32Builder:
+			b.Append(v.(uint32))
+		case *array.Int32Builder:
+			b.Append(v.(int32))
+		case *array.TimestampBuilder:
+			b.AppendTime(v.(time.Time))
+		}
+	}
+	return nil
+}
+
+func (e *arrowEncoder) flush() error {
+	rec := e.builder.NewRecord()
+	defer rec.Release()
+	return e.write(rec)
+}
+
+func (e *arrowEncoder) close() error {
+	e.builder.Release()
+	return e.closeFn()
+}
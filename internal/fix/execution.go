@@ -0,0 +1,90 @@
+package fix
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+var (
+	sides = map[string]string{
+		"1": "buy",
+		"2": "sell",
+	}
+	orderTypes = map[string]string{
+		"1": "market",
+		"2": "limit",
+		"3": "stop",
+		"4": "stop_limit",
+	}
+)
+
+// isFill reports whether an ExecutionReport carries a fill: ExecType Trade in
+// FIX 4.4 or Partial fill / Fill kept by brokers still on 4.2 semantics.
+func isFill(msg *Message) bool {
+	switch msg.Get(tagExecType) {
+	case "F", "1", "2":
+		return true
+	}
+	return false
+}
+
+func tagOr(msg *Message, tag int, fallback string) string {
+	if tag == 0 {
+		return fallback
+	}
+	if v := msg.Get(tag); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func executionToOrder(msg *Message, cfg *config.FIXSession) (*domain.HistoryOrder, error) {
+	order := &domain.HistoryOrder{
+		Client: domain.Client{
+			ClientName:   tagOr(msg, cfg.Tags.Client, ""),
+			ExchangeName: tagOr(msg, cfg.Tags.Exchange, cfg.Exchange),
+			Label:        tagOr(msg, cfg.Tags.Label, cfg.Label),
+			Pair:         tagOr(msg, cfg.Tags.Pair, ""),
+		},
+		Side:                sides[msg.Get(tagSide)],
+		Type:                orderTypes[msg.Get(tagOrdType)],
+		AlgorithmNamePlaced: tagOr(msg, cfg.Tags.Algorithm, ""),
+	}
+	if order.Client.ClientName == "" || order.Client.ExchangeName == "" || order.Client.Pair == "" {
+		return nil, errors.New("execution report has no client, exchange or pair")
+	}
+	if order.Side == "" {
+		return nil, errors.New("unsupported side: " + msg.Get(tagSide))
+	}
+	var err error
+	if order.BaseQty, err = strconv.ParseFloat(msg.Get(tagLastQty), 64); err != nil {
+		return nil, errors.New("invalid LastQty: " + msg.Get(tagLastQty))
+	}
+	if order.Price, err = strconv.ParseFloat(msg.Get(tagLastPx), 64); err != nil {
+		return nil, errors.New("invalid LastPx: " + msg.Get(tagLastPx))
+	}
+	if v := msg.Get(tagCommission); v != "" {
+		if order.CommissionQuoteQty, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, errors.New("invalid Commission: " + v)
+		}
+	}
+	order.TimePlaced = time.Now()
+	if v := msg.Get(tagTransactTime); v != "" {
+		if order.TimePlaced, err = parseTime(v); err != nil {
+			return nil, errors.New("invalid TransactTime: " + v)
+		}
+	}
+	return order, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, v)
+	if err != nil {
+		t, err = time.Parse("20060102-15:04:05", v)
+	}
+	return t, err
+}
@@ -0,0 +1,124 @@
+package fix
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ModeInitiator = "initiator"
+	ModeAcceptor  = "acceptor"
+)
+
+// Engine runs one FIX 4.4 drop copy session and stores the fills from its
+// ExecutionReports as order history.
+type Engine struct {
+	cfg   *config.FIXSession
+	repo  repository.Orderhistory
+	store *seqStore
+}
+
+func New(cfg *config.FIXSession, repo repository.Orderhistory) (*Engine, error) {
+	if cfg.Mode != ModeInitiator && cfg.Mode != ModeAcceptor {
+		return nil, errors.New("unknown fix session mode: " + cfg.Mode)
+	}
+	if cfg.SenderCompID == "" || cfg.TargetCompID == "" {
+		return nil, errors.New("fix session " + cfg.Name + " requires senderCompID and targetCompID")
+	}
+	store, err := newSeqStore(cfg.SeqStore)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{
+		cfg:   cfg,
+		repo:  repo,
+		store: store,
+	}, nil
+}
+
+func (e *Engine) Run(ctx context.Context) error {
+	if e.cfg.Mode == ModeAcceptor {
+		l, err := net.Listen("tcp", e.cfg.Address)
+		if err != nil {
+			return errors.New("failed to listen: " + err.Error())
+		}
+		return e.Serve(ctx, l)
+	}
+	interval := e.cfg.ReconnectInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	for {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", e.cfg.Address)
+		if err == nil {
+			err = e.newSession(conn).run(ctx, true)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logrus.Errorf("fix session %s: %s", e.cfg.Name, err.Error())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Serve accepts counterparty connections on l. Only one connection is served
+// at a time, others are closed right away.
+func (e *Engine) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	var active atomic.Bool
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.New("failed to accept connection: " + err.Error())
+		}
+		if !active.CompareAndSwap(false, true) {
+			conn.Close()
+			continue
+		}
+		go func() {
+			defer active.Store(false)
+			if err := e.newSession(conn).run(ctx, false); err != nil && ctx.Err() == nil {
+				logrus.Errorf("fix session %s: %s", e.cfg.Name, err.Error())
+			}
+		}()
+	}
+}
+
+func (e *Engine) newSession(conn net.Conn) *session {
+	return &session{
+		cfg:         e.cfg,
+		store:       e.store,
+		conn:        conn,
+		onExecution: e.onExecution,
+	}
+}
+
+func (e *Engine) onExecution(msg *Message) error {
+	if !isFill(msg) {
+		return nil
+	}
+	order, err := executionToOrder(msg, e.cfg)
+	if err != nil {
+		logrus.Errorf("fix session %s: skipping execution report %d: %s", e.cfg.Name, msg.SeqNum(), err.Error())
+		return nil
+	}
+	return e.repo.SaveOrder(context.Background(), order)
+}
@@ -0,0 +1,278 @@
+package fix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// counterparty simulates the broker side of a drop copy session.
+type counterparty struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newCounterparty(t *testing.T, conn net.Conn) *counterparty {
+	return &counterparty{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *counterparty) send(msgType string, seq int, fields ...Field) {
+	header := []Field{
+		{tagMsgType, msgType},
+		{tagSenderCompID, "BROKER"},
+		{tagTargetCompID, "TM"},
+		{tagMsgSeqNum, strconv.Itoa(seq)},
+		{tagSendingTime, time.Now().UTC().Format(timeLayout)},
+	}
+	_, err := c.conn.Write(Encode(append(header, fields...)))
+	require.NoError(c.t, err)
+}
+
+// expect returns the next message of msgType, skipping heartbeats.
+func (c *counterparty) expect(msgType string) *Message {
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		raw, err := readMessage(c.r, defaultMaxBodyLength)
+		require.NoError(c.t, err)
+		msg, err := Parse(raw)
+		require.NoError(c.t, err)
+		if msg.Type() == msgType {
+			return msg
+		}
+		if msg.Type() != msgHeartbeat {
+			c.t.Fatalf("expected message %s, got %s", msgType, msg.Type())
+		}
+	}
+}
+
+func fill(execID, qty, price string, extra ...Field) []Field {
+	fields := []Field{
+		{17, execID},
+		{tagExecType, "F"},
+		{1, "Misha"},
+		{55, "BTCUSDT"},
+		{tagSide, "1"},
+		{tagOrdType, "2"},
+		{tagLastQty, qty},
+		{tagLastPx, price},
+		{tagCommission, "0.1"},
+		{tagTransactTime, "20240701-10:00:00.000"},
+	}
+	return append(fields, extra...)
+}
+
+func testSession(mode, address string) *config.FIXSession {
+	return &config.FIXSession{
+		Name:         "test",
+		Mode:         mode,
+		Address:      address,
+		SenderCompID: "TM",
+		TargetCompID: "BROKER",
+		HeartBtInt:   30 * time.Second,
+		Exchange:     "binance",
+		Label:        "prime",
+		Tags: config.FIXTags{
+			Client: 1,
+			Pair:   55,
+		},
+	}
+}
+
+func TestEngine_Initiator(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	saved := make(chan *domain.HistoryOrder, 10)
+	repo := mock_repository.NewMockOrderhistory(c)
+	repo.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil).Times(2).Do(func(_ context.Context, order *domain.HistoryOrder) {
+		saved <- order
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	cfg := testSession(ModeInitiator, l.Addr().String())
+	cfg.ResetOnLogon = true
+	engine, err := New(cfg, repo)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go engine.Run(ctx)
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	cp := newCounterparty(t, conn)
+
+	logon := cp.expect(msgLogon)
+	assert.Equal(t, "Y", logon.Get(tagResetSeqNumFlag))
+	assert.Equal(t, 1, logon.SeqNum())
+	cp.send(msgLogon, 1, Field{tagEncryptMethod, "0"}, Field{tagHeartBtInt, "30"}, Field{tagResetSeqNumFlag, "Y"})
+
+	cp.send(msgExecutionReport, 2, fill("e1", "1", "50000")...)
+	order := <-saved
+	assert.Equal(t, domain.HistoryOrder{
+		Client:             domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "prime", Pair: "BTCUSDT"},
+		Side:               "buy",
+		Type:               "limit",
+		BaseQty:            1,
+		Price:              50000,
+		CommissionQuoteQty: 0.1,
+		TimePlaced:         time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC),
+	}, *order)
+
+	// message 3 is lost, message 4 reveals the gap
+	cp.send(msgExecutionReport, 4, fill("e3", "3", "50020")...)
+	resend := cp.expect(msgResendRequest)
+	assert.Equal(t, "3", resend.Get(tagBeginSeqNo))
+	assert.Equal(t, "0", resend.Get(tagEndSeqNo))
+
+	newOrder := append(fill("e2", "0", "0"), Field{tagPossDupFlag, "Y"})
+	newOrder[1].Value = "0"
+	cp.send(msgExecutionReport, 3, newOrder...)
+	cp.send(msgExecutionReport, 4, fill("e3", "3", "50020", Field{tagPossDupFlag, "Y"})...)
+	order = <-saved
+	assert.Equal(t, 50020.0, order.Price)
+
+	// a duplicate of an already processed message is ignored
+	cp.send(msgExecutionReport, 4, fill("e3", "3", "50020", Field{tagPossDupFlag, "Y"})...)
+
+	cp.send(msgTestRequest, 5, Field{tagTestReqID, "abc"})
+	heartbeat := cp.expect(msgHeartbeat)
+	assert.Equal(t, "abc", heartbeat.Get(tagTestReqID))
+
+	cp.send(msgResendRequest, 6, Field{tagBeginSeqNo, "1"}, Field{tagEndSeqNo, "0"})
+	reset := cp.expect(msgSequenceReset)
+	assert.Equal(t, 1, reset.SeqNum())
+	assert.Equal(t, "Y", reset.Get(tagGapFillFlag))
+	assert.Equal(t, "Y", reset.Get(tagPossDupFlag))
+	assert.Equal(t, "4", reset.Get(tagNewSeqNo))
+
+	cp.send(msgLogout, 7)
+	cp.expect(msgLogout)
+	in, out := engine.store.next()
+	assert.Equal(t, 8, in)
+	assert.Equal(t, 5, out)
+}
+
+func TestEngine_Acceptor(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	saved := make(chan *domain.HistoryOrder, 10)
+	repo := mock_repository.NewMockOrderhistory(c)
+	gomock.InOrder(
+		repo.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(errors.New("server error")),
+		repo.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, order *domain.HistoryOrder) {
+			saved <- order
+		}),
+	)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	cfg := testSession(ModeAcceptor, "")
+	cfg.SeqStore = t.TempDir() + "/seq.json"
+	engine, err := New(cfg, repo)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go engine.Serve(ctx, l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	cp := newCounterparty(t, conn)
+	cp.send(msgLogon, 1, Field{tagEncryptMethod, "0"}, Field{tagHeartBtInt, "30"}, Field{tagResetSeqNumFlag, "Y"})
+	logon := cp.expect(msgLogon)
+	assert.Equal(t, "Y", logon.Get(tagResetSeqNumFlag))
+
+	// no client tag, the report is skipped but its sequence number is used
+	noClient := fill("e1", "1", "50000")
+	noClient[2] = Field{100, "XNAS"}
+	cp.send(msgExecutionReport, 2, noClient...)
+	// the write fails, the engine drops the connection without consuming 3
+	cp.send(msgExecutionReport, 3, fill("e2", "2", "50010")...)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = readMessage(cp.r, defaultMaxBodyLength)
+	assert.Error(t, err)
+	conn.Close()
+
+	// the sequence store survives a restart of the engine
+	cancel()
+	engine, err = New(cfg, repo)
+	require.NoError(t, err)
+	in, _ := engine.store.next()
+	assert.Equal(t, 3, in)
+	l, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go engine.Serve(ctx, l)
+
+	conn, err = net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	cp = newCounterparty(t, conn)
+	cp.send(msgLogon, 4, Field{tagEncryptMethod, "0"}, Field{tagHeartBtInt, "30"})
+	cp.expect(msgLogon)
+	resend := cp.expect(msgResendRequest)
+	assert.Equal(t, "3", resend.Get(tagBeginSeqNo))
+	cp.send(msgExecutionReport, 3, fill("e2", "2", "50010", Field{tagPossDupFlag, "Y"})...)
+	cp.send(msgSequenceReset, 4, Field{tagPossDupFlag, "Y"}, Field{tagGapFillFlag, "Y"}, Field{tagNewSeqNo, "5"})
+
+	order := <-saved
+	assert.Equal(t, 50010.0, order.Price)
+	assert.Equal(t, 2.0, order.BaseQty)
+}
+
+func TestParse(t *testing.T) {
+	raw := Encode([]Field{{tagMsgType, msgHeartbeat}, {tagMsgSeqNum, "7"}})
+	msg, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, msgHeartbeat, msg.Type())
+	assert.Equal(t, 7, msg.SeqNum())
+
+	raw[len(raw)-2]++
+	_, err = Parse(raw)
+	assert.EqualError(t, err, "invalid checksum")
+}
+
+func TestReadMessage_MaxBodyLength(t *testing.T) {
+	heartbeat := Encode([]Field{{tagMsgType, msgHeartbeat}, {tagMsgSeqNum, "7"}})
+	tests := []struct {
+		name          string
+		raw           string
+		maxBodyLength int
+		wantErr       string
+	}{
+		{name: "within maximum", raw: string(heartbeat), maxBodyLength: 64},
+		{name: "above maximum", raw: string(heartbeat), maxBodyLength: 4, wantErr: "BodyLength 10 exceeds maximum of 4"},
+		// nothing is allocated for a body that never arrives
+		{name: "huge", raw: "8=FIX.4.4\x019=2000000000\x01", maxBodyLength: defaultMaxBodyLength, wantErr: "BodyLength 2000000000 exceeds maximum of 4194304"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := readMessage(bufio.NewReader(strings.NewReader(tt.raw)), tt.maxBodyLength)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.raw, string(raw))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	beginString = "FIX.4.4"
+	soh         = '\x01'
+	timeLayout  = "20060102-15:04:05.000"
+)
+
+const (
+	tagBeginSeqNo      = 7
+	tagBeginString     = 8
+	tagBodyLength      = 9
+	tagCheckSum        = 10
+	tagEndSeqNo        = 16
+	tagMsgSeqNum       = 34
+	tagMsgType         = 35
+	tagNewSeqNo        = 36
+	tagPossDupFlag     = 43
+	tagSenderCompID    = 49
+	tagSendingTime     = 52
+	tagTargetCompID    = 56
+	tagText            = 58
+	tagEncryptMethod   = 98
+	tagHeartBtInt      = 108
+	tagTestReqID       = 112
+	tagOrigSendingTime = 122
+	tagGapFillFlag     = 123
+	tagResetSeqNumFlag = 141
+
+	tagCommission   = 12
+	tagLastPx       = 31
+	tagLastQty      = 32
+	tagOrdType      = 40
+	tagSide         = 54
+	tagTransactTime = 60
+	tagExecType     = 150
+)
+
+const (
+	msgHeartbeat       = "0"
+	msgTestRequest     = "1"
+	msgResendRequest   = "2"
+	msgReject          = "3"
+	msgSequenceReset   = "4"
+	msgLogout          = "5"
+	msgExecutionReport = "8"
+	msgLogon           = "A"
+)
+
+type Field struct {
+	Tag   int
+	Value string
+}
+
+type Message struct {
+	Fields []Field
+}
+
+func (m *Message) Get(tag int) string {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+func (m *Message) Type() string {
+	return m.Get(tagMsgType)
+}
+
+func (m *Message) SeqNum() int {
+	seq, _ := strconv.Atoi(m.Get(tagMsgSeqNum))
+	return seq
+}
+
+func (m *Message) int(tag int) int {
+	v, _ := strconv.Atoi(m.Get(tag))
+	return v
+}
+
+// Encode builds a message from header and body fields without BeginString,
+// BodyLength and CheckSum, which are computed here.
+func Encode(fields []Field) []byte {
+	var body bytes.Buffer
+	for _, f := range fields {
+		body.WriteString(strconv.Itoa(f.Tag))
+		body.WriteByte('=')
+		body.WriteString(f.Value)
+		body.WriteByte(soh)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "8=%s\x019=%d\x01", beginString, body.Len())
+	msg.Write(body.Bytes())
+	fmt.Fprintf(&msg, "10=%03d\x01", checksum(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func checksum(b []byte) int {
+	var sum int
+	for _, c := range b {
+		sum += int(c)
+	}
+	return sum % 256
+}
+
+func Parse(raw []byte) (*Message, error) {
+	if len(raw) == 0 || raw[len(raw)-1] != soh {
+		return nil, errors.New("message is not terminated by SOH")
+	}
+	msg := &Message{}
+	for _, part := range bytes.Split(raw[:len(raw)-1], []byte{soh}) {
+		tag, value, ok := bytes.Cut(part, []byte{'='})
+		if !ok {
+			return nil, errors.New("invalid field: " + string(part))
+		}
+		n, err := strconv.Atoi(string(tag))
+		if err != nil {
+			return nil, errors.New("invalid tag: " + string(tag))
+		}
+		msg.Fields = append(msg.Fields, Field{Tag: n, Value: string(value)})
+	}
+	if len(msg.Fields) < 4 || msg.Fields[0].Tag != tagBeginString || msg.Fields[1].Tag != tagBodyLength ||
+		msg.Fields[2].Tag != tagMsgType || msg.Fields[len(msg.Fields)-1].Tag != tagCheckSum {
+		return nil, errors.New("invalid message header or trailer")
+	}
+	if msg.Fields[0].Value != beginString {
+		return nil, errors.New("unsupported BeginString: " + msg.Fields[0].Value)
+	}
+	trailer := bytes.LastIndex(raw[:len(raw)-1], []byte{soh, '1', '0', '='}) + 1
+	expected, _ := strconv.Atoi(msg.Fields[len(msg.Fields)-1].Value)
+	if checksum(raw[:trailer]) != expected {
+		return nil, errors.New("invalid checksum")
+	}
+	return msg, nil
+}
+
+// readMessage reads one raw message using BodyLength to find its end. A
+// BodyLength above maxBodyLength is rejected.
+func readMessage(r *bufio.Reader, maxBodyLength int) ([]byte, error) {
+	begin, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(begin, []byte("8=")) {
+		return nil, errors.New("message does not start with BeginString")
+	}
+	length, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(length, []byte("9=")) {
+		return nil, errors.New("BodyLength must follow BeginString")
+	}
+	n, err := strconv.Atoi(string(length[2 : len(length)-1]))
+	if err != nil || n <= 0 {
+		return nil, errors.New("invalid BodyLength: " + string(length))
+	}
+	if n > maxBodyLength {
+		return nil, errors.New("BodyLength " + strconv.Itoa(n) + " exceeds maximum of " + strconv.Itoa(maxBodyLength))
+	}
+	// body and the 7 byte "10=NNN<SOH>" trailer
+	rest := make([]byte, n+7)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, 0, len(begin)+len(length)+len(rest))
+	raw = append(raw, begin...)
+	raw = append(raw, length...)
+	return append(raw, rest...), nil
+}
@@ -0,0 +1,313 @@
+package fix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+const writeTimeout = 10 * time.Second
+
+var errLogout = errors.New("counterparty logged out")
+
+// session runs the FIX session layer over a single connection: logon,
+// heartbeats and test requests, sequence number checks and resend requests.
+// All writes happen on the goroutine running run.
+type session struct {
+	cfg         *config.FIXSession
+	store       *seqStore
+	conn        net.Conn
+	onExecution func(msg *Message) error
+
+	lastSent    time.Time
+	lastRecv    time.Time
+	testReqID   string
+	resendUntil int
+}
+
+func (s *session) heartBtInt() time.Duration {
+	if s.cfg.HeartBtInt < time.Second {
+		return 30 * time.Second
+	}
+	return s.cfg.HeartBtInt
+}
+
+// defaultMaxBodyLength bounds the BodyLength a counterparty may announce,
+// the body is allocated before it is read.
+const defaultMaxBodyLength = 4 << 20
+
+func (s *session) maxBodyLength() int {
+	if s.cfg.MaxBodyLength <= 0 {
+		return defaultMaxBodyLength
+	}
+	return s.cfg.MaxBodyLength
+}
+
+func (s *session) run(ctx context.Context, initiator bool) error {
+	defer s.conn.Close()
+	msgs := make(chan *Message)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go s.readLoop(msgs, errs, done)
+
+	start := time.Now()
+	s.lastRecv = start
+	if initiator {
+		if s.cfg.ResetOnLogon {
+			if err := s.store.reset(); err != nil {
+				return err
+			}
+		}
+		if err := s.sendLogon(s.cfg.ResetOnLogon); err != nil {
+			return err
+		}
+	}
+
+	loggedOn := false
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if loggedOn {
+				s.send(msgLogout)
+			}
+			return ctx.Err()
+		case err := <-errs:
+			return err
+		case msg := <-msgs:
+			s.lastRecv = time.Now()
+			s.testReqID = ""
+			if loggedOn {
+				if err := s.handle(msg); err != nil {
+					return err
+				}
+				continue
+			}
+			if msg.Type() != msgLogon {
+				return errors.New("first message must be Logon, got " + msg.Type())
+			}
+			if err := s.logon(msg, initiator); err != nil {
+				return err
+			}
+			loggedOn = true
+			logrus.Infof("fix session %s logged on", s.cfg.Name)
+		case <-ticker.C:
+			if !loggedOn {
+				if time.Since(start) > 2*s.heartBtInt() {
+					return errors.New("logon timeout")
+				}
+				continue
+			}
+			if err := s.heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *session) readLoop(msgs chan<- *Message, errs chan<- error, done <-chan struct{}) {
+	r := bufio.NewReader(s.conn)
+	for {
+		raw, err := readMessage(r, s.maxBodyLength())
+		if err != nil {
+			errs <- errors.New("failed to read message: " + err.Error())
+			return
+		}
+		msg, err := Parse(raw)
+		if err != nil {
+			// garbled messages are ignored, the sequence gap they leave is
+			// recovered with a resend request
+			logrus.Errorf("fix session %s: %s", s.cfg.Name, err.Error())
+			continue
+		}
+		select {
+		case msgs <- msg:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (s *session) logon(msg *Message, initiator bool) error {
+	if msg.Get(tagSenderCompID) != s.cfg.TargetCompID || msg.Get(tagTargetCompID) != s.cfg.SenderCompID {
+		return fmt.Errorf("unexpected logon from %s to %s", msg.Get(tagSenderCompID), msg.Get(tagTargetCompID))
+	}
+	reset := msg.Get(tagResetSeqNumFlag) == "Y"
+	if !initiator {
+		if reset {
+			if err := s.store.reset(); err != nil {
+				return err
+			}
+		}
+		if err := s.sendLogon(reset); err != nil {
+			return err
+		}
+	} else if reset && !s.cfg.ResetOnLogon {
+		if err := s.store.setIn(1); err != nil {
+			return err
+		}
+	}
+
+	seq := msg.SeqNum()
+	in, _ := s.store.next()
+	switch {
+	case seq < in:
+		s.send(msgLogout, Field{tagText, "MsgSeqNum too low"})
+		return fmt.Errorf("logon MsgSeqNum too low: expected %d, got %d", in, seq)
+	case seq > in:
+		return s.requestResend(in, seq)
+	}
+	return s.store.setIn(in + 1)
+}
+
+func (s *session) handle(msg *Message) error {
+	if msg.Type() == msgSequenceReset && msg.Get(tagGapFillFlag) != "Y" {
+		// reset mode ignores MsgSeqNum
+		if newSeq := msg.int(tagNewSeqNo); newSeq > 0 {
+			return s.store.setIn(newSeq)
+		}
+		return nil
+	}
+
+	seq := msg.SeqNum()
+	in, _ := s.store.next()
+	if seq < in {
+		if msg.Get(tagPossDupFlag) == "Y" {
+			return nil
+		}
+		s.send(msgLogout, Field{tagText, "MsgSeqNum too low"})
+		return fmt.Errorf("MsgSeqNum too low: expected %d, got %d", in, seq)
+	}
+	if seq > in {
+		switch msg.Type() {
+		case msgResendRequest:
+			if err := s.answerResend(msg); err != nil {
+				return err
+			}
+		case msgLogout:
+			return errLogout
+		}
+		return s.requestResend(in, seq)
+	}
+
+	switch msg.Type() {
+	case msgTestRequest:
+		if err := s.send(msgHeartbeat, Field{tagTestReqID, msg.Get(tagTestReqID)}); err != nil {
+			return err
+		}
+	case msgResendRequest:
+		if err := s.answerResend(msg); err != nil {
+			return err
+		}
+	case msgSequenceReset:
+		if newSeq := msg.int(tagNewSeqNo); newSeq > in {
+			return s.store.setIn(newSeq)
+		}
+	case msgLogout:
+		if err := s.store.setIn(in + 1); err != nil {
+			return err
+		}
+		s.send(msgLogout)
+		return errLogout
+	case msgReject:
+		logrus.Errorf("fix session %s: message %s rejected: %s", s.cfg.Name, msg.Get(45), msg.Get(tagText))
+	case msgExecutionReport:
+		// the sequence number only moves once the report is stored, so a
+		// failed write is resent by the counterparty after reconnect
+		if err := s.onExecution(msg); err != nil {
+			return err
+		}
+	}
+	return s.store.setIn(in + 1)
+}
+
+// requestResend asks for everything from the first missing message. Gaps
+// seen while an earlier request is being answered are ignored.
+func (s *session) requestResend(in, seq int) error {
+	if s.resendUntil >= in {
+		return nil
+	}
+	s.resendUntil = seq
+	return s.send(msgResendRequest, Field{tagBeginSeqNo, strconv.Itoa(in)}, Field{tagEndSeqNo, "0"})
+}
+
+// answerResend gap fills the requested range: a drop copy session never
+// sends application messages, so there is nothing to replay.
+func (s *session) answerResend(msg *Message) error {
+	begin := msg.int(tagBeginSeqNo)
+	_, out := s.store.next()
+	if begin <= 0 || begin >= out {
+		return nil
+	}
+	header := []Field{
+		{tagPossDupFlag, "Y"},
+		{tagOrigSendingTime, time.Now().UTC().Format(timeLayout)},
+	}
+	body := []Field{
+		{tagGapFillFlag, "Y"},
+		{tagNewSeqNo, strconv.Itoa(out)},
+	}
+	return s.write(msgSequenceReset, begin, header, body)
+}
+
+func (s *session) heartbeat() error {
+	hb := s.heartBtInt()
+	now := time.Now()
+	if s.testReqID != "" && now.Sub(s.lastRecv) > 2*hb {
+		return errors.New("heartbeat timeout")
+	}
+	if s.testReqID == "" && now.Sub(s.lastRecv) > hb+hb/5 {
+		s.testReqID = strconv.FormatInt(now.UnixNano(), 10)
+		return s.send(msgTestRequest, Field{tagTestReqID, s.testReqID})
+	}
+	if now.Sub(s.lastSent) >= hb {
+		return s.send(msgHeartbeat)
+	}
+	return nil
+}
+
+func (s *session) sendLogon(reset bool) error {
+	body := []Field{
+		{tagEncryptMethod, "0"},
+		{tagHeartBtInt, strconv.Itoa(int(s.heartBtInt().Seconds()))},
+	}
+	if reset {
+		body = append(body, Field{tagResetSeqNumFlag, "Y"})
+	}
+	return s.send(msgLogon, body...)
+}
+
+func (s *session) send(msgType string, body ...Field) error {
+	seq, err := s.store.takeOut()
+	if err != nil {
+		return err
+	}
+	return s.write(msgType, seq, nil, body)
+}
+
+func (s *session) write(msgType string, seq int, header, body []Field) error {
+	fields := []Field{
+		{tagMsgType, msgType},
+		{tagSenderCompID, s.cfg.SenderCompID},
+		{tagTargetCompID, s.cfg.TargetCompID},
+		{tagMsgSeqNum, strconv.Itoa(seq)},
+		{tagSendingTime, time.Now().UTC().Format(timeLayout)},
+	}
+	fields = append(fields, header...)
+	fields = append(fields, body...)
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := s.conn.Write(Encode(fields)); err != nil {
+		return errors.New("failed to send message: " + err.Error())
+	}
+	s.lastSent = time.Now()
+	return nil
+}
@@ -0,0 +1,83 @@
+package fix
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// seqStore keeps the next expected incoming and outgoing sequence numbers.
+// With a path they survive restarts, so a reconnect asks the counterparty to
+// resend only what was missed.
+type seqStore struct {
+	mu   sync.Mutex
+	path string
+	In   int `json:"in"`
+	Out  int `json:"out"`
+}
+
+func newSeqStore(path string) (*seqStore, error) {
+	s := &seqStore{path: path, In: 1, Out: 1}
+	if path == "" {
+		return s, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to read sequence store: " + err.Error())
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, errors.New("invalid sequence store " + path + ": " + err.Error())
+	}
+	return s, nil
+}
+
+func (s *seqStore) next() (in, out int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.In, s.Out
+}
+
+func (s *seqStore) setIn(seq int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.In = seq
+	return s.save()
+}
+
+// takeOut returns the sequence number for a new outgoing message.
+func (s *seqStore) takeOut() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq := s.Out
+	s.Out++
+	return seq, s.save()
+}
+
+func (s *seqStore) reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.In, s.Out = 1, 1
+	return s.save()
+}
+
+func (s *seqStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.New("failed to encode sequence store: " + err.Error())
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return errors.New("failed to write sequence store: " + err.Error())
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.New("failed to write sequence store: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	ok := Check{Name: "database", Check: func(ctx context.Context) (any, error) {
+		return Pool{Open: 2, Idle: 1, InUse: 1, MaxOpen: 10}, nil
+	}}
+	failing := Check{Name: "migrations", Check: func(ctx context.Context) (any, error) {
+		return nil, errors.New("database schema is dirty at version 1, fix it and run migrate force")
+	}}
+	slow := Check{Name: "slow", Check: func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	tests := []struct {
+		name           string
+		checks         []Check
+		drain          bool
+		expectedStatus string
+		expected       []Result
+	}{
+		{
+			name:           "OK",
+			checks:         []Check{ok},
+			expectedStatus: StatusOK,
+			expected:       []Result{{Name: "database", Status: StatusOK, Details: Pool{Open: 2, Idle: 1, InUse: 1, MaxOpen: 10}}},
+		},
+		{
+			name:           "No checks",
+			expectedStatus: StatusOK,
+			expected:       []Result{},
+		},
+		{
+			name:           "Failing check",
+			checks:         []Check{ok, failing},
+			expectedStatus: StatusUnavailable,
+			expected: []Result{
+				{Name: "database", Status: StatusOK, Details: Pool{Open: 2, Idle: 1, InUse: 1, MaxOpen: 10}},
+				{Name: "migrations", Status: StatusUnavailable, Error: "database schema is dirty at version 1, fix it and run migrate force"},
+			},
+		},
+		{
+			name:           "Timeout",
+			checks:         []Check{slow},
+			expectedStatus: StatusUnavailable,
+			expected:       []Result{{Name: "slow", Status: StatusUnavailable, Error: "context deadline exceeded"}},
+		},
+		{
+			name:           "Draining",
+			checks:         []Check{ok},
+			drain:          true,
+			expectedStatus: StatusDraining,
+			expected:       []Result{{Name: "database", Status: StatusOK, Details: Pool{Open: 2, Idle: 1, InUse: 1, MaxOpen: 10}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(10*time.Millisecond, tt.checks...)
+			if tt.drain {
+				checker.Drain()
+			}
+			report := checker.Ready(context.Background())
+
+			assert.Equal(t, tt.expectedStatus, report.Status)
+			assert.Equal(t, tt.drain, checker.Draining())
+			for i := range report.Checks {
+				assert.GreaterOrEqual(t, report.Checks[i].LatencyMs, 0.0)
+				report.Checks[i].LatencyMs = 0
+			}
+			assert.Equal(t, tt.expected, report.Checks)
+		})
+	}
+}
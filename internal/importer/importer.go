@@ -0,0 +1,270 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	KindOrders     = "orders"
+	KindOrderBooks = "orderbooks"
+
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+type Options struct {
+	Kind       string
+	Format     string
+	BatchSize  int
+	TimeLayout string
+	Columns    map[string]string
+	Restart    bool
+}
+
+// Stats is also the checkpoint stored next to the imported file. It is only
+// written after a batch is saved, so an interrupted import resumes from the
+// first record of the batch that was in flight. Batches are saved with an
+// insert token of the import ID and their start offset, so a batch saved
+// before the checkpoint was written is not stored twice.
+type Stats struct {
+	ID       string `json:"id"`
+	Offset   int64  `json:"offset"`
+	Line     int    `json:"line"`
+	Imported int    `json:"imported"`
+	Rejected int    `json:"rejected"`
+	Done     bool   `json:"done"`
+}
+
+type Importer struct {
+	repo *repository.Repository
+	opts Options
+}
+
+func New(repo *repository.Repository, opts Options) (*Importer, error) {
+	if opts.Kind != KindOrders && opts.Kind != KindOrderBooks {
+		return nil, errors.New("unknown import kind: " + opts.Kind)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	return &Importer{
+		repo: repo,
+		opts: opts,
+	}, nil
+}
+
+type rejectedLine struct {
+	Line   int    `json:"line"`
+	Error  string `json:"error"`
+	Record string `json:"record"`
+}
+
+type batch struct {
+	orders     []*domain.HistoryOrder
+	orderBooks []*domain.OrderBook
+	rejected   []rejectedLine
+	offset     int64
+	line       int
+}
+
+func (b *batch) len() int {
+	return len(b.orders) + len(b.orderBooks) + len(b.rejected)
+}
+
+func (i *Importer) ImportFile(ctx context.Context, path string) (*Stats, error) {
+	format, err := detectFormat(path, i.opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	checkpointPath := path + ".checkpoint"
+	stats := &Stats{}
+	if !i.opts.Restart {
+		if stats, err = loadCheckpoint(checkpointPath); err != nil {
+			return nil, err
+		}
+	}
+	if stats.Done {
+		logrus.Infof("%s is already imported, skipping", path)
+		return stats, nil
+	}
+	if stats.ID == "" {
+		stats.ID = uuid.NewString()
+		if err := saveCheckpoint(checkpointPath, stats); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("failed to open file: " + err.Error())
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.New("failed to stat file: " + err.Error())
+	}
+
+	var reader recordReader
+	if format == FormatCSV {
+		reader, err = newCSVReader(f, stats.Offset, stats.Line)
+	} else {
+		reader, err = newNDJSONReader(f, stats.Offset, stats.Line)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if stats.Offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	rejects, err := os.OpenFile(path+".rejected", flags, 0o644)
+	if err != nil {
+		return nil, errors.New("failed to open rejected lines file: " + err.Error())
+	}
+	defer rejects.Close()
+
+	b := &batch{}
+	for {
+		rec, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		i.add(b, rec)
+		if b.len() >= i.opts.BatchSize {
+			if err := i.flush(ctx, b, stats, rejects, checkpointPath, info.Size()); err != nil {
+				return stats, err
+			}
+			b = &batch{}
+		}
+	}
+	stats.Done = true
+	if err := i.flush(ctx, b, stats, rejects, checkpointPath, info.Size()); err != nil {
+		stats.Done = false
+		return stats, err
+	}
+	return stats, nil
+}
+
+func (i *Importer) add(b *batch, rec *record) {
+	b.offset = rec.offset
+	b.line = rec.line
+	err := rec.err
+	if err == nil {
+		switch i.opts.Kind {
+		case KindOrders:
+			var order *domain.HistoryOrder
+			if order, err = parseOrder(rec, i.opts.Columns, i.opts.TimeLayout); err == nil {
+				b.orders = append(b.orders, order)
+			}
+		case KindOrderBooks:
+			var orderBook *domain.OrderBook
+			if orderBook, err = parseOrderBook(rec, i.opts.Columns); err == nil {
+				b.orderBooks = append(b.orderBooks, orderBook)
+			}
+		}
+	}
+	if err != nil {
+		b.rejected = append(b.rejected, rejectedLine{Line: rec.line, Error: err.Error(), Record: rec.raw})
+	}
+}
+
+func (i *Importer) flush(ctx context.Context, b *batch, stats *Stats, rejects io.Writer, checkpointPath string, size int64) error {
+	ctx = repository.WithInsertToken(ctx, "import-"+stats.ID+"-"+strconv.FormatInt(stats.Offset, 10))
+	if len(b.orders) > 0 {
+		if err := i.repo.SaveOrders(ctx, b.orders); err != nil {
+			return err
+		}
+	}
+	if len(b.orderBooks) > 0 {
+		if err := i.repo.SaveOrderBooks(ctx, b.orderBooks); err != nil {
+			return err
+		}
+	}
+	enc := json.NewEncoder(rejects)
+	for _, rejected := range b.rejected {
+		if err := enc.Encode(rejected); err != nil {
+			return errors.New("failed to write rejected line: " + err.Error())
+		}
+	}
+	if b.len() > 0 {
+		stats.Offset = b.offset
+		stats.Line = b.line
+	}
+	stats.Imported += len(b.orders) + len(b.orderBooks)
+	stats.Rejected += len(b.rejected)
+	if err := saveCheckpoint(checkpointPath, stats); err != nil {
+		return err
+	}
+	progress := 100.0
+	if size > 0 {
+		progress = float64(stats.Offset) * 100 / float64(size)
+	}
+	logrus.WithFields(logrus.Fields{
+		"imported": stats.Imported,
+		"rejected": stats.Rejected,
+		"progress": fmt.Sprintf("%.1f%%", progress),
+	}).Info("import progress")
+	return nil
+}
+
+func detectFormat(path, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = FormatCSV
+		case ".ndjson", ".jsonl", ".json":
+			format = FormatNDJSON
+		}
+	}
+	if format != FormatCSV && format != FormatNDJSON {
+		return "", errors.New("unknown format of " + path + ", use csv or ndjson")
+	}
+	return format, nil
+}
+
+func loadCheckpoint(path string) (*Stats, error) {
+	var stats Stats
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &stats, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to read checkpoint: " + err.Error())
+	}
+	if err := json.Unmarshal(b, &stats); err != nil {
+		return nil, errors.New("invalid checkpoint " + path + ": " + err.Error())
+	}
+	return &stats, nil
+}
+
+func saveCheckpoint(path string, stats *Stats) error {
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return errors.New("failed to encode checkpoint: " + err.Error())
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return errors.New("failed to write checkpoint: " + err.Error())
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.New("failed to write checkpoint: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const ordersCSV = `client,exchange_name,label,pair,side,type,base_qty,price,algorithm_name_placed,lowest_sell_prc,highest_buy_prc,commission_quote_qty,time_placed
+Misha,binance,111,BTCUSDT,buy,limit,1,50000,alg1,49900,50100,0.1,2024-07-01 10:00:00
+Misha,binance,111,BTCUSDT,sell,limit,abc,50000,alg1,49900,50100,0.1,2024-07-01 10:00:01
+Misha,binance,111,BTCUSDT,sell,limit,2,50010,alg1,49900,50100,0.1,2024-07-01 10:00:02
+Misha,binance,111,BTCUSDT,buy,market,3,50020,alg1,49900,50100,0.1,2024-07-01 10:00:03
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestImporter_ImportFile(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockOrderhistory, saved *[]*domain.HistoryOrder)
+
+	tests := []struct {
+		name             string
+		fileName         string
+		content          string
+		mockBehavior     mockBehavior
+		expectedPrices   []float64
+		expectedRejected int
+	}{
+		{
+			name:     "CSV with column mapping",
+			fileName: "orders.csv",
+			content:  ordersCSV,
+			mockBehavior: func(r *mock_repository.MockOrderhistory, saved *[]*domain.HistoryOrder) {
+				r.EXPECT().SaveOrders(gomock.Any(), gomock.Any()).Return(nil).Times(2).Do(func(_ context.Context, orders []*domain.HistoryOrder) {
+					*saved = append(*saved, orders...)
+				})
+			},
+			expectedPrices:   []float64{50000, 50010, 50020},
+			expectedRejected: 1,
+		},
+		{
+			name:     "NDJSON in API format",
+			fileName: "orders.ndjson",
+			content: `{"client":{"client":"Misha","exchange_name":"binance","label":"111","pair":"BTCUSDT"},"side":"buy","type":"limit","base_qty":1,"price":50000,"algorithm_name_placed":"alg1","lowest_sell_prc":49900,"highest_buy_prc":50100,"commission_quote_qty":0.1,"time_placed":"2024-07-01T10:00:00Z"}
+
+not json
+`,
+			mockBehavior: func(r *mock_repository.MockOrderhistory, saved *[]*domain.HistoryOrder) {
+				r.EXPECT().SaveOrders(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, orders []*domain.HistoryOrder) {
+					*saved = append(*saved, orders...)
+				})
+			},
+			expectedPrices:   []float64{50000},
+			expectedRejected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			var saved []*domain.HistoryOrder
+			repo := mock_repository.NewMockOrderhistory(c)
+			tt.mockBehavior(repo, &saved)
+
+			path := writeFile(t, tt.fileName, tt.content)
+			imp, err := New(&repository.Repository{Orderhistory: repo}, Options{
+				Kind:       KindOrders,
+				BatchSize:  2,
+				TimeLayout: "2006-01-02 15:04:05",
+				Columns:    map[string]string{"client_name": "client"},
+			})
+			assert.NoError(t, err)
+
+			stats, err := imp.ImportFile(context.Background(), path)
+			assert.NoError(t, err)
+			assert.True(t, stats.Done)
+			assert.Equal(t, len(tt.expectedPrices), stats.Imported)
+			assert.Equal(t, tt.expectedRejected, stats.Rejected)
+
+			var prices []float64
+			for _, order := range saved {
+				assert.Equal(t, "Misha", order.Client.ClientName)
+				assert.False(t, order.TimePlaced.IsZero())
+				prices = append(prices, order.Price)
+			}
+			assert.Equal(t, tt.expectedPrices, prices)
+
+			rejected, err := os.ReadFile(path + ".rejected")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRejected, strings.Count(string(rejected), "\n"))
+		})
+	}
+}
+
+func TestImporter_Resume(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	var saved []*domain.HistoryOrder
+	repo := mock_repository.NewMockOrderhistory(c)
+	gomock.InOrder(
+		repo.EXPECT().SaveOrders(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, orders []*domain.HistoryOrder) {
+			saved = append(saved, orders...)
+		}),
+		repo.EXPECT().SaveOrders(gomock.Any(), gomock.Any()).Return(errors.New("server error")),
+		repo.EXPECT().SaveOrders(gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, orders []*domain.HistoryOrder) {
+			saved = append(saved, orders...)
+		}),
+	)
+
+	path := writeFile(t, "orders.csv", ordersCSV)
+	imp, err := New(&repository.Repository{Orderhistory: repo}, Options{
+		Kind:       KindOrders,
+		BatchSize:  2,
+		TimeLayout: "2006-01-02 15:04:05",
+		Columns:    map[string]string{"client_name": "client"},
+	})
+	assert.NoError(t, err)
+
+	_, err = imp.ImportFile(context.Background(), path)
+	assert.Error(t, err)
+
+	stats, err := imp.ImportFile(context.Background(), path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Imported)
+	assert.Equal(t, 1, stats.Rejected)
+	assert.Len(t, saved, 3)
+	assert.Equal(t, time.Date(2024, 7, 1, 10, 0, 3, 0, time.UTC), saved[2].TimePlaced)
+
+	stats, err = imp.ImportFile(context.Background(), path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Imported)
+}
+
+// crashAfterSave stores the first batch and then fails, like an import killed
+// before it wrote the checkpoint of a saved batch.
+type crashAfterSave struct {
+	repository.Orderhistory
+	crashed bool
+}
+
+func (c *crashAfterSave) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	if err := c.Orderhistory.SaveOrders(ctx, orders); err != nil {
+		return err
+	}
+	if !c.crashed {
+		c.crashed = true
+		return errors.New("killed")
+	}
+	return nil
+}
+
+func TestImporter_ResumeAfterSavedBatch(t *testing.T) {
+	memory := repository.NewMemory()
+	path := writeFile(t, "orders.csv", ordersCSV)
+	imp, err := New(&repository.Repository{Orderhistory: &crashAfterSave{Orderhistory: memory}}, Options{
+		Kind:       KindOrders,
+		BatchSize:  2,
+		TimeLayout: "2006-01-02 15:04:05",
+		Columns:    map[string]string{"client_name": "client"},
+	})
+	assert.NoError(t, err)
+
+	_, err = imp.ImportFile(context.Background(), path)
+	assert.Error(t, err)
+	stats, err := imp.ImportFile(context.Background(), path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Imported)
+
+	orders, err := memory.GetOrderHistory(context.Background(), &domain.Client{
+		ClientName: "Misha", ExchangeName: "binance", Label: "111", Pair: "BTCUSDT",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 3)
+}
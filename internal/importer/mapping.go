@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// fieldParser reads domain fields from a record through the column mapping
+// and keeps the first conversion error.
+type fieldParser struct {
+	rec     *record
+	columns map[string]string
+	err     error
+}
+
+func (p *fieldParser) str(field string) string {
+	column := field
+	if c, ok := p.columns[field]; ok && c != "" {
+		column = c
+	}
+	return p.rec.fields[column]
+}
+
+func (p *fieldParser) float(field string) float64 {
+	s := p.str(field)
+	if s == "" || p.err != nil {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		p.err = errors.New("invalid " + field + ": " + s)
+	}
+	return v
+}
+
+func (p *fieldParser) time(field, layout string) time.Time {
+	s := p.str(field)
+	if p.err != nil {
+		return time.Time{}
+	}
+	if s == "" {
+		p.err = errors.New(field + " is required")
+		return time.Time{}
+	}
+	for _, l := range []string{layout, time.RFC3339Nano} {
+		if l == "" {
+			continue
+		}
+		if t, err := time.Parse(l, s); err == nil {
+			return t
+		}
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC()
+	}
+	p.err = errors.New("invalid " + field + ": " + s)
+	return time.Time{}
+}
+
+func (p *fieldParser) levels(field string) []domain.DepthOrder {
+	s := p.str(field)
+	if p.err != nil {
+		return nil
+	}
+	var pairs [][2]float64
+	if err := json.Unmarshal([]byte(s), &pairs); err == nil {
+		levels := make([]domain.DepthOrder, len(pairs))
+		for i, pair := range pairs {
+			levels[i] = domain.DepthOrder{Price: pair[0], BaseQty: pair[1]}
+		}
+		return levels
+	}
+	var levels []domain.DepthOrder
+	if err := json.Unmarshal([]byte(s), &levels); err != nil {
+		p.err = errors.New("invalid " + field + ": " + s)
+	}
+	return levels
+}
+
+func parseOrder(rec *record, columns map[string]string, timeLayout string) (*domain.HistoryOrder, error) {
+	p := fieldParser{rec: rec, columns: columns}
+	order := &domain.HistoryOrder{
+		Client: domain.Client{
+			ClientName:   p.str("client_name"),
+			ExchangeName: p.str("exchange_name"),
+			Label:        p.str("label"),
+			Pair:         p.str("pair"),
+		},
+		Side:                p.str("side"),
+		Type:                p.str("type"),
+		BaseQty:             p.float("base_qty"),
+		Price:               p.float("price"),
+		AlgorithmNamePlaced: p.str("algorithm_name_placed"),
+		LowestSellPrice:     p.float("lowest_sell_prc"),
+		HighestBuyPrice:     p.float("highest_buy_prc"),
+		CommissionQuoteQty:  p.float("commission_quote_qty"),
+		TimePlaced:          p.time("time_placed", timeLayout),
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := binding.Validator.ValidateStruct(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func parseOrderBook(rec *record, columns map[string]string) (*domain.OrderBook, error) {
+	p := fieldParser{rec: rec, columns: columns}
+	orderBook := &domain.OrderBook{
+		ID:       int64(p.float("id")),
+		Exchange: p.str("exchange"),
+		Pair:     p.str("pair"),
+		Asks:     p.levels("asks"),
+		Bids:     p.levels("bids"),
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := binding.Validator.ValidateStruct(orderBook); err != nil {
+		return nil, err
+	}
+	if orderBook.ID == 0 {
+		orderBook.ID = int64(uuid.New().ID())
+	}
+	return orderBook, nil
+}
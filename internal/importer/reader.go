@@ -0,0 +1,149 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// record is a single source line. offset points right after the record, so a
+// checkpoint taken after it resumes with the next one.
+type record struct {
+	line   int
+	offset int64
+	raw    string
+	fields map[string]string
+	err    error
+}
+
+type recordReader interface {
+	next() (*record, error)
+}
+
+type csvReader struct {
+	reader     *csv.Reader
+	header     []string
+	baseOffset int64
+	baseLine   int
+}
+
+func newCSVReader(f *os.File, offset int64, line int) (*csvReader, error) {
+	reader := csv.NewReader(f)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("failed to read csv header: " + err.Error())
+	}
+	header = append([]string(nil), header...)
+	if offset == 0 {
+		return &csvReader{reader: reader, header: header}, nil
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, errors.New("failed to seek to checkpoint: " + err.Error())
+	}
+	reader = csv.NewReader(f)
+	reader.FieldsPerRecord = len(header)
+	return &csvReader{reader: reader, header: header, baseOffset: offset, baseLine: line}, nil
+}
+
+func (r *csvReader) next() (*record, error) {
+	values, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+		return nil, errors.New("failed to read csv: " + err.Error())
+	}
+	line, _ := r.reader.FieldPos(0)
+	rec := &record{
+		line:   r.baseLine + line,
+		offset: r.baseOffset + r.reader.InputOffset(),
+		raw:    joinCSV(values),
+		err:    err,
+	}
+	if err == nil {
+		rec.fields = make(map[string]string, len(r.header))
+		for i, column := range r.header {
+			rec.fields[column] = values[i]
+		}
+	}
+	return rec, nil
+}
+
+func joinCSV(values []string) string {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Write(values)
+	w.Flush()
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+type ndjsonReader struct {
+	reader *bufio.Reader
+	offset int64
+	line   int
+}
+
+func newNDJSONReader(f *os.File, offset int64, line int) (*ndjsonReader, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, errors.New("failed to seek to checkpoint: " + err.Error())
+	}
+	return &ndjsonReader{reader: bufio.NewReader(f), offset: offset, line: line}, nil
+}
+
+func (r *ndjsonReader) next() (*record, error) {
+	for {
+		b, err := r.reader.ReadBytes('\n')
+		if len(b) == 0 && err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return nil, errors.New("failed to read ndjson: " + err.Error())
+		}
+		r.offset += int64(len(b))
+		r.line++
+		raw := strings.TrimSpace(string(b))
+		if raw == "" {
+			continue
+		}
+		rec := &record{line: r.line, offset: r.offset, raw: raw}
+		var obj map[string]any
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&obj); err != nil {
+			rec.err = err
+		} else {
+			rec.fields = make(map[string]string)
+			flatten(obj, rec.fields)
+		}
+		return rec, nil
+	}
+}
+
+// flatten copies leaf values of nested objects under their own keys, so the
+// API representation of an order ({"client": {"client_name": ...}}) maps to
+// the same field names as a flat CSV export. Arrays are kept as JSON.
+func flatten(obj map[string]any, fields map[string]string) {
+	for key, value := range obj {
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(v, fields)
+		case []any:
+			b, _ := json.Marshal(v)
+			fields[key] = string(b)
+		case json.Number:
+			fields[key] = v.String()
+		case string:
+			fields[key] = v
+		case bool:
+			fields[key] = strconv.FormatBool(v)
+		case nil:
+			fields[key] = ""
+		}
+	}
+}
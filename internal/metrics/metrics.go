@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "trade_metrics"
+
+// Metrics keeps its own registry, so that several instances, one per test,
+// don't collide.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests   *prometheus.CounterVec
+	httpDuration   *prometheus.HistogramVec
+	repoDuration   *prometheus.HistogramVec
+	repoErrors     *prometheus.CounterVec
+	ordersSaved    *prometheus.CounterVec
+	orderBooks     *prometheus.CounterVec
+	orderBookLevel *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_duration_seconds",
+			Help:      "Repository operation latency by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_errors_total",
+			Help:      "Failed repository operations by method.",
+		}, []string{"method"}),
+		ordersSaved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_saved_total",
+			Help:      "Saved orders by exchange and pair.",
+		}, []string{"exchange", "pair"}),
+		orderBooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "order_books_saved_total",
+			Help:      "Saved order books by exchange and pair.",
+		}, []string{"exchange", "pair"}),
+		orderBookLevel: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "order_book_levels_total",
+			Help:      "Price levels of saved order books by exchange, pair and side.",
+		}, []string{"exchange", "pair", "side"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.repoDuration,
+		m.repoErrors,
+		m.ordersSaved,
+		m.orderBooks,
+		m.orderBookLevel,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware counts requests by their route template, so that path
+// parameters don't blow up the number of series. Requests matching no
+// route are counted as "unmatched".
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handle counts the saved orders and order books published on the event
+// bus.
+func (m *Metrics) Handle(e event.Event) {
+	switch e.Type {
+	case event.TypeOrderSaved:
+		m.ordersSaved.WithLabelValues(e.Exchange, e.Pair).Inc()
+	case event.TypeOrderBookSaved:
+		m.orderBooks.WithLabelValues(e.Exchange, e.Pair).Inc()
+		m.orderBookLevel.WithLabelValues(e.Exchange, e.Pair, "ask").Add(float64(len(e.OrderBook.Asks)))
+		m.orderBookLevel.WithLabelValues(e.Exchange, e.Pair, "bid").Add(float64(len(e.OrderBook.Bids)))
+	}
+}
+
+// RegisterDB exports the connection pool state of db. The memory backend
+// has no pool.
+func (m *Metrics) RegisterDB(db repository.DB) {
+	switch db := db.(type) {
+	case driver.Conn:
+		m.registry.MustRegister(newClickHouseCollector(db))
+	case *repository.SQLDB:
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db.DB, namespace))
+	}
+}
+
+type clickHouseCollector struct {
+	conn         driver.Conn
+	open         *prometheus.Desc
+	idle         *prometheus.Desc
+	maxOpenConns *prometheus.Desc
+	maxIdleConns *prometheus.Desc
+}
+
+func newClickHouseCollector(conn driver.Conn) *clickHouseCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "clickhouse", name), help, nil, nil)
+	}
+	return &clickHouseCollector{
+		conn:         conn,
+		open:         desc("open_connections", "Open ClickHouse connections."),
+		idle:         desc("idle_connections", "Idle ClickHouse connections."),
+		maxOpenConns: desc("max_open_connections", "Maximum number of open ClickHouse connections."),
+		maxIdleConns: desc("max_idle_connections", "Maximum number of idle ClickHouse connections."),
+	}
+}
+
+func (c *clickHouseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.open
+	ch <- c.idle
+	ch <- c.maxOpenConns
+	ch <- c.maxIdleConns
+}
+
+func (c *clickHouseCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.conn.Stats()
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.Open))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.maxOpenConns, prometheus.GaugeValue, float64(stats.MaxOpenConns))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleConns, prometheus.GaugeValue, float64(stats.MaxIdleConns))
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMetrics_Middleware(t *testing.T) {
+	m := New()
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/orderbook/:exchangeName/:pair/", func(c *gin.Context) { c.Status(200) })
+	r.GET("/metrics", gin.WrapH(m.Handler()))
+	for _, target := range []string{"/orderbook/binance/BTCUSDT/", "/orderbook/binance/ETHUSDT/", "/unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/orderbook/:exchangeName/:pair/", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "unmatched", "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.httpDuration))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `trade_metrics_http_requests_total{method="GET",route="/orderbook/:exchangeName/:pair/",status="200"} 2`)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
+
+func TestInstrument(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	orderBooks := mock_repository.NewMockOrderbook(c)
+	orderHistory := mock_repository.NewMockOrderhistory(c)
+	orderBooks.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(nil, repository.ErrNotFound)
+	orderBooks.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", gomock.Any()).Return(nil)
+	orderHistory.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(errors.New("failed to save order: connection refused"))
+	orderHistory.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(nil)
+
+	m := New()
+	repo := Instrument(&repository.Repository{Orderbook: orderBooks, Orderhistory: orderHistory}, m)
+	ctx := context.Background()
+	_, err := repo.GetOrderBook(ctx, "binance", "BTCUSDT")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.NoError(t, repo.SaveOrderBook(ctx, "binance", "BTCUSDT", &domain.AsksBids{}))
+	assert.EqualError(t, repo.SaveOrder(ctx, &domain.HistoryOrder{}), "failed to save order: connection refused")
+	assert.NoError(t, repo.SaveOrder(ctx, &domain.HistoryOrder{}))
+
+	assert.Equal(t, 3, testutil.CollectAndCount(m.repoDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.repoErrors))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.repoErrors.WithLabelValues("SaveOrder")))
+}
+
+func TestMetrics_Handle(t *testing.T) {
+	m := New()
+	bus := event.NewBus()
+	bus.Subscribe(m.Handle)
+	client := domain.Client{ClientName: "Misha", ExchangeName: "binance", Pair: "BTCUSDT"}
+	bus.Publish(event.OrderSaved(&domain.HistoryOrder{Client: client}))
+	bus.Publish(event.OrderSaved(&domain.HistoryOrder{Client: client}))
+	bus.Publish(event.OrderBookSaved("binance", "ETHUSDT", &domain.AsksBids{
+		Asks: []domain.DepthOrder{{Price: 3000, BaseQty: 1}, {Price: 3001, BaseQty: 2}},
+		Bids: []domain.DepthOrder{{Price: 2999, BaseQty: 1}},
+	}))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.ordersSaved.WithLabelValues("binance", "BTCUSDT")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.orderBooks.WithLabelValues("binance", "ETHUSDT")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.orderBookLevel.WithLabelValues("binance", "ETHUSDT", "ask")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.orderBookLevel.WithLabelValues("binance", "ETHUSDT", "bid")))
+}
+
+func TestMetrics_RegisterDB(t *testing.T) {
+	db, err := repository.NewSQLiteDB(&config.SQLite{Path: filepath.Join(t.TempDir(), "test.db")})
+	assert.NoError(t, err)
+	defer db.Close()
+	m := New()
+	m.RegisterDB(db)
+	m.RegisterDB(repository.NewMemory())
+
+	count, err := testutil.GatherAndCount(m.registry, "go_sql_max_open_connections")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+)
+
+// Instrument wraps repo so that the latency of every operation and its
+// failures are recorded per method. Order books that were never saved are
+// not counted as failures.
+func Instrument(repo *repository.Repository, m *Metrics) *repository.Repository {
+	return &repository.Repository{
+		Orderbook:    &orderBookMetrics{Orderbook: repo.Orderbook, m: m},
+		Orderhistory: &orderHistoryMetrics{Orderhistory: repo.Orderhistory, m: m},
+	}
+}
+
+func (m *Metrics) observe(method string, start time.Time, err error) {
+	m.repoDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		m.repoErrors.WithLabelValues(method).Inc()
+	}
+}
+
+type orderBookMetrics struct {
+	repository.Orderbook
+	m *Metrics
+}
+
+func (o *orderBookMetrics) GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+	start := time.Now()
+	orderBook, err := o.Orderbook.GetOrderBook(ctx, exchangeName, pair)
+	o.m.observe("GetOrderBook", start, err)
+	return orderBook, err
+}
+
+func (o *orderBookMetrics) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	start := time.Now()
+	err := o.Orderbook.SaveOrderBook(ctx, exchangeName, pair, asksBids)
+	o.m.observe("SaveOrderBook", start, err)
+	return err
+}
+
+func (o *orderBookMetrics) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	start := time.Now()
+	err := o.Orderbook.SaveOrderBooks(ctx, orderBooks)
+	o.m.observe("SaveOrderBooks", start, err)
+	return err
+}
+
+func (o *orderBookMetrics) ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(orderBook *domain.OrderBook) error) error {
+	start := time.Now()
+	err := o.Orderbook.ExportOrderBooks(ctx, exchangeName, pair, fn)
+	o.m.observe("ExportOrderBooks", start, err)
+	return err
+}
+
+type orderHistoryMetrics struct {
+	repository.Orderhistory
+	m *Metrics
+}
+
+func (o *orderHistoryMetrics) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
+	start := time.Now()
+	orders, err := o.Orderhistory.GetOrderHistory(ctx, client)
+	o.m.observe("GetOrderHistory", start, err)
+	return orders, err
+}
+
+func (o *orderHistoryMetrics) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	start := time.Now()
+	orders, err := o.Orderhistory.GetOrderHistories(ctx, clients, limit)
+	o.m.observe("GetOrderHistories", start, err)
+	return orders, err
+}
+
+func (o *orderHistoryMetrics) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	start := time.Now()
+	err := o.Orderhistory.SaveOrder(ctx, order)
+	o.m.observe("SaveOrder", start, err)
+	return err
+}
+
+func (o *orderHistoryMetrics) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	start := time.Now()
+	err := o.Orderhistory.SaveOrders(ctx, orders)
+	o.m.observe("SaveOrders", start, err)
+	return err
+}
+
+func (o *orderHistoryMetrics) ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+	start := time.Now()
+	err := o.Orderhistory.ExportOrders(ctx, filter, fn)
+	o.m.observe("ExportOrders", start, err)
+	return err
+}
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+type clickHouse struct {
+	db driver.Conn
+}
+
+// NewClickHouse keeps the version like the clickhouse driver of
+// golang-migrate: every change is a new row, the one with the highest
+// sequence is the current version.
+func NewClickHouse(db driver.Conn) Driver {
+	return &clickHouse{
+		db: db,
+	}
+}
+
+func (c *clickHouse) Prepare(ctx context.Context) error {
+	err := c.db.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version Int64, dirty UInt8, sequence UInt64) ENGINE = TinyLog`)
+	if err != nil {
+		return errors.New("failed to create schema_migrations: " + err.Error())
+	}
+	return nil
+}
+
+func (c *clickHouse) Version(ctx context.Context) (int64, bool, error) {
+	rows, err := c.db.Query(ctx, `SELECT version, dirty FROM schema_migrations ORDER BY sequence DESC LIMIT 1`)
+	if err != nil {
+		return 0, false, errors.New("failed to get schema version: " + err.Error())
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return NilVersion, false, rows.Err()
+	}
+	var (
+		version int64
+		dirty   uint8
+	)
+	if err := rows.Scan(&version, &dirty); err != nil {
+		return 0, false, errors.New("failed to scan schema version: " + err.Error())
+	}
+	return version, dirty == 1, nil
+}
+
+func (c *clickHouse) SetVersion(ctx context.Context, version int64, dirty bool) error {
+	var dirtyFlag uint8
+	if dirty {
+		dirtyFlag = 1
+	}
+	err := c.db.Exec(ctx, `INSERT INTO schema_migrations (version, dirty, sequence) VALUES (?, ?, ?)`,
+		version, dirtyFlag, uint64(time.Now().UnixNano()))
+	if err != nil {
+		return errors.New("failed to set schema version: " + err.Error())
+	}
+	return nil
+}
+
+// Exec runs the statements of migration one by one, ClickHouse doesn't take
+// several in one query.
+func (c *clickHouse) Exec(ctx context.Context, migration string) error {
+	for _, statement := range strings.Split(migration, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if err := c.db.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+// Package migrate applies the embedded schema migrations. It keeps the
+// version in the schema_migrations table of golang-migrate, so databases
+// migrated with its CLI before keep their version.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NilVersion is the version of a database without any migration applied.
+const NilVersion int64 = -1
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Driver keeps the schema version of a database and runs migrations on it.
+type Driver interface {
+	// Prepare creates the version table if it doesn't exist.
+	Prepare(ctx context.Context) error
+	Version(ctx context.Context) (version int64, dirty bool, err error)
+	SetVersion(ctx context.Context, version int64, dirty bool) error
+	Exec(ctx context.Context, migration string) error
+}
+
+type Status struct {
+	Version int64
+	Dirty   bool
+	// Latest is the version of the last known migration, the version the
+	// code expects.
+	Latest  int64
+	Pending []Migration
+}
+
+type Migrator struct {
+	driver     Driver
+	migrations []Migration
+}
+
+// Load reads the migrations of dir in fsys, named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.New("failed to read migrations: " + err.Error())
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		base, direction, ok := cutDirection(strings.TrimSuffix(name, ".sql"))
+		if !ok {
+			return nil, errors.New("invalid migration file name: " + name)
+		}
+		versionText, title, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionText, 10, 64)
+		if err != nil || version < 0 {
+			return nil, errors.New("invalid migration version: " + name)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, errors.New("failed to read migration: " + err.Error())
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func cutDirection(name string) (string, string, bool) {
+	for _, direction := range []string{"up", "down"} {
+		if base, ok := strings.CutSuffix(name, "."+direction); ok {
+			return base, direction, true
+		}
+	}
+	return "", "", false
+}
+
+func New(driver Driver, migrations []Migration) *Migrator {
+	return &Migrator{
+		driver:     driver,
+		migrations: migrations,
+	}
+}
+
+// Status returns the version of the database and the migrations that
+// Up would apply.
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	if err := m.driver.Prepare(ctx); err != nil {
+		return nil, err
+	}
+	return m.ReadStatus(ctx)
+}
+
+// ReadStatus is Status without creating the version table, it only reads
+// and suits checks that run often like readiness. It fails when the table
+// doesn't exist.
+func (m *Migrator) ReadStatus(ctx context.Context) (*Status, error) {
+	version, dirty, err := m.driver.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{Version: version, Dirty: dirty, Latest: NilVersion}
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Err returns an error unless the status is at the latest version and
+// isn't dirty.
+func (s *Status) Err() error {
+	if s.Dirty {
+		return errors.New("database schema is dirty at version " + FormatVersion(s.Version) + ", fix it and run migrate force")
+	}
+	if s.Version != s.Latest {
+		return errors.New("database schema version " + FormatVersion(s.Version) + " doesn't match the expected version " +
+			FormatVersion(s.Latest) + ", run migrate up")
+	}
+	return nil
+}
+
+// Check returns an error unless the database has the latest version and
+// isn't dirty.
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
+
+// Up applies the pending migrations in order and returns how many it
+// applied. A failing migration leaves the database dirty at its version.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	status, err := m.clean(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, migration := range status.Pending {
+		if err := m.apply(ctx, migration.Version, migration.Up); err != nil {
+			return i, errors.New("failed to apply migration " + FormatVersion(migration.Version) + ": " + err.Error())
+		}
+	}
+	return len(status.Pending), nil
+}
+
+// Down reverts the last steps applied migrations and returns how many it
+// reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	status, err := m.clean(ctx)
+	if err != nil {
+		return 0, err
+	}
+	current := -1
+	for i, migration := range m.migrations {
+		if migration.Version == status.Version {
+			current = i
+		}
+	}
+	if current < 0 && status.Version != NilVersion {
+		return 0, errors.New("unknown database schema version " + FormatVersion(status.Version))
+	}
+	var reverted int
+	for i := current; i >= 0 && reverted < steps; i-- {
+		previous := NilVersion
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+		if err := m.apply(ctx, previous, m.migrations[i].Down); err != nil {
+			return reverted, errors.New("failed to revert migration " + FormatVersion(m.migrations[i].Version) + ": " + err.Error())
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Force sets the version without running migrations and clears the dirty
+// flag, after a failed migration has been fixed by hand.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if err := m.driver.Prepare(ctx); err != nil {
+		return err
+	}
+	return m.driver.SetVersion(ctx, version, false)
+}
+
+func (m *Migrator) clean(ctx context.Context) (*Status, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, errors.New("database schema is dirty at version " + FormatVersion(status.Version) + ", fix it and run migrate force")
+	}
+	return status, nil
+}
+
+// apply runs migration and sets the database to target, which is marked
+// dirty while the migration runs.
+func (m *Migrator) apply(ctx context.Context, target int64, migration string) error {
+	if err := m.driver.SetVersion(ctx, target, true); err != nil {
+		return err
+	}
+	if strings.TrimSpace(migration) != "" {
+		if err := m.driver.Exec(ctx, migration); err != nil {
+			return err
+		}
+	}
+	return m.driver.SetVersion(ctx, target, false)
+}
+
+// FormatVersion returns version as text, "none" for NilVersion.
+func FormatVersion(version int64) string {
+	if version == NilVersion {
+		return "none"
+	}
+	return strconv.FormatInt(version, 10)
+}
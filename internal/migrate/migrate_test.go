@@ -0,0 +1,138 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kolibriee/trade-metrics/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	for _, dir := range []string{"clickhouse", "sqlite", "postgres"} {
+		list, err := Load(migrations.FS, dir)
+		require.NoError(t, err, dir)
+		require.NotEmpty(t, list, dir)
+		for i, migration := range list {
+			assert.NotEmpty(t, migration.Up, "%s %d", dir, migration.Version)
+			assert.NotEmpty(t, migration.Down, "%s %d", dir, migration.Version)
+			if i > 0 {
+				assert.Greater(t, migration.Version, list[i-1].Version)
+			}
+		}
+	}
+}
+
+func TestLoad_InvalidName(t *testing.T) {
+	_, err := Load(fstest.MapFS{"m/init.up.sql": {Data: []byte("SELECT 1")}}, "m")
+	assert.EqualError(t, err, "invalid migration version: init.up.sql")
+	_, err = Load(fstest.MapFS{"m/1_init.sql": {Data: []byte("SELECT 1")}}, "m")
+	assert.EqualError(t, err, "invalid migration file name: 1_init.sql")
+}
+
+var testMigrations = fstest.MapFS{
+	"m/1_books.up.sql":     {Data: []byte("CREATE TABLE books (id INTEGER);\nCREATE INDEX books_id ON books (id);")},
+	"m/1_books.down.sql":   {Data: []byte("DROP TABLE books;")},
+	"m/2_orders.up.sql":    {Data: []byte("CREATE TABLE orders (id INTEGER);")},
+	"m/2_orders.down.sql":  {Data: []byte("DROP TABLE orders;")},
+	"m/10_broken.up.sql":   {Data: []byte("CREATE TABLE orders (id INTEGER);")},
+	"m/10_broken.down.sql": {Data: []byte("")},
+}
+
+func newTestMigrator(t *testing.T, files fstest.MapFS) (*Migrator, *sql.DB) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	list, err := Load(files, "m")
+	require.NoError(t, err)
+	return New(NewSQLite(db), list), db
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	files := fstest.MapFS{}
+	for name, file := range testMigrations {
+		if name != "m/10_broken.up.sql" && name != "m/10_broken.down.sql" {
+			files[name] = file
+		}
+	}
+	m, db := newTestMigrator(t, files)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, NilVersion, status.Version)
+	assert.Equal(t, int64(2), status.Latest)
+	assert.Len(t, status.Pending, 2)
+	assert.EqualError(t, m.Check(ctx), "database schema version none doesn't match the expected version 2, run migrate up")
+
+	n, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, m.Check(ctx))
+	_, err = db.Exec("INSERT INTO orders (id) VALUES (1)")
+	assert.NoError(t, err)
+
+	n, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	n, err = m.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), status.Version)
+	assert.Equal(t, []Migration{{Version: 2, Name: "orders", Up: "CREATE TABLE orders (id INTEGER);", Down: "DROP TABLE orders;"}}, status.Pending)
+
+	n, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, NilVersion, status.Version)
+	_, err = db.Exec("SELECT * FROM books")
+	assert.Error(t, err)
+}
+
+func TestMigrator_Dirty(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestMigrator(t, testMigrations)
+
+	n, err := m.Up(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), status.Version)
+	assert.True(t, status.Dirty)
+	assert.EqualError(t, m.Check(ctx), "database schema is dirty at version 10, fix it and run migrate force")
+	_, err = m.Up(ctx)
+	assert.EqualError(t, err, "database schema is dirty at version 10, fix it and run migrate force")
+
+	require.NoError(t, m.Force(ctx, 10))
+	assert.NoError(t, m.Check(ctx))
+}
+
+func TestMigrator_ReadStatus(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t, testMigrations)
+
+	// the version table isn't created by reading
+	_, err := m.ReadStatus(ctx)
+	assert.Error(t, err)
+	var tables int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&tables))
+	assert.Equal(t, 0, tables)
+
+	require.NoError(t, m.Force(ctx, 2))
+	status, err := m.ReadStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), status.Version)
+	assert.Equal(t, int64(10), status.Latest)
+	assert.Len(t, status.Pending, 1)
+}
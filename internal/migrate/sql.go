@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlDB struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// NewSQLite and NewPostgres keep the version like the sqlite and postgres
+// drivers of golang-migrate, in a single row.
+func NewSQLite(db *sql.DB) Driver {
+	return &sqlDB{db: db}
+}
+
+func NewPostgres(db *sql.DB) Driver {
+	return &sqlDB{db: db, postgres: true}
+}
+
+func (s *sqlDB) Prepare(ctx context.Context) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (version uint64, dirty bool);
+        CREATE UNIQUE INDEX IF NOT EXISTS version_unique ON schema_migrations (version)`
+	if s.postgres {
+		query = `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+	}
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return errors.New("failed to create schema_migrations: " + err.Error())
+	}
+	return nil
+}
+
+func (s *sqlDB) Version(ctx context.Context) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.New("failed to get schema version: " + err.Error())
+	}
+	return version, dirty, nil
+}
+
+func (s *sqlDB) SetVersion(ctx context.Context, version int64, dirty bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.New("failed to set schema version: " + err.Error())
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return errors.New("failed to set schema version: " + err.Error())
+	}
+	if version != NilVersion || dirty {
+		insert := `INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)`
+		if s.postgres {
+			insert = `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`
+		}
+		if _, err := tx.ExecContext(ctx, insert, version, dirty); err != nil {
+			return errors.New("failed to set schema version: " + err.Error())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.New("failed to set schema version: " + err.Error())
+	}
+	return nil
+}
+
+func (s *sqlDB) Exec(ctx context.Context, migration string) error {
+	_, err := s.db.ExecContext(ctx, migration)
+	return err
+}
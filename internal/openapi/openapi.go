@@ -0,0 +1,209 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+func init() {
+	for _, contentType := range []string{"application/x-protobuf", "application/protobuf", "application/msgpack", "application/x-msgpack"} {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.FileBodyDecoder)
+	}
+}
+
+// Spec is the OpenAPI document of the HTTP API. Its middleware rejects
+// requests that don't match the document and checks every response against
+// it, so handlers and the document can't drift apart unnoticed.
+type Spec struct {
+	cfg    *config.OpenAPI
+	doc    *openapi3.T
+	router routers.Router
+	json   []byte
+}
+
+func New(cfg *config.OpenAPI) (*Spec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, errors.New("failed to load openapi spec: " + err.Error())
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, errors.New("invalid openapi spec: " + err.Error())
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, errors.New("failed to build openapi router: " + err.Error())
+	}
+	b, err := doc.MarshalJSON()
+	if err != nil {
+		return nil, errors.New("failed to encode openapi spec: " + err.Error())
+	}
+	return &Spec{
+		cfg:    cfg,
+		doc:    doc,
+		router: router,
+		json:   b,
+	}, nil
+}
+
+func (s *Spec) ServeJSON(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", s.json)
+}
+
+func (s *Spec) ServeDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsHTML)
+}
+
+// Middleware validates requests of the routes described in the spec and,
+// when ValidateResponses is set, their responses. Invalid requests get a 400.
+// An invalid response is logged and, with StrictResponses, replaced by a 500.
+// Routes marked x-streaming only have their requests validated, those marked
+// x-handler-validates only their responses.
+func (s *Spec) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, pathParams, err := s.router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if route.Operation.Extensions["x-handler-validates"] != true {
+			if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid input: " + reason(err)})
+				return
+			}
+		}
+		if !s.cfg.ValidateResponses || route.Operation.Extensions["x-streaming"] == true {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		err = openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 w.Status(),
+			Header:                 w.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(w.body.Bytes())),
+		})
+		if err != nil {
+			logrus.Errorf("%s %s response doesn't match the openapi spec: %s", c.Request.Method, route.Path, reason(err))
+			if s.cfg.StrictResponses {
+				w.ResponseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+				w.ResponseWriter.Write([]byte(`{"message":"server error"}`))
+				return
+			}
+		}
+		w.ResponseWriter.WriteHeader(w.Status())
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
+
+func reason(err error) string {
+	var (
+		prefix    string
+		reqErr    *openapi3filter.RequestError
+		respErr   *openapi3filter.ResponseError
+		schemaErr *openapi3.SchemaError
+	)
+	switch {
+	case errors.As(err, &reqErr):
+		switch {
+		case reqErr.Parameter != nil:
+			prefix = "parameter " + reqErr.Parameter.Name + ": "
+		case reqErr.RequestBody != nil:
+			prefix = "body: "
+		}
+		if reqErr.Err == nil {
+			return prefix + reqErr.Reason
+		}
+		err = reqErr.Err
+	case errors.As(err, &respErr):
+		prefix = "body: "
+		if respErr.Err == nil {
+			return prefix + respErr.Reason
+		}
+		err = respErr.Err
+	}
+	if errors.As(err, &schemaErr) {
+		// For a oneOf mismatch report why the first alternative, the
+		// documented default, didn't match.
+		var inner *openapi3.SchemaError
+		for schemaErr.SchemaField == "oneOf" && schemaErr.Origin != nil && errors.As(schemaErr.Origin, &inner) {
+			schemaErr = inner
+		}
+		if field := strings.Join(schemaErr.JSONPointer(), "."); field != "" {
+			prefix += field + ": "
+		}
+		return prefix + schemaErr.Reason
+	}
+	return prefix + err.Error()
+}
+
+// bufferedWriter holds the response until it has been validated.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0 || w.body.Len() > 0
+}
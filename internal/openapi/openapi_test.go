@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec_Middleware(t *testing.T) {
+	tests := []struct {
+		name                 string
+		cfg                  config.OpenAPI
+		method               string
+		target               string
+		body                 string
+		handler              gin.HandlerFunc
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			cfg:    config.OpenAPI{ValidateResponses: true, StrictResponses: true},
+			method: "GET",
+			target: "/health",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:   "invalid body",
+			cfg:    config.OpenAPI{ValidateResponses: true, StrictResponses: true},
+			method: "POST",
+			target: "/orderbook/binance/BTCUSDT/",
+			body:   `{"asks":[{"price":"high","base_qty":1}],"bids":[]}`,
+			handler: func(c *gin.Context) {
+				t.Fatal("handler called with an invalid body")
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input: body: asks.0.price: value must be a number"}`,
+		},
+		{
+			name:   "missing parameter",
+			cfg:    config.OpenAPI{},
+			method: "GET",
+			target: "/orderhistory/?client-name=c&exchange-name=binance&label=l",
+			handler: func(c *gin.Context) {
+				t.Fatal("handler called without a required parameter")
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input: parameter pair: value is required but missing"}`,
+		},
+		{
+			name:   "drifted response",
+			cfg:    config.OpenAPI{ValidateResponses: true, StrictResponses: true},
+			method: "GET",
+			target: "/health",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"state": "ok"})
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"server error"}`,
+		},
+		{
+			name:   "drifted response not strict",
+			cfg:    config.OpenAPI{ValidateResponses: true},
+			method: "GET",
+			target: "/health",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"state": "ok"})
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"state":"ok"}`,
+		},
+		{
+			name:   "undocumented route",
+			cfg:    config.OpenAPI{ValidateResponses: true, StrictResponses: true},
+			method: "GET",
+			target: "/undocumented",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `ok`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := New(&tt.cfg)
+			require.NoError(t, err)
+			r := gin.New()
+			r.Use(spec.Middleware())
+			r.Handle(tt.method, strings.SplitN(tt.target, "?", 2)[0], tt.handler)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectedResponseBody, w.Body.String())
+		})
+	}
+}
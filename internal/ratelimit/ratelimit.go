@@ -0,0 +1,204 @@
+// Package ratelimit limits the requests of every client with token buckets,
+// one per group of routes.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/auth"
+	"github.com/kolibriee/trade-metrics/internal/config"
+)
+
+// defaultGroup holds the routes of no group.
+const defaultGroup = "default"
+
+// sweepInterval is how often buckets that have filled up again are
+// dropped, a full bucket is the same as none.
+const sweepInterval = time.Minute
+
+// Limiter is safe for concurrent use. Its limits can be changed with Update
+// while it runs, the buckets of groups that are kept carry on.
+type Limiter struct {
+	mu        sync.Mutex
+	enabled   bool
+	groups    []*group
+	fallback  *group
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type group struct {
+	name    string
+	methods []string
+	paths   []string
+	rate    float64
+	burst   float64
+}
+
+type bucketKey struct {
+	group  string
+	client string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func New(cfg *config.RateLimit) (*Limiter, error) {
+	l := &Limiter{buckets: make(map[bucketKey]*bucket), now: time.Now}
+	if err := l.Update(cfg); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Update replaces the limits with those of cfg. An invalid cfg is rejected
+// as a whole and the limits stay as they were.
+func (l *Limiter) Update(cfg *config.RateLimit) error {
+	fallback, err := newGroup(config.RateLimitGroup{Name: defaultGroup, Rate: cfg.Rate, Burst: cfg.Burst})
+	if err != nil {
+		return err
+	}
+	groups := make([]*group, 0, len(cfg.Groups))
+	names := map[string]bool{defaultGroup: true}
+	for _, g := range cfg.Groups {
+		if g.Name == "" {
+			return errors.New("rate limit group needs a name")
+		}
+		if names[g.Name] {
+			return errors.New("rate limit group " + g.Name + ": duplicate name")
+		}
+		if len(g.Paths) == 0 {
+			return errors.New("rate limit group " + g.Name + ": needs at least one path")
+		}
+		names[g.Name] = true
+		res, err := newGroup(g)
+		if err != nil {
+			return err
+		}
+		groups = append(groups, res)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enabled = cfg.Enabled
+	l.groups = groups
+	l.fallback = fallback
+	for key := range l.buckets {
+		if !names[key.group] {
+			delete(l.buckets, key)
+		}
+	}
+	return nil
+}
+
+func newGroup(cfg config.RateLimitGroup) (*group, error) {
+	if cfg.Rate < 0 {
+		return nil, errors.New("rate limit group " + cfg.Name + ": rate must not be negative")
+	}
+	if cfg.Rate > 0 && cfg.Burst < 1 {
+		return nil, errors.New("rate limit group " + cfg.Name + ": burst must be at least 1")
+	}
+	methods := make([]string, len(cfg.Methods))
+	for i, method := range cfg.Methods {
+		methods[i] = strings.ToUpper(method)
+	}
+	return &group{name: cfg.Name, methods: methods, paths: cfg.Paths, rate: cfg.Rate, burst: float64(cfg.Burst)}, nil
+}
+
+func (g *group) match(method, route string) bool {
+	if len(g.methods) > 0 && !contains(g.methods, method) {
+		return false
+	}
+	for _, path := range g.paths {
+		if strings.HasPrefix(route, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow takes a token of client from the bucket of the group of method and
+// route, the route template like /orderbook/:exchangeName/:pair/. When the
+// bucket is empty it returns false and how long until the next token.
+func (l *Limiter) Allow(client, method, route string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.enabled {
+		return true, 0
+	}
+	g := l.fallback
+	for _, candidate := range l.groups {
+		if candidate.match(method, route) {
+			g = candidate
+			break
+		}
+	}
+	if g.rate == 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+	key := bucketKey{group: g.name, client: client}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: g.burst, last: now}
+		l.buckets[key] = b
+	}
+	// limits may have changed since the last request
+	b.tokens = math.Min(g.burst, b.tokens+now.Sub(b.last).Seconds()*g.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / g.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	rates := map[string]*group{l.fallback.name: l.fallback}
+	for _, g := range l.groups {
+		rates[g.name] = g
+	}
+	for key, b := range l.buckets {
+		g := rates[key.group]
+		if b.tokens+now.Sub(b.last).Seconds()*g.rate >= g.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Client identifies the caller of ctx by its API key, without one by ip.
+func Client(ctx context.Context, ip string) string {
+	if key := auth.FromContext(ctx); key != nil {
+		return "key:" + key.Name
+	}
+	return "ip:" + ip
+}
+
+// RetryAfter formats d as the seconds of a Retry-After header, rounded up.
+func RetryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
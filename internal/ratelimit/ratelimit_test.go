@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/auth"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.RateLimit
+		expectedError string
+	}{
+		{
+			name: "OK",
+			cfg: config.RateLimit{Enabled: true, Rate: 1, Burst: 1, Groups: []config.RateLimitGroup{
+				{Name: "writes", Methods: []string{"post"}, Paths: []string{"/orderbook"}, Rate: 10, Burst: 20},
+			}},
+		},
+		{
+			name: "No limits",
+		},
+		{
+			name:          "Negative rate",
+			cfg:           config.RateLimit{Rate: -1},
+			expectedError: "rate limit group default: rate must not be negative",
+		},
+		{
+			name:          "No burst",
+			cfg:           config.RateLimit{Groups: []config.RateLimitGroup{{Name: "writes", Paths: []string{"/orderbook"}, Rate: 10}}},
+			expectedError: "rate limit group writes: burst must be at least 1",
+		},
+		{
+			name:          "No name",
+			cfg:           config.RateLimit{Groups: []config.RateLimitGroup{{Paths: []string{"/orderbook"}}}},
+			expectedError: "rate limit group needs a name",
+		},
+		{
+			name:          "No paths",
+			cfg:           config.RateLimit{Groups: []config.RateLimitGroup{{Name: "writes"}}},
+			expectedError: "rate limit group writes: needs at least one path",
+		},
+		{
+			name: "Duplicate name",
+			cfg: config.RateLimit{Groups: []config.RateLimitGroup{
+				{Name: "writes", Paths: []string{"/orderbook"}},
+				{Name: "writes", Paths: []string{"/orderhistory"}},
+			}},
+			expectedError: "rate limit group writes: duplicate name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&tt.cfg)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, err := New(&config.RateLimit{Enabled: true, Rate: 1, Burst: 2, Groups: []config.RateLimitGroup{
+		{Name: "writes", Methods: []string{"POST"}, Paths: []string{"/orderbook", "/v2/orderbook"}, Rate: 2, Burst: 1},
+		{Name: "unlimited", Paths: []string{"/orderhistory"}},
+	}})
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+
+	// default group
+	ok, _ := l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.True(t, ok)
+	ok, _ = l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.True(t, ok)
+	ok, retryAfter := l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// other groups and clients have their own buckets
+	ok, _ = l.Allow("ip:1", "POST", "/v2/orderbook/:exchangeName/:pair")
+	assert.True(t, ok)
+	ok, retryAfter = l.Allow("ip:1", "POST", "/orderbook/:exchangeName/:pair/")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+	ok, _ = l.Allow("ip:2", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.True(t, ok)
+	for i := 0; i < 10; i++ {
+		ok, _ = l.Allow("ip:1", "GET", "/orderhistory/")
+		assert.True(t, ok)
+	}
+
+	// refilled
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("ip:1", "POST", "/orderbook/:exchangeName/:pair/")
+	assert.True(t, ok)
+	ok, retryAfter = l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// full buckets are dropped
+	now = now.Add(2 * sweepInterval)
+	ok, _ = l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.True(t, ok)
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_Update(t *testing.T) {
+	l, err := New(&config.RateLimit{Enabled: true, Rate: 1, Burst: 1})
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.True(t, ok)
+	ok, _ = l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.False(t, ok)
+
+	// an invalid config keeps the limits
+	assert.Error(t, l.Update(&config.RateLimit{Enabled: true, Rate: -1}))
+	ok, _ = l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.False(t, ok)
+
+	// the bucket carries on with the new rate
+	require.NoError(t, l.Update(&config.RateLimit{Enabled: true, Rate: 10, Burst: 1}))
+	now = now.Add(100 * time.Millisecond)
+	ok, _ = l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+	assert.True(t, ok)
+
+	require.NoError(t, l.Update(&config.RateLimit{Enabled: false, Rate: 10, Burst: 1}))
+	for i := 0; i < 10; i++ {
+		ok, _ = l.Allow("ip:1", "GET", "/orderbook/:exchangeName/:pair/")
+		assert.True(t, ok)
+	}
+}
+
+func TestClient(t *testing.T) {
+	assert.Equal(t, "ip:10.0.0.1", Client(context.Background(), "10.0.0.1"))
+	ctx := auth.WithKey(context.Background(), &auth.Key{Name: "misha", Client: "Misha"})
+	assert.Equal(t, "key:misha", Client(ctx, "10.0.0.1"))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, "1", RetryAfter(0))
+	assert.Equal(t, "1", RetryAfter(200*time.Millisecond))
+	assert.Equal(t, "3", RetryAfter(2100*time.Millisecond))
+}
@@ -2,7 +2,15 @@ package repository
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
@@ -10,14 +18,11 @@ import (
 )
 
 func NewClickHouseDB(cfg *config.ClickHouse) (driver.Conn, error) {
-	db, err := clickhouse.Open(&clickhouse.Options{
-		Addr: []string{cfg.Host + ":" + cfg.Port},
-		Auth: clickhouse.Auth{
-			Database: cfg.DBName,
-			Username: cfg.Username,
-			Password: cfg.Password,
-		},
-	})
+	opts, err := clickHouseOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := clickhouse.Open(opts)
 	if err != nil {
 		return nil, errors.New("failed to connect to ClickHouse: " + err.Error())
 	}
@@ -27,3 +32,179 @@ func NewClickHouseDB(cfg *config.ClickHouse) (driver.Conn, error) {
 	}
 	return db, nil
 }
+
+var connOpenStrategies = map[string]clickhouse.ConnOpenStrategy{
+	"":            clickhouse.ConnOpenInOrder,
+	"in_order":    clickhouse.ConnOpenInOrder,
+	"round_robin": clickhouse.ConnOpenRoundRobin,
+	"random":      clickhouse.ConnOpenRandom,
+}
+
+// compressions are those of the native protocol.
+var compressions = map[string]clickhouse.CompressionMethod{
+	"":     clickhouse.CompressionNone,
+	"none": clickhouse.CompressionNone,
+	"lz4":  clickhouse.CompressionLZ4,
+	"zstd": clickhouse.CompressionZSTD,
+}
+
+// clickHouseOptions validates cfg and returns the driver options of it.
+// Errors name the setting as in config.yaml.
+func clickHouseOptions(cfg *config.ClickHouse) (*clickhouse.Options, error) {
+	addrs := cfg.Addresses
+	if len(addrs) == 0 {
+		if cfg.Host == "" {
+			return nil, invalidConfig("host or addresses is required (DB_HOST or DB_ADDRESSES)")
+		}
+		port := cfg.Port
+		switch {
+		case port != "":
+		case cfg.TLS.Enabled:
+			port = "9440"
+		default:
+			port = "9000"
+		}
+		addrs = []string{net.JoinHostPort(cfg.Host, port)}
+	}
+	for _, addr := range addrs {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || host == "" {
+			return nil, invalidConfig("address " + strconv.Quote(addr) + " must be host:port")
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, invalidConfig("address " + strconv.Quote(addr) + " has an invalid port")
+		}
+	}
+	strategy, ok := connOpenStrategies[cfg.ConnOpenStrategy]
+	if !ok {
+		return nil, invalidConfig("connOpenStrategy must be in_order, round_robin or random, got " + strconv.Quote(cfg.ConnOpenStrategy))
+	}
+	compression, ok := compressions[cfg.Compression]
+	if !ok {
+		return nil, invalidConfig("compression must be none, lz4 or zstd, got " + strconv.Quote(cfg.Compression))
+	}
+	switch {
+	case cfg.DialTimeout < 0:
+		return nil, invalidConfig("dialTimeout must not be negative")
+	case cfg.ReadTimeout < 0:
+		return nil, invalidConfig("readTimeout must not be negative")
+	case cfg.ConnMaxLifetime < 0:
+		return nil, invalidConfig("connMaxLifetime must not be negative")
+	case cfg.MaxOpenConns < 0:
+		return nil, invalidConfig("maxOpenConns must not be negative")
+	case cfg.MaxIdleConns < 0:
+		return nil, invalidConfig("maxIdleConns must not be negative")
+	case cfg.MaxOpenConns > 0 && cfg.MaxIdleConns > cfg.MaxOpenConns:
+		return nil, invalidConfig("maxIdleConns must not be above maxOpenConns")
+	}
+	tlsConfig, err := clickHouseTLS(&cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clickhouse.Options{
+		Addr: addrs,
+		Auth: clickhouse.Auth{
+			Database: cfg.DBName,
+			Username: cfg.Username,
+			Password: cfg.Password,
+		},
+		TLS:              tlsConfig,
+		Compression:      &clickhouse.Compression{Method: compression},
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		MaxOpenConns:     cfg.MaxOpenConns,
+		MaxIdleConns:     cfg.MaxIdleConns,
+		ConnMaxLifetime:  cfg.ConnMaxLifetime,
+		ConnOpenStrategy: strategy,
+	}, nil
+}
+
+func clickHouseTLS(cfg *config.ClickHouseTLS) (*tls.Config, error) {
+	if !cfg.Enabled {
+		if cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" || cfg.ServerName != "" || cfg.InsecureSkipVerify {
+			return nil, invalidConfig("tls settings are set, but tls.enabled is false")
+		}
+		return nil, nil
+	}
+	res := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, invalidConfig("failed to read tls.caFile: " + err.Error())
+		}
+		res.RootCAs = x509.NewCertPool()
+		if !res.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, invalidConfig("tls.caFile " + cfg.CAFile + " contains no PEM certificates")
+		}
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, invalidConfig("tls.certFile and tls.keyFile must be set together")
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, invalidConfig("failed to load the tls client certificate: " + err.Error())
+		}
+		res.Certificates = []tls.Certificate{cert}
+	}
+	return res, nil
+}
+
+func invalidConfig(message string) error {
+	return errors.New("invalid clickhouse config: " + message)
+}
+
+// Settings are the query settings of the ClickHouse repository. They may be
+// replaced while queries run, a nil *Settings has none.
+type Settings struct {
+	settings atomic.Pointer[clickhouse.Settings]
+}
+
+func NewSettings(settings map[string]any) *Settings {
+	s := &Settings{}
+	s.Set(settings)
+	return s
+}
+
+func (s *Settings) Set(settings map[string]any) {
+	res := make(clickhouse.Settings, len(settings))
+	for name, value := range settings {
+		res[name] = value
+	}
+	s.settings.Store(&res)
+}
+
+func (s *Settings) Get() clickhouse.Settings {
+	if s == nil {
+		return nil
+	}
+	return *s.settings.Load()
+}
+
+// queryContext adds settings to the query context. Unless settings contain
+// it, max_execution_time is set to the time left until the deadline of ctx,
+// so ClickHouse stops queries nobody waits for anymore. The insert token of
+// ctx is sent as insert_deduplication_token.
+func queryContext(ctx context.Context, settings clickhouse.Settings) context.Context {
+	deadline, ok := ctx.Deadline()
+	token := insertToken(ctx)
+	if len(settings) == 0 && !ok && token == "" {
+		return ctx
+	}
+	querySettings := make(clickhouse.Settings, len(settings)+2)
+	for name, value := range settings {
+		querySettings[name] = value
+	}
+	if _, set := querySettings["max_execution_time"]; ok && !set {
+		querySettings["max_execution_time"] = max(1, int(math.Ceil(time.Until(deadline).Seconds())))
+	}
+	if token != "" {
+		querySettings["insert_deduplication_token"] = token
+	}
+	return clickhouse.Context(ctx, clickhouse.WithSettings(querySettings))
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickHouseOptions(t *testing.T) {
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name          string
+		cfg           config.ClickHouse
+		expectedError string
+		check         func(t *testing.T, opts *clickhouse.Options)
+	}{
+		{
+			name: "Host and port",
+			cfg:  config.ClickHouse{Host: "localhost", Port: "9001", Username: "default", DBName: "trade"},
+			check: func(t *testing.T, opts *clickhouse.Options) {
+				assert.Equal(t, []string{"localhost:9001"}, opts.Addr)
+				assert.Equal(t, "trade", opts.Auth.Database)
+				assert.Nil(t, opts.TLS)
+				assert.Equal(t, clickhouse.CompressionNone, opts.Compression.Method)
+			},
+		},
+		{
+			name: "Default port",
+			cfg:  config.ClickHouse{Host: "localhost"},
+			check: func(t *testing.T, opts *clickhouse.Options) {
+				assert.Equal(t, []string{"localhost:9000"}, opts.Addr)
+			},
+		},
+		{
+			name: "Replicas with TLS",
+			cfg: config.ClickHouse{
+				Host:             "ignored",
+				Addresses:        []string{"ch1:9440", "ch2:9440"},
+				ConnOpenStrategy: "round_robin",
+				Compression:      "zstd",
+				DialTimeout:      5 * time.Second,
+				ReadTimeout:      time.Minute,
+				MaxOpenConns:     20,
+				MaxIdleConns:     10,
+				ConnMaxLifetime:  time.Hour,
+				TLS:              config.ClickHouseTLS{Enabled: true, ServerName: "clickhouse.internal"},
+			},
+			check: func(t *testing.T, opts *clickhouse.Options) {
+				assert.Equal(t, []string{"ch1:9440", "ch2:9440"}, opts.Addr)
+				assert.Equal(t, clickhouse.ConnOpenRoundRobin, opts.ConnOpenStrategy)
+				assert.Equal(t, clickhouse.CompressionZSTD, opts.Compression.Method)
+				assert.Equal(t, 5*time.Second, opts.DialTimeout)
+				assert.Equal(t, time.Minute, opts.ReadTimeout)
+				assert.Equal(t, 20, opts.MaxOpenConns)
+				assert.Equal(t, 10, opts.MaxIdleConns)
+				assert.Equal(t, time.Hour, opts.ConnMaxLifetime)
+				require.NotNil(t, opts.TLS)
+				assert.Equal(t, "clickhouse.internal", opts.TLS.ServerName)
+			},
+		},
+		{
+			name: "Default TLS port",
+			cfg:  config.ClickHouse{Host: "localhost", TLS: config.ClickHouseTLS{Enabled: true}},
+			check: func(t *testing.T, opts *clickhouse.Options) {
+				assert.Equal(t, []string{"localhost:9440"}, opts.Addr)
+			},
+		},
+		{
+			name:          "No host",
+			expectedError: "invalid clickhouse config: host or addresses is required (DB_HOST or DB_ADDRESSES)",
+		},
+		{
+			name:          "Address without port",
+			cfg:           config.ClickHouse{Addresses: []string{"ch1"}},
+			expectedError: `invalid clickhouse config: address "ch1" must be host:port`,
+		},
+		{
+			name:          "Invalid port",
+			cfg:           config.ClickHouse{Host: "localhost", Port: "native"},
+			expectedError: `invalid clickhouse config: address "localhost:native" has an invalid port`,
+		},
+		{
+			name:          "Unknown strategy",
+			cfg:           config.ClickHouse{Host: "localhost", ConnOpenStrategy: "fastest"},
+			expectedError: `invalid clickhouse config: connOpenStrategy must be in_order, round_robin or random, got "fastest"`,
+		},
+		{
+			name:          "Compression of HTTP",
+			cfg:           config.ClickHouse{Host: "localhost", Compression: "gzip"},
+			expectedError: `invalid clickhouse config: compression must be none, lz4 or zstd, got "gzip"`,
+		},
+		{
+			name:          "Negative timeout",
+			cfg:           config.ClickHouse{Host: "localhost", DialTimeout: -time.Second},
+			expectedError: "invalid clickhouse config: dialTimeout must not be negative",
+		},
+		{
+			name:          "More idle than open connections",
+			cfg:           config.ClickHouse{Host: "localhost", MaxOpenConns: 5, MaxIdleConns: 10},
+			expectedError: "invalid clickhouse config: maxIdleConns must not be above maxOpenConns",
+		},
+		{
+			name:          "TLS settings without TLS",
+			cfg:           config.ClickHouse{Host: "localhost", TLS: config.ClickHouseTLS{CAFile: notPEM}},
+			expectedError: "invalid clickhouse config: tls settings are set, but tls.enabled is false",
+		},
+		{
+			name:          "CA file without certificates",
+			cfg:           config.ClickHouse{Host: "localhost", TLS: config.ClickHouseTLS{Enabled: true, CAFile: notPEM}},
+			expectedError: "invalid clickhouse config: tls.caFile " + notPEM + " contains no PEM certificates",
+		},
+		{
+			name:          "Certificate without key",
+			cfg:           config.ClickHouse{Host: "localhost", TLS: config.ClickHouseTLS{Enabled: true, CertFile: "client.pem"}},
+			expectedError: "invalid clickhouse config: tls.certFile and tls.keyFile must be set together",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := clickHouseOptions(&tt.cfg)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, opts)
+		})
+	}
+}
+
+func TestSettings(t *testing.T) {
+	var none *Settings
+	assert.Nil(t, none.Get())
+
+	settings := NewSettings(map[string]any{"max_threads": 4})
+	assert.Equal(t, clickhouse.Settings{"max_threads": 4}, settings.Get())
+	settings.Set(nil)
+	assert.Empty(t, settings.Get())
+}
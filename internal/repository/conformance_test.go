@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/migrate"
+	"github.com/kolibriee/trade-metrics/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConformance checks the query semantics every backend has to share.
+// newRepo returns an empty repository for every subtest.
+func testConformance(t *testing.T, newRepo func(t *testing.T) *Repository) {
+	t.Run("order book not found", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+		assert.True(t, errors.Is(err, ErrNotFound), "got %v", err)
+	})
+
+	t.Run("latest order book", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &domain.AsksBids{
+			Id:   7,
+			Asks: []domain.DepthOrder{{Price: 50000, BaseQty: 1}},
+			Bids: []domain.DepthOrder{{Price: 49900, BaseQty: 1}},
+		}))
+		require.NoError(t, repo.SaveOrderBook(context.Background(), "binance", "ETHUSDT", &domain.AsksBids{
+			Id:   8,
+			Asks: []domain.DepthOrder{{Price: 3000, BaseQty: 1}},
+			Bids: []domain.DepthOrder{{Price: 2900, BaseQty: 1}},
+		}))
+		require.NoError(t, repo.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &domain.AsksBids{
+			Id:   3,
+			Asks: []domain.DepthOrder{{Price: 51000.5, BaseQty: 0.25}, {Price: 51001, BaseQty: 2}},
+			Bids: []domain.DepthOrder{{Price: 50999.5, BaseQty: 3}},
+		}))
+
+		book, err := repo.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+		require.NoError(t, err)
+		assert.Equal(t, &domain.AsksBids{
+			Id:   3,
+			Asks: []domain.DepthOrder{{Price: 51000.5, BaseQty: 0.25}, {Price: 51001, BaseQty: 2}},
+			Bids: []domain.DepthOrder{{Price: 50999.5, BaseQty: 3}},
+		}, book)
+
+		_, err = repo.GetOrderBook(context.Background(), "kraken", "BTCUSDT")
+		assert.True(t, errors.Is(err, ErrNotFound), "got %v", err)
+	})
+
+	t.Run("empty sides", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &domain.AsksBids{Id: 1}))
+		book, err := repo.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+		require.NoError(t, err)
+		assert.Equal(t, []domain.DepthOrder{}, book.Asks)
+		assert.Equal(t, []domain.DepthOrder{}, book.Bids)
+	})
+
+	t.Run("export order books", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveOrderBooks(context.Background(), []*domain.OrderBook{
+			{ID: 3, Exchange: "binance", Pair: "BTCUSDT", Asks: []domain.DepthOrder{{Price: 1, BaseQty: 2}}},
+			{ID: 1<<32 + 1, Exchange: "binance", Pair: "ETHUSDT"},
+			{ID: 2, Exchange: "kraken", Pair: "BTCUSDT", Bids: []domain.DepthOrder{{Price: 3, BaseQty: 4}}},
+		}))
+
+		var books []*domain.OrderBook
+		collect := func(book *domain.OrderBook) error {
+			books = append(books, book)
+			return nil
+		}
+		require.NoError(t, repo.ExportOrderBooks(context.Background(), "", "BTCUSDT", collect))
+		// in save order, not by the random ids
+		assert.Equal(t, []*domain.OrderBook{
+			{ID: 3, Exchange: "binance", Pair: "BTCUSDT", Asks: []domain.DepthOrder{{Price: 1, BaseQty: 2}}, Bids: []domain.DepthOrder{}},
+			{ID: 2, Exchange: "kraken", Pair: "BTCUSDT", Asks: []domain.DepthOrder{}, Bids: []domain.DepthOrder{{Price: 3, BaseQty: 4}}},
+		}, books)
+
+		books = nil
+		require.NoError(t, repo.ExportOrderBooks(context.Background(), "binance", "", collect))
+		require.Len(t, books, 2)
+		assert.Equal(t, int64(3), books[0].ID)
+		assert.Equal(t, int64(1), books[1].ID)
+
+		books = nil
+		require.NoError(t, repo.ExportOrderBooks(context.Background(), "", "", collect))
+		assert.Len(t, books, 3)
+
+		stop := errors.New("stop")
+		assert.Equal(t, stop, repo.ExportOrderBooks(context.Background(), "", "", func(*domain.OrderBook) error {
+			return stop
+		}))
+	})
+
+	misha := domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"}
+	sasha := domain.Client{ClientName: "Sasha", ExchangeName: "binance", Label: "test", Pair: "ETHUSDT"}
+	placed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	order := func(client domain.Client, side string, timePlaced time.Time) *domain.HistoryOrder {
+		return &domain.HistoryOrder{
+			Client:              client,
+			Side:                side,
+			Type:                "limit",
+			BaseQty:             1.5,
+			Price:               50000.25,
+			AlgorithmNamePlaced: "twap",
+			LowestSellPrice:     50001,
+			HighestBuyPrice:     49999,
+			CommissionQuoteQty:  0.75,
+			TimePlaced:          timePlaced,
+		}
+	}
+	sides := func(orders []*domain.HistoryOrder) []string {
+		res := make([]string, 0, len(orders))
+		for _, order := range orders {
+			res = append(res, order.Client.ClientName+" "+order.Side)
+		}
+		return res
+	}
+
+	t.Run("order history", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveOrder(context.Background(), order(misha, "buy", placed.Add(2*time.Second+700*time.Millisecond))))
+		require.NoError(t, repo.SaveOrders(context.Background(), []*domain.HistoryOrder{
+			order(sasha, "sell", placed.Add(time.Second)),
+			order(misha, "sell", placed),
+		}))
+
+		orders, err := repo.GetOrderHistory(context.Background(), &misha)
+		require.NoError(t, err)
+		require.Len(t, orders, 2)
+		sort.Slice(orders, func(i, j int) bool {
+			return orders[i].TimePlaced.Before(orders[j].TimePlaced)
+		})
+		want := order(misha, "sell", placed)
+		assert.True(t, want.TimePlaced.Equal(orders[0].TimePlaced), "got %v", orders[0].TimePlaced)
+		orders[0].TimePlaced = want.TimePlaced
+		assert.Equal(t, want, orders[0])
+		assert.True(t, placed.Add(2*time.Second).Equal(orders[1].TimePlaced), "got %v", orders[1].TimePlaced)
+
+		other := misha
+		other.Label = "other"
+		orders, err = repo.GetOrderHistory(context.Background(), &other)
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+	})
+
+	t.Run("order histories", func(t *testing.T) {
+		repo := newRepo(t)
+		other := misha
+		other.Pair = "ETHUSDT"
+		require.NoError(t, repo.SaveOrders(context.Background(), []*domain.HistoryOrder{
+			order(misha, "buy", placed),
+			order(sasha, "sell", placed),
+			order(other, "buy", placed),
+		}))
+
+		orders, err := repo.GetOrderHistories(context.Background(), []*domain.Client{&misha, &sasha}, 0)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Misha buy", "Sasha sell"}, sides(orders))
+
+		orders, err = repo.GetOrderHistories(context.Background(), nil, 0)
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+	})
+
+	t.Run("latest order histories", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveOrders(context.Background(), []*domain.HistoryOrder{
+			order(misha, "buy", placed),
+			order(misha, "sell", placed.Add(2*time.Second)),
+			order(misha, "buy", placed.Add(time.Second)),
+			order(sasha, "sell", placed),
+		}))
+
+		orders, err := repo.GetOrderHistories(context.Background(), []*domain.Client{&misha, &sasha}, 2)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Misha sell", "Misha buy", "Sasha sell"}, sides(orders))
+		for _, order := range orders {
+			assert.False(t, order.TimePlaced.Equal(placed) && order.Client == misha, "oldest order of Misha returned")
+		}
+	})
+
+	t.Run("export orders", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveOrders(context.Background(), []*domain.HistoryOrder{
+			order(misha, "buy", placed.Add(2*time.Second)),
+			order(sasha, "sell", placed.Add(time.Second)),
+			order(misha, "sell", placed),
+		}))
+
+		var orders []*domain.HistoryOrder
+		collect := func(order *domain.HistoryOrder) error {
+			orders = append(orders, order)
+			return nil
+		}
+		require.NoError(t, repo.ExportOrders(context.Background(), &domain.Client{ExchangeName: "binance"}, collect))
+		assert.Equal(t, []string{"Misha sell", "Sasha sell", "Misha buy"}, sides(orders))
+
+		orders = nil
+		require.NoError(t, repo.ExportOrders(context.Background(), &domain.Client{ClientName: "Misha", Pair: "BTCUSDT"}, collect))
+		assert.Equal(t, []string{"Misha sell", "Misha buy"}, sides(orders))
+
+		orders = nil
+		require.NoError(t, repo.ExportOrders(context.Background(), &domain.Client{Label: "other"}, collect))
+		assert.Empty(t, orders)
+
+		stop := errors.New("stop")
+		assert.Equal(t, stop, repo.ExportOrders(context.Background(), &domain.Client{}, func(*domain.HistoryOrder) error {
+			return stop
+		}))
+	})
+
+	t.Run("insert tokens", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := WithInsertToken(context.Background(), "spool-1")
+		for i := 0; i < 2; i++ {
+			require.NoError(t, repo.SaveOrder(ctx, order(misha, "buy", placed)))
+			require.NoError(t, repo.SaveOrders(WithInsertToken(context.Background(), "spool-2"), []*domain.HistoryOrder{
+				order(misha, "sell", placed),
+				order(sasha, "sell", placed),
+			}))
+			require.NoError(t, repo.SaveOrderBook(WithInsertToken(context.Background(), "spool-3"), "binance", "BTCUSDT", &domain.AsksBids{Id: 7}))
+			require.NoError(t, repo.SaveOrderBooks(WithInsertToken(context.Background(), "spool-4"), []*domain.OrderBook{
+				{ID: 2, Exchange: "binance", Pair: "BTCUSDT"},
+			}))
+		}
+		// without a token every insert is stored
+		require.NoError(t, repo.SaveOrder(context.Background(), order(misha, "buy", placed)))
+
+		orders, err := repo.GetOrderHistories(context.Background(), []*domain.Client{&misha, &sasha}, 0)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Misha buy", "Misha buy", "Misha sell", "Sasha sell"}, sides(orders))
+
+		var ids []int64
+		require.NoError(t, repo.ExportOrderBooks(context.Background(), "binance", "BTCUSDT", func(orderBook *domain.OrderBook) error {
+			ids = append(ids, orderBook.ID)
+			return nil
+		}))
+		assert.Equal(t, []int64{7, 2}, ids)
+	})
+}
+
+func TestConformance_Memory(t *testing.T) {
+	testConformance(t, func(t *testing.T) *Repository {
+		return NewMemoryRepository(NewMemory())
+	})
+}
+
+func TestConformance_SQLite(t *testing.T) {
+	testConformance(t, func(t *testing.T) *Repository {
+		db, err := NewSQLiteDB(&config.SQLite{Path: filepath.Join(t.TempDir(), "test.db")})
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		migrateUp(t, "sqlite", migrate.NewSQLite(db.DB))
+		return NewSQLRepository(db)
+	})
+}
+
+// TestConformance_Postgres runs with TEST_POSTGRES_DSN, a postgres:// URL.
+// Every subtest gets its own schema, which is dropped afterwards.
+func TestConformance_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	admin, err := NewPostgresDB(&config.Postgres{DSN: dsn})
+	require.NoError(t, err)
+	defer admin.Close()
+
+	testConformance(t, func(t *testing.T) *Repository {
+		schema := testName()
+		_, err := admin.Exec("CREATE SCHEMA " + schema)
+		require.NoError(t, err)
+		t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+		u, err := url.Parse(dsn)
+		require.NoError(t, err)
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		db, err := NewPostgresDB(&config.Postgres{DSN: u.String()})
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		migrateUp(t, "postgres", migrate.NewPostgres(db.DB))
+		return NewSQLRepository(db)
+	})
+}
+
+// TestConformance_ClickHouse runs with TEST_CLICKHOUSE_HOST and optionally
+// TEST_CLICKHOUSE_PORT, TEST_CLICKHOUSE_USERNAME and TEST_CLICKHOUSE_PASSWORD.
+// Every subtest gets its own database, which is dropped afterwards.
+func TestConformance_ClickHouse(t *testing.T) {
+	cfg := config.ClickHouse{
+		Host:     os.Getenv("TEST_CLICKHOUSE_HOST"),
+		Port:     os.Getenv("TEST_CLICKHOUSE_PORT"),
+		Username: os.Getenv("TEST_CLICKHOUSE_USERNAME"),
+		Password: os.Getenv("TEST_CLICKHOUSE_PASSWORD"),
+		DBName:   "default",
+	}
+	if cfg.Host == "" {
+		t.Skip("TEST_CLICKHOUSE_HOST is not set")
+	}
+	if cfg.Port == "" {
+		cfg.Port = "9000"
+	}
+	admin, err := NewClickHouseDB(&cfg)
+	require.NoError(t, err)
+	defer admin.Close()
+
+	testConformance(t, func(t *testing.T) *Repository {
+		ctx := context.Background()
+		database := testName()
+		require.NoError(t, admin.Exec(ctx, "CREATE DATABASE "+database))
+		t.Cleanup(func() { admin.Exec(ctx, "DROP DATABASE "+database) })
+
+		dbCfg := cfg
+		dbCfg.DBName = database
+		db, err := NewClickHouseDB(&dbCfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		migrateUp(t, "clickhouse", migrate.NewClickHouse(db))
+		return NewRepository(db, nil)
+	})
+}
+
+// migrateUp applies the embedded migrations of migrations/<dir>.
+func migrateUp(t *testing.T, dir string, driver migrate.Driver) {
+	list, err := migrate.Load(migrations.FS, dir)
+	require.NoError(t, err)
+	_, err = migrate.New(driver, list).Up(context.Background())
+	require.NoError(t, err)
+}
+
+func testName() string {
+	return "trade_metrics_test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
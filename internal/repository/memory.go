@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// Memory keeps order books and orders in memory, for development and tests.
+// It answers queries like the ClickHouse repository: every saved order book
+// is kept, GetOrderBook returns the latest of its exchange and pair, and
+// time_placed has the second precision of a DateTime column. Callers get
+// copies, so they may modify what they save and read. Operations fail with
+// the error of their context once it ended. Inserts with an insert token are
+// stored once.
+type Memory struct {
+	mu     sync.RWMutex
+	books  []*domain.OrderBook
+	orders []*domain.HistoryOrder
+	tokens map[string]bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{tokens: make(map[string]bool)}
+}
+
+// inserted tells if the insert token of ctx was already stored and records
+// it otherwise. m.mu must be held.
+func (m *Memory) inserted(ctx context.Context) bool {
+	token := insertToken(ctx)
+	if token == "" {
+		return false
+	}
+	if m.tokens[token] {
+		return true
+	}
+	m.tokens[token] = true
+	return false
+}
+
+func NewMemoryRepository(m *Memory) *Repository {
+	return &Repository{
+		Orderbook:    m,
+		Orderhistory: m,
+	}
+}
+
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := len(m.books) - 1; i >= 0; i-- {
+		book := m.books[i]
+		if book.Exchange == exchangeName && book.Pair == pair {
+			return &domain.AsksBids{
+				Id:   uint32(book.ID),
+				Asks: copyLevels(book.Asks),
+				Bids: copyLevels(book.Bids),
+			}, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inserted(ctx) {
+		return nil
+	}
+	m.books = append(m.books, &domain.OrderBook{
+		ID:       int64(asksBids.Id),
+		Exchange: exchangeName,
+		Pair:     pair,
+		Asks:     copyLevels(asksBids.Asks),
+		Bids:     copyLevels(asksBids.Bids),
+	})
+	return nil
+}
+
+func (m *Memory) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inserted(ctx) {
+		return nil
+	}
+	for _, orderBook := range orderBooks {
+		book := copyOrderBook(orderBook)
+		book.ID = int64(uint32(book.ID))
+		m.books = append(m.books, book)
+	}
+	return nil
+}
+
+func (m *Memory) ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(orderBook *domain.OrderBook) error) error {
+	m.mu.RLock()
+	var books []*domain.OrderBook
+	for _, book := range m.books {
+		if (exchangeName == "" || book.Exchange == exchangeName) && (pair == "" || book.Pair == pair) {
+			books = append(books, book)
+		}
+	}
+	m.mu.RUnlock()
+	for _, book := range books {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(copyOrderBook(book)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var orders []*domain.HistoryOrder
+	for _, order := range m.orders {
+		if order.Client == *client {
+			o := *order
+			orders = append(orders, &o)
+		}
+	}
+	return orders, nil
+}
+
+// GetOrderHistories returns the orders of clients in the order they were
+// saved, with a limit the latest of each client from the newest.
+func (m *Memory) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	wanted := make(map[domain.Client]bool, len(clients))
+	for _, client := range clients {
+		wanted[*client] = true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var orders []*domain.HistoryOrder
+	for _, order := range m.orders {
+		if wanted[order.Client] {
+			o := *order
+			orders = append(orders, &o)
+		}
+	}
+	if limit <= 0 {
+		return orders, nil
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].TimePlaced.After(orders[j].TimePlaced)
+	})
+	count := make(map[domain.Client]int, len(clients))
+	latest := orders[:0]
+	for _, order := range orders {
+		if count[order.Client] < limit {
+			count[order.Client]++
+			latest = append(latest, order)
+		}
+	}
+	return latest, nil
+}
+
+func (m *Memory) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inserted(ctx) {
+		return nil
+	}
+	m.orders = append(m.orders, storedOrder(order))
+	return nil
+}
+
+func (m *Memory) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inserted(ctx) {
+		return nil
+	}
+	for _, order := range orders {
+		m.orders = append(m.orders, storedOrder(order))
+	}
+	return nil
+}
+
+func (m *Memory) ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+	m.mu.RLock()
+	var orders []*domain.HistoryOrder
+	for _, order := range m.orders {
+		c := order.Client
+		if (filter.ClientName == "" || c.ClientName == filter.ClientName) &&
+			(filter.ExchangeName == "" || c.ExchangeName == filter.ExchangeName) &&
+			(filter.Label == "" || c.Label == filter.Label) &&
+			(filter.Pair == "" || c.Pair == filter.Pair) {
+			orders = append(orders, order)
+		}
+	}
+	m.mu.RUnlock()
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].TimePlaced.Before(orders[j].TimePlaced)
+	})
+	for _, order := range orders {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		o := *order
+		if err := fn(&o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func storedOrder(order *domain.HistoryOrder) *domain.HistoryOrder {
+	o := *order
+	o.TimePlaced = o.TimePlaced.Truncate(time.Second)
+	return &o
+}
+
+func copyOrderBook(orderBook *domain.OrderBook) *domain.OrderBook {
+	book := *orderBook
+	book.Asks = copyLevels(orderBook.Asks)
+	book.Bids = copyLevels(orderBook.Bids)
+	return &book
+}
+
+// copyLevels returns an empty slice for nil, like a ClickHouse array.
+func copyLevels(levels []domain.DepthOrder) []domain.DepthOrder {
+	res := make([]domain.DepthOrder, len(levels))
+	copy(res, levels)
+	return res
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_GetOrderBook(t *testing.T) {
+	m := NewMemory()
+
+	_, err := m.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	require.NoError(t, m.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &domain.AsksBids{
+		Id:   1,
+		Asks: []domain.DepthOrder{{Price: 50000, BaseQty: 1}},
+	}))
+	require.NoError(t, m.SaveOrderBook(context.Background(), "binance", "ETHUSDT", &domain.AsksBids{
+		Id:   2,
+		Asks: []domain.DepthOrder{{Price: 3000, BaseQty: 1}},
+		Bids: []domain.DepthOrder{{Price: 2900, BaseQty: 1}},
+	}))
+	require.NoError(t, m.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &domain.AsksBids{
+		Id:   3,
+		Asks: []domain.DepthOrder{{Price: 51000, BaseQty: 2}},
+		Bids: []domain.DepthOrder{{Price: 50900, BaseQty: 3}},
+	}))
+
+	book, err := m.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, &domain.AsksBids{
+		Id:   3,
+		Asks: []domain.DepthOrder{{Price: 51000, BaseQty: 2}},
+		Bids: []domain.DepthOrder{{Price: 50900, BaseQty: 3}},
+	}, book)
+
+	book.Asks[0].Price = 1
+	book, err = m.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, float64(51000), book.Asks[0].Price)
+
+	_, err = m.GetOrderBook(context.Background(), "kraken", "BTCUSDT")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestMemory_ExportOrderBooks(t *testing.T) {
+	m := NewMemory()
+	require.NoError(t, m.SaveOrderBooks(context.Background(), []*domain.OrderBook{
+		{ID: 3, Exchange: "binance", Pair: "BTCUSDT", Asks: []domain.DepthOrder{{Price: 1, BaseQty: 1}}},
+		{ID: 1<<32 + 1, Exchange: "binance", Pair: "ETHUSDT"},
+		{ID: 2, Exchange: "kraken", Pair: "BTCUSDT"},
+	}))
+
+	var ids []int64
+	require.NoError(t, m.ExportOrderBooks(context.Background(), "", "BTCUSDT", func(book *domain.OrderBook) error {
+		ids = append(ids, book.ID)
+		assert.NotNil(t, book.Bids)
+		return nil
+	}))
+	assert.Equal(t, []int64{3, 2}, ids)
+
+	ids = nil
+	require.NoError(t, m.ExportOrderBooks(context.Background(), "binance", "", func(book *domain.OrderBook) error {
+		ids = append(ids, book.ID)
+		return nil
+	}))
+	assert.Equal(t, []int64{3, 1}, ids)
+
+	stop := errors.New("stop")
+	err := m.ExportOrderBooks(context.Background(), "", "", func(book *domain.OrderBook) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+}
+
+func TestMemory_Orders(t *testing.T) {
+	misha := domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"}
+	sasha := domain.Client{ClientName: "Sasha", ExchangeName: "binance", Label: "test", Pair: "ETHUSDT"}
+	placed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	m := NewMemory()
+	require.NoError(t, m.SaveOrder(context.Background(), &domain.HistoryOrder{Client: misha, Side: "buy", TimePlaced: placed.Add(2*time.Second + 700*time.Millisecond)}))
+	require.NoError(t, m.SaveOrders(context.Background(), []*domain.HistoryOrder{
+		{Client: sasha, Side: "sell", TimePlaced: placed.Add(time.Second)},
+		{Client: misha, Side: "sell", TimePlaced: placed},
+	}))
+
+	orders, err := m.GetOrderHistory(context.Background(), &misha)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, placed.Add(2*time.Second), orders[0].TimePlaced)
+	assert.Equal(t, "sell", orders[1].Side)
+
+	orders[0].Side = "changed"
+	orders, err = m.GetOrderHistory(context.Background(), &misha)
+	require.NoError(t, err)
+	assert.Equal(t, "buy", orders[0].Side)
+
+	other := misha
+	other.Label = "other"
+	orders, err = m.GetOrderHistory(context.Background(), &other)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+
+	orders, err = m.GetOrderHistories(context.Background(), []*domain.Client{&misha, &sasha}, 0)
+	require.NoError(t, err)
+	assert.Len(t, orders, 3)
+	orders, err = m.GetOrderHistories(context.Background(), nil, 0)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+
+	var sides []string
+	require.NoError(t, m.ExportOrders(context.Background(), &domain.Client{ExchangeName: "binance"}, func(order *domain.HistoryOrder) error {
+		sides = append(sides, order.Client.ClientName+" "+order.Side)
+		return nil
+	}))
+	assert.Equal(t, []string{"Misha sell", "Sasha sell", "Misha buy"}, sides)
+
+	sides = nil
+	require.NoError(t, m.ExportOrders(context.Background(), &domain.Client{Pair: "ETHUSDT"}, func(order *domain.HistoryOrder) error {
+		sides = append(sides, order.Client.ClientName+" "+order.Side)
+		return nil
+	}))
+	assert.Equal(t, []string{"Sasha sell"}, sides)
+}
+
+func TestMemory_Concurrent(t *testing.T) {
+	m := NewMemory()
+	client := domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, m.SaveOrder(context.Background(), &domain.HistoryOrder{Client: client}))
+				assert.NoError(t, m.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &domain.AsksBids{Id: uint32(i*50 + j)}))
+				_, err := m.GetOrderHistory(context.Background(), &client)
+				assert.NoError(t, err)
+				_, err = m.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	orders, err := m.GetOrderHistory(context.Background(), &client)
+	require.NoError(t, err)
+	assert.Len(t, orders, 400)
+}
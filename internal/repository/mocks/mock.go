@@ -10,112 +10,235 @@
 package mock_repository
 
 import (
+	context "context"
 	reflect "reflect"
 
 	domain "github.com/kolibriee/trade-metrics/internal/domain"
 	gomock "go.uber.org/mock/gomock"
 )
 
-// Mockorderbook is a mock of orderbook interface.
-type Mockorderbook struct {
+// MockDB is a mock of DB interface.
+type MockDB struct {
 	ctrl     *gomock.Controller
-	recorder *MockorderbookMockRecorder
+	recorder *MockDBMockRecorder
 }
 
-// MockorderbookMockRecorder is the mock recorder for Mockorderbook.
-type MockorderbookMockRecorder struct {
-	mock *Mockorderbook
+// MockDBMockRecorder is the mock recorder for MockDB.
+type MockDBMockRecorder struct {
+	mock *MockDB
 }
 
-// NewMockorderbook creates a new mock instance.
-func NewMockorderbook(ctrl *gomock.Controller) *Mockorderbook {
-	mock := &Mockorderbook{ctrl: ctrl}
-	mock.recorder = &MockorderbookMockRecorder{mock}
+// NewMockDB creates a new mock instance.
+func NewMockDB(ctrl *gomock.Controller) *MockDB {
+	mock := &MockDB{ctrl: ctrl}
+	mock.recorder = &MockDBMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *Mockorderbook) EXPECT() *MockorderbookMockRecorder {
+func (m *MockDB) EXPECT() *MockDBMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockDB) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockDBMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDB)(nil).Close))
+}
+
+// Ping mocks base method.
+func (m *MockDB) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockDBMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockDB)(nil).Ping), ctx)
+}
+
+// MockOrderbook is a mock of Orderbook interface.
+type MockOrderbook struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderbookMockRecorder
+}
+
+// MockOrderbookMockRecorder is the mock recorder for MockOrderbook.
+type MockOrderbookMockRecorder struct {
+	mock *MockOrderbook
+}
+
+// NewMockOrderbook creates a new mock instance.
+func NewMockOrderbook(ctrl *gomock.Controller) *MockOrderbook {
+	mock := &MockOrderbook{ctrl: ctrl}
+	mock.recorder = &MockOrderbookMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderbook) EXPECT() *MockOrderbookMockRecorder {
+	return m.recorder
+}
+
+// ExportOrderBooks mocks base method.
+func (m *MockOrderbook) ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(*domain.OrderBook) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportOrderBooks", ctx, exchangeName, pair, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportOrderBooks indicates an expected call of ExportOrderBooks.
+func (mr *MockOrderbookMockRecorder) ExportOrderBooks(ctx, exchangeName, pair, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportOrderBooks", reflect.TypeOf((*MockOrderbook)(nil).ExportOrderBooks), ctx, exchangeName, pair, fn)
+}
+
 // GetOrderBook mocks base method.
-func (m *Mockorderbook) GetOrderBook(exchangeName, pair string) (*domain.AsksBids, error) {
+func (m *MockOrderbook) GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderBook", exchangeName, pair)
+	ret := m.ctrl.Call(m, "GetOrderBook", ctx, exchangeName, pair)
 	ret0, _ := ret[0].(*domain.AsksBids)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrderBook indicates an expected call of GetOrderBook.
-func (mr *MockorderbookMockRecorder) GetOrderBook(exchangeName, pair any) *gomock.Call {
+func (mr *MockOrderbookMockRecorder) GetOrderBook(ctx, exchangeName, pair any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderBook", reflect.TypeOf((*Mockorderbook)(nil).GetOrderBook), exchangeName, pair)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderBook", reflect.TypeOf((*MockOrderbook)(nil).GetOrderBook), ctx, exchangeName, pair)
 }
 
 // SaveOrderBook mocks base method.
-func (m *Mockorderbook) SaveOrderBook(exchangeName, pair string, asksBids *domain.AsksBids) error {
+func (m *MockOrderbook) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveOrderBook", exchangeName, pair, asksBids)
+	ret := m.ctrl.Call(m, "SaveOrderBook", ctx, exchangeName, pair, asksBids)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SaveOrderBook indicates an expected call of SaveOrderBook.
-func (mr *MockorderbookMockRecorder) SaveOrderBook(exchangeName, pair, asksBids any) *gomock.Call {
+func (mr *MockOrderbookMockRecorder) SaveOrderBook(ctx, exchangeName, pair, asksBids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrderBook", reflect.TypeOf((*MockOrderbook)(nil).SaveOrderBook), ctx, exchangeName, pair, asksBids)
+}
+
+// SaveOrderBooks mocks base method.
+func (m *MockOrderbook) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOrderBooks", ctx, orderBooks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveOrderBooks indicates an expected call of SaveOrderBooks.
+func (mr *MockOrderbookMockRecorder) SaveOrderBooks(ctx, orderBooks any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrderBook", reflect.TypeOf((*Mockorderbook)(nil).SaveOrderBook), exchangeName, pair, asksBids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrderBooks", reflect.TypeOf((*MockOrderbook)(nil).SaveOrderBooks), ctx, orderBooks)
 }
 
-// Mockorderhistory is a mock of orderhistory interface.
-type Mockorderhistory struct {
+// MockOrderhistory is a mock of Orderhistory interface.
+type MockOrderhistory struct {
 	ctrl     *gomock.Controller
-	recorder *MockorderhistoryMockRecorder
+	recorder *MockOrderhistoryMockRecorder
 }
 
-// MockorderhistoryMockRecorder is the mock recorder for Mockorderhistory.
-type MockorderhistoryMockRecorder struct {
-	mock *Mockorderhistory
+// MockOrderhistoryMockRecorder is the mock recorder for MockOrderhistory.
+type MockOrderhistoryMockRecorder struct {
+	mock *MockOrderhistory
 }
 
-// NewMockorderhistory creates a new mock instance.
-func NewMockorderhistory(ctrl *gomock.Controller) *Mockorderhistory {
-	mock := &Mockorderhistory{ctrl: ctrl}
-	mock.recorder = &MockorderhistoryMockRecorder{mock}
+// NewMockOrderhistory creates a new mock instance.
+func NewMockOrderhistory(ctrl *gomock.Controller) *MockOrderhistory {
+	mock := &MockOrderhistory{ctrl: ctrl}
+	mock.recorder = &MockOrderhistoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *Mockorderhistory) EXPECT() *MockorderhistoryMockRecorder {
+func (m *MockOrderhistory) EXPECT() *MockOrderhistoryMockRecorder {
 	return m.recorder
 }
 
+// ExportOrders mocks base method.
+func (m *MockOrderhistory) ExportOrders(ctx context.Context, filter *domain.Client, fn func(*domain.HistoryOrder) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportOrders", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportOrders indicates an expected call of ExportOrders.
+func (mr *MockOrderhistoryMockRecorder) ExportOrders(ctx, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportOrders", reflect.TypeOf((*MockOrderhistory)(nil).ExportOrders), ctx, filter, fn)
+}
+
+// GetOrderHistories mocks base method.
+func (m *MockOrderhistory) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderHistories", ctx, clients, limit)
+	ret0, _ := ret[0].([]*domain.HistoryOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderHistories indicates an expected call of GetOrderHistories.
+func (mr *MockOrderhistoryMockRecorder) GetOrderHistories(ctx, clients, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderHistories", reflect.TypeOf((*MockOrderhistory)(nil).GetOrderHistories), ctx, clients, limit)
+}
+
 // GetOrderHistory mocks base method.
-func (m *Mockorderhistory) GetOrderHistory(client *domain.Client) ([]*domain.HistoryOrder, error) {
+func (m *MockOrderhistory) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderHistory", client)
+	ret := m.ctrl.Call(m, "GetOrderHistory", ctx, client)
 	ret0, _ := ret[0].([]*domain.HistoryOrder)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrderHistory indicates an expected call of GetOrderHistory.
-func (mr *MockorderhistoryMockRecorder) GetOrderHistory(client any) *gomock.Call {
+func (mr *MockOrderhistoryMockRecorder) GetOrderHistory(ctx, client any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderHistory", reflect.TypeOf((*Mockorderhistory)(nil).GetOrderHistory), client)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderHistory", reflect.TypeOf((*MockOrderhistory)(nil).GetOrderHistory), ctx, client)
 }
 
 // SaveOrder mocks base method.
-func (m *Mockorderhistory) SaveOrder(order *domain.HistoryOrder) error {
+func (m *MockOrderhistory) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveOrder", order)
+	ret := m.ctrl.Call(m, "SaveOrder", ctx, order)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SaveOrder indicates an expected call of SaveOrder.
-func (mr *MockorderhistoryMockRecorder) SaveOrder(order any) *gomock.Call {
+func (mr *MockOrderhistoryMockRecorder) SaveOrder(ctx, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrder", reflect.TypeOf((*MockOrderhistory)(nil).SaveOrder), ctx, order)
+}
+
+// SaveOrders mocks base method.
+func (m *MockOrderhistory) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOrders", ctx, orders)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveOrders indicates an expected call of SaveOrders.
+func (mr *MockOrderhistoryMockRecorder) SaveOrders(ctx, orders any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrder", reflect.TypeOf((*Mockorderhistory)(nil).SaveOrder), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrders", reflect.TypeOf((*MockOrderhistory)(nil).SaveOrders), ctx, orders)
 }
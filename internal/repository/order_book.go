@@ -2,28 +2,35 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/kolibriee/trade-metrics/internal/domain"
 )
 
 type orderBookCH struct {
-	db driver.Conn
+	db       driver.Conn
+	settings *Settings
 }
 
-func NewOrderBookCH(db driver.Conn) *orderBookCH {
+func NewOrderBookCH(db driver.Conn, settings *Settings) *orderBookCH {
 	return &orderBookCH{
-		db: db,
+		db:       db,
+		settings: settings,
 	}
 }
 
-func (o *orderBookCH) GetOrderBook(exchangeName, pair string) (*domain.AsksBids, error) {
+func (o *orderBookCH) GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+	ctx = queryContext(ctx, o.settings.Get())
 	query := `
         SELECT id, asks, bids 
         FROM order_book
         WHERE exchange = ? AND pair = ?
+        ORDER BY saved_at DESC
+        LIMIT 1
     `
 
 	var (
@@ -32,32 +39,24 @@ func (o *orderBookCH) GetOrderBook(exchangeName, pair string) (*domain.AsksBids,
 		bids [][]float64
 	)
 
-	err := o.db.QueryRow(context.Background(), query, exchangeName, pair).Scan(&id, &asks, &bids)
+	err := o.db.QueryRow(ctx, query, exchangeName, pair).Scan(&id, &asks, &bids)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, errors.New("failed to get order book: " + err.Error())
 	}
 
 	var asksBids domain.AsksBids
 	asksBids.Id = id
-	asksBids.Asks = make([]domain.DepthOrder, len(asks))
-	for i, ask := range asks {
-		asksBids.Asks[i] = domain.DepthOrder{
-			Price:   ask[0],
-			BaseQty: ask[1],
-		}
-	}
-	asksBids.Bids = make([]domain.DepthOrder, len(bids))
-	for i, bid := range bids {
-		asksBids.Bids[i] = domain.DepthOrder{
-			Price:   bid[0],
-			BaseQty: bid[1],
-		}
-	}
+	asksBids.Asks = depthOrders(asks)
+	asksBids.Bids = depthOrders(bids)
 
 	return &asksBids, nil
 }
 
-func (o *orderBookCH) SaveOrderBook(exchangeName, pair string, asksBids *domain.AsksBids) error {
+func (o *orderBookCH) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	ctx = queryContext(ctx, o.settings.Get())
 	id := asksBids.Id
 	asks := make([]string, len(asksBids.Asks))
 	for i, ask := range asksBids.Asks {
@@ -70,11 +69,85 @@ func (o *orderBookCH) SaveOrderBook(exchangeName, pair string, asksBids *domain.
 	}
 
 	query := `
-        INSERT INTO order_book (id, exchange, pair, asks, bids)
-        VALUES (?, ?, ?, ?, ?)
+        INSERT INTO order_book (id, exchange, pair, asks, bids, saved_at)
+        VALUES (?, ?, ?, ?, ?, ?)
     `
-	if err := o.db.Exec(context.Background(), query, id, exchangeName, pair, asks, bids); err != nil {
+	// saved_at is set by the app like for batches, so both are ordered by
+	// the same clock
+	if err := o.db.Exec(ctx, query, id, exchangeName, pair, asks, bids, time.Now()); err != nil {
 		return errors.New("failed to save order book: " + err.Error())
 	}
 	return nil
 }
+
+func (o *orderBookCH) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	ctx = queryContext(ctx, o.settings.Get())
+	batch, err := o.db.PrepareBatch(ctx, "INSERT INTO order_book (id, exchange, pair, asks, bids, saved_at)")
+	if err != nil {
+		return errors.New("failed to prepare order book batch: " + err.Error())
+	}
+	// rows of a batch would share the default saved_at, a nanosecond apart
+	// they are exported in the order of the batch
+	savedAt := time.Now()
+	for i, orderBook := range orderBooks {
+		err := batch.Append(uint32(orderBook.ID), orderBook.Exchange, orderBook.Pair, levels(orderBook.Asks), levels(orderBook.Bids),
+			savedAt.Add(time.Duration(i)))
+		if err != nil {
+			batch.Abort()
+			return errors.New("failed to append order book: " + err.Error())
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return errors.New("failed to save order books: " + err.Error())
+	}
+	return nil
+}
+
+func (o *orderBookCH) ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(orderBook *domain.OrderBook) error) error {
+	ctx = queryContext(ctx, o.settings.Get())
+	where, args := whereEqual([]string{"exchange", "pair"}, []string{exchangeName, pair})
+	rows, err := o.db.Query(ctx, "SELECT id, exchange, pair, asks, bids FROM order_book"+where+" ORDER BY saved_at", args...)
+	if err != nil {
+		return errors.New("failed to export order books: " + err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id         uint32
+			orderBook  domain.OrderBook
+			asks, bids [][]float64
+		)
+		if err := rows.Scan(&id, &orderBook.Exchange, &orderBook.Pair, &asks, &bids); err != nil {
+			return errors.New("failed to scan row: " + err.Error())
+		}
+		orderBook.ID = int64(id)
+		orderBook.Asks = depthOrders(asks)
+		orderBook.Bids = depthOrders(bids)
+		if err := fn(&orderBook); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.New("failed to read rows: " + err.Error())
+	}
+	return nil
+}
+
+func depthOrders(levels [][]float64) []domain.DepthOrder {
+	res := make([]domain.DepthOrder, len(levels))
+	for i, level := range levels {
+		res[i] = domain.DepthOrder{
+			Price:   level[0],
+			BaseQty: level[1],
+		}
+	}
+	return res
+}
+
+func levels(orders []domain.DepthOrder) [][]float64 {
+	res := make([][]float64, len(orders))
+	for i, order := range orders {
+		res[i] = []float64{order.Price, order.BaseQty}
+	}
+	return res
+}
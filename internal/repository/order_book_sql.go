@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// orderBookSQL stores the levels of a side as a JSON array of
+// [price, base_qty] pairs, like the tuples of the ClickHouse table.
+type orderBookSQL struct {
+	db *SQLDB
+}
+
+func NewOrderBookSQL(db *SQLDB) *orderBookSQL {
+	return &orderBookSQL{
+		db: db,
+	}
+}
+
+func (o *orderBookSQL) GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+	query := `
+        SELECT id, asks, bids
+        FROM order_book
+        WHERE exchange = ? AND pair = ?
+        ORDER BY seq DESC
+        LIMIT 1
+    `
+
+	var (
+		id         int64
+		asks, bids string
+	)
+	traceQuery(ctx, query)
+	err := o.db.QueryRowContext(ctx, o.db.rebind(query), exchangeName, pair).Scan(&id, &asks, &bids)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.New("failed to get order book: " + err.Error())
+	}
+
+	asksBids := domain.AsksBids{Id: uint32(id)}
+	if asksBids.Asks, err = decodeLevels(asks); err != nil {
+		return nil, err
+	}
+	if asksBids.Bids, err = decodeLevels(bids); err != nil {
+		return nil, err
+	}
+	return &asksBids, nil
+}
+
+func (o *orderBookSQL) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	err := o.db.insertOnce(ctx, func(tx *sql.Tx) error {
+		return o.insert(ctx, tx, int64(asksBids.Id), exchangeName, pair, asksBids.Asks, asksBids.Bids)
+	})
+	if err != nil {
+		return errors.New("failed to save order book: " + err.Error())
+	}
+	return nil
+}
+
+func (o *orderBookSQL) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	err := o.db.insertOnce(ctx, func(tx *sql.Tx) error {
+		for _, orderBook := range orderBooks {
+			err := o.insert(ctx, tx, int64(uint32(orderBook.ID)), orderBook.Exchange, orderBook.Pair, orderBook.Asks, orderBook.Bids)
+			if err != nil {
+				return errors.New("failed to append order book: " + err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.New("failed to save order books: " + err.Error())
+	}
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (o *orderBookSQL) insert(ctx context.Context, db execer, id int64, exchangeName, pair string, asks, bids []domain.DepthOrder) error {
+	asksJSON, err := json.Marshal(levels(asks))
+	if err != nil {
+		return err
+	}
+	bidsJSON, err := json.Marshal(levels(bids))
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO order_book (id, exchange, pair, asks, bids) VALUES (?, ?, ?, ?, ?)`
+	traceQuery(ctx, query)
+	_, err = db.ExecContext(ctx, o.db.rebind(query), id, exchangeName, pair, string(asksJSON), string(bidsJSON))
+	return err
+}
+
+func (o *orderBookSQL) ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(orderBook *domain.OrderBook) error) error {
+	where, args := whereEqual([]string{"exchange", "pair"}, []string{exchangeName, pair})
+	query := o.db.rebind("SELECT id, exchange, pair, asks, bids FROM order_book" + where + " ORDER BY seq")
+	traceQuery(ctx, query)
+	rows, err := o.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.New("failed to export order books: " + err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			orderBook  domain.OrderBook
+			asks, bids string
+		)
+		if err := rows.Scan(&orderBook.ID, &orderBook.Exchange, &orderBook.Pair, &asks, &bids); err != nil {
+			return errors.New("failed to scan row: " + err.Error())
+		}
+		if orderBook.Asks, err = decodeLevels(asks); err != nil {
+			return err
+		}
+		if orderBook.Bids, err = decodeLevels(bids); err != nil {
+			return err
+		}
+		if err := fn(&orderBook); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.New("failed to read rows: " + err.Error())
+	}
+	return nil
+}
+
+func decodeLevels(data string) ([]domain.DepthOrder, error) {
+	var levels [][]float64
+	if err := json.Unmarshal([]byte(data), &levels); err != nil {
+		return nil, errors.New("failed to decode order book levels: " + err.Error())
+	}
+	for _, level := range levels {
+		if len(level) != 2 {
+			return nil, errors.New("failed to decode order book levels: level without price and quantity")
+		}
+	}
+	return depthOrders(levels), nil
+}
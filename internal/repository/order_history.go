@@ -3,68 +3,172 @@ package repository
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/kolibriee/trade-metrics/internal/domain"
 )
 
 type orderHistoryCH struct {
-	db driver.Conn
+	db       driver.Conn
+	settings *Settings
 }
 
-func NewOrderHistoryCH(db driver.Conn) *orderHistoryCH {
+func NewOrderHistoryCH(db driver.Conn, settings *Settings) *orderHistoryCH {
 	return &orderHistoryCH{
-		db: db,
+		db:       db,
+		settings: settings,
 	}
 }
 
-func (o *orderHistoryCH) GetOrderHistory(client *domain.Client) ([]*domain.HistoryOrder, error) {
+func (o *orderHistoryCH) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
+	ctx = queryContext(ctx, o.settings.Get())
 	query := `SELECT client_name, exchange_name, label, pair, side, type,
         base_qty, price, algorithm_name_placed,
         lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
         FROM order_history
         WHERE client_name = ? AND exchange_name = ? AND label = ? AND pair = ?`
 
-	var orders []*domain.HistoryOrder
-
-	rows, err := o.db.Query(context.Background(), query, client.ClientName, client.ExchangeName, client.Label, client.Pair)
+	rows, err := o.db.Query(ctx, query, client.ClientName, client.ExchangeName, client.Label, client.Pair)
 	if err != nil {
 		return nil, errors.New("failed to get order history: " + err.Error())
 	}
 	defer rows.Close()
-	var order domain.HistoryOrder
+	return scanOrders(rows)
+}
+
+// GetOrderHistories returns the orders of all clients in one query, with a
+// limit the latest of each client by LIMIT BY.
+func (o *orderHistoryCH) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	ctx = queryContext(ctx, o.settings.Get())
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, 0, len(clients))
+	args := make([]any, 0, 4*len(clients))
+	for _, client := range clients {
+		placeholders = append(placeholders, "(?, ?, ?, ?)")
+		args = append(args, client.ClientName, client.ExchangeName, client.Label, client.Pair)
+	}
+	query := `SELECT client_name, exchange_name, label, pair, side, type,
+        base_qty, price, algorithm_name_placed,
+        lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
+        FROM order_history
+        WHERE (client_name, exchange_name, label, pair) IN (` + strings.Join(placeholders, ", ") + `)`
+	if limit > 0 {
+		query += `
+        ORDER BY time_placed DESC
+        LIMIT ` + strconv.Itoa(limit) + ` BY client_name, exchange_name, label, pair`
+	}
+
+	rows, err := o.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.New("failed to get order histories: " + err.Error())
+	}
+	defer rows.Close()
+	return scanOrders(rows)
+}
+
+func (o *orderHistoryCH) ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+	ctx = queryContext(ctx, o.settings.Get())
+	query := `SELECT client_name, exchange_name, label, pair, side, type,
+        base_qty, price, algorithm_name_placed,
+        lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
+        FROM order_history`
+	where, args := whereEqual([]string{"client_name", "exchange_name", "label", "pair"},
+		[]string{filter.ClientName, filter.ExchangeName, filter.Label, filter.Pair})
+	rows, err := o.db.Query(ctx, query+where+" ORDER BY time_placed", args...)
+	if err != nil {
+		return errors.New("failed to export order history: " + err.Error())
+	}
+	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(
-			&order.Client.ClientName,
-			&order.Client.ExchangeName,
-			&order.Client.Label,
-			&order.Client.Pair,
-			&order.Side,
-			&order.Type,
-			&order.BaseQty,
-			&order.Price,
-			&order.AlgorithmNamePlaced,
-			&order.LowestSellPrice,
-			&order.HighestBuyPrice,
-			&order.CommissionQuoteQty,
-			&order.TimePlaced,
-		)
+		order, err := scanOrder(rows)
 		if err != nil {
-			return nil, errors.New("failed to scan row: " + err.Error())
+			return err
+		}
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.New("failed to read rows: " + err.Error())
+	}
+	return nil
+}
+
+// whereEqual returns a WHERE clause matching the columns with non-empty
+// values, and its arguments.
+func whereEqual(columns, values []string) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	for i, column := range columns {
+		if values[i] == "" {
+			continue
 		}
-		orders = append(orders, &order)
+		conds = append(conds, column+" = ?")
+		args = append(args, values[i])
 	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
 
+func scanOrders(rows driver.Rows) ([]*domain.HistoryOrder, error) {
+	var orders []*domain.HistoryOrder
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to read rows: " + err.Error())
+	}
 	return orders, nil
 }
 
-func (o *orderHistoryCH) SaveOrder(order *domain.HistoryOrder) error {
+// scanner is a row of driver.Rows or *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(rows scanner) (*domain.HistoryOrder, error) {
+	var order domain.HistoryOrder
+	err := rows.Scan(
+		&order.Client.ClientName,
+		&order.Client.ExchangeName,
+		&order.Client.Label,
+		&order.Client.Pair,
+		&order.Side,
+		&order.Type,
+		&order.BaseQty,
+		&order.Price,
+		&order.AlgorithmNamePlaced,
+		&order.LowestSellPrice,
+		&order.HighestBuyPrice,
+		&order.CommissionQuoteQty,
+		&order.TimePlaced,
+	)
+	if err != nil {
+		return nil, errors.New("failed to scan row: " + err.Error())
+	}
+	return &order, nil
+}
+
+func (o *orderHistoryCH) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	ctx = queryContext(ctx, o.settings.Get())
 	quary := `INSERT INTO order_history (
 		client_name, exchange_name, label, pair, side, type,
 		base_qty, price, algorithm_name_placed,
 		lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
 	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	if err := o.db.Exec(context.Background(), quary,
+	if err := o.db.Exec(ctx, quary,
 		order.Client.ClientName, order.Client.ExchangeName, order.Client.Label, order.Client.Pair,
 		order.Side, order.Type, order.BaseQty, order.Price, order.AlgorithmNamePlaced,
 		order.LowestSellPrice, order.HighestBuyPrice, order.CommissionQuoteQty,
@@ -73,3 +177,30 @@ func (o *orderHistoryCH) SaveOrder(order *domain.HistoryOrder) error {
 	}
 	return nil
 }
+
+func (o *orderHistoryCH) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	ctx = queryContext(ctx, o.settings.Get())
+	batch, err := o.db.PrepareBatch(ctx, `INSERT INTO order_history (
+		client_name, exchange_name, label, pair, side, type,
+		base_qty, price, algorithm_name_placed,
+		lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
+	)`)
+	if err != nil {
+		return errors.New("failed to prepare order batch: " + err.Error())
+	}
+	for _, order := range orders {
+		err := batch.Append(
+			order.Client.ClientName, order.Client.ExchangeName, order.Client.Label, order.Client.Pair,
+			order.Side, order.Type, order.BaseQty, order.Price, order.AlgorithmNamePlaced,
+			order.LowestSellPrice, order.HighestBuyPrice, order.CommissionQuoteQty,
+			order.TimePlaced)
+		if err != nil {
+			batch.Abort()
+			return errors.New("failed to append order: " + err.Error())
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return errors.New("failed to save orders: " + err.Error())
+	}
+	return nil
+}
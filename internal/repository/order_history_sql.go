@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// orderHistorySQL stores time_placed with the second precision of the
+// ClickHouse DateTime column, so every backend returns the same orders.
+type orderHistorySQL struct {
+	db *SQLDB
+}
+
+func NewOrderHistorySQL(db *SQLDB) *orderHistorySQL {
+	return &orderHistorySQL{
+		db: db,
+	}
+}
+
+const selectOrdersSQL = `SELECT client_name, exchange_name, label, pair, side, type,
+        base_qty, price, algorithm_name_placed,
+        lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
+        FROM order_history`
+
+func (o *orderHistorySQL) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
+	query := selectOrdersSQL + `
+        WHERE client_name = ? AND exchange_name = ? AND label = ? AND pair = ?`
+
+	traceQuery(ctx, query)
+	rows, err := o.db.QueryContext(ctx, o.db.rebind(query), client.ClientName, client.ExchangeName, client.Label, client.Pair)
+	if err != nil {
+		return nil, errors.New("failed to get order history: " + err.Error())
+	}
+	defer rows.Close()
+	return scanOrdersSQL(rows)
+}
+
+// GetOrderHistories returns the orders of all clients in one query, with a
+// limit the latest of each client numbered by a window function.
+func (o *orderHistorySQL) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, 0, len(clients))
+	args := make([]any, 0, 4*len(clients))
+	for _, client := range clients {
+		placeholders = append(placeholders, "(?, ?, ?, ?)")
+		args = append(args, client.ClientName, client.ExchangeName, client.Label, client.Pair)
+	}
+	where := `
+        WHERE (client_name, exchange_name, label, pair) IN (` + strings.Join(placeholders, ", ") + `)`
+	query := selectOrdersSQL + where
+	if limit > 0 {
+		query = `SELECT client_name, exchange_name, label, pair, side, type,
+        base_qty, price, algorithm_name_placed,
+        lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
+        FROM (
+            SELECT *, ROW_NUMBER() OVER (
+                PARTITION BY client_name, exchange_name, label, pair ORDER BY time_placed DESC
+            ) AS n
+            FROM order_history` + where + `
+        ) latest
+        WHERE n <= ?`
+		args = append(args, limit)
+	}
+
+	traceQuery(ctx, query)
+	rows, err := o.db.QueryContext(ctx, o.db.rebind(query), args...)
+	if err != nil {
+		return nil, errors.New("failed to get order histories: " + err.Error())
+	}
+	defer rows.Close()
+	return scanOrdersSQL(rows)
+}
+
+func (o *orderHistorySQL) ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+	where, args := whereEqual([]string{"client_name", "exchange_name", "label", "pair"},
+		[]string{filter.ClientName, filter.ExchangeName, filter.Label, filter.Pair})
+	query := selectOrdersSQL + where + " ORDER BY time_placed"
+	traceQuery(ctx, query)
+	rows, err := o.db.QueryContext(ctx, o.db.rebind(query), args...)
+	if err != nil {
+		return errors.New("failed to export order history: " + err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.New("failed to read rows: " + err.Error())
+	}
+	return nil
+}
+
+func (o *orderHistorySQL) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	err := o.db.insertOnce(ctx, func(tx *sql.Tx) error {
+		return o.insert(ctx, tx, order)
+	})
+	if err != nil {
+		return errors.New("failed to save order: " + err.Error())
+	}
+	return nil
+}
+
+func (o *orderHistorySQL) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	err := o.db.insertOnce(ctx, func(tx *sql.Tx) error {
+		for _, order := range orders {
+			if err := o.insert(ctx, tx, order); err != nil {
+				return errors.New("failed to append order: " + err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.New("failed to save orders: " + err.Error())
+	}
+	return nil
+}
+
+func (o *orderHistorySQL) insert(ctx context.Context, db execer, order *domain.HistoryOrder) error {
+	query := `INSERT INTO order_history (
+		client_name, exchange_name, label, pair, side, type,
+		base_qty, price, algorithm_name_placed,
+		lowest_sell_prc, highest_buy_prc, commission_quote_qty, time_placed
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	traceQuery(ctx, query)
+	_, err := db.ExecContext(ctx, o.db.rebind(query),
+		order.Client.ClientName, order.Client.ExchangeName, order.Client.Label, order.Client.Pair,
+		order.Side, order.Type, order.BaseQty, order.Price, order.AlgorithmNamePlaced,
+		order.LowestSellPrice, order.HighestBuyPrice, order.CommissionQuoteQty,
+		order.TimePlaced.UTC().Truncate(time.Second))
+	return err
+}
+
+func scanOrdersSQL(rows *sql.Rows) ([]*domain.HistoryOrder, error) {
+	var orders []*domain.HistoryOrder
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to read rows: " + err.Error())
+	}
+	return orders, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderRows returns orders as the rows of an order_history query.
+type orderRows struct {
+	driver.Rows
+	orders []domain.HistoryOrder
+	next   int
+	err    error
+}
+
+func (r *orderRows) Next() bool {
+	r.next++
+	return r.next <= len(r.orders)
+}
+
+func (r *orderRows) Scan(dest ...any) error {
+	o := r.orders[r.next-1]
+	values := []any{o.Client.ClientName, o.Client.ExchangeName, o.Client.Label, o.Client.Pair, o.Side, o.Type,
+		o.BaseQty, o.Price, o.AlgorithmNamePlaced, o.LowestSellPrice, o.HighestBuyPrice, o.CommissionQuoteQty, o.TimePlaced}
+	for i, v := range values {
+		switch d := dest[i].(type) {
+		case *string:
+			*d = v.(string)
+		case *float64:
+			*d = v.(float64)
+		case *time.Time:
+			*d = v.(time.Time)
+		}
+	}
+	return nil
+}
+
+func (r *orderRows) Err() error {
+	return r.err
+}
+
+func TestScanOrders(t *testing.T) {
+	client := domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"}
+	placed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := []domain.HistoryOrder{
+		{Client: client, Side: "buy", Type: "limit", Price: 100, TimePlaced: placed},
+		{Client: client, Side: "sell", Type: "market", Price: 101, TimePlaced: placed.Add(time.Second)},
+	}
+
+	// every row gets its own order
+	orders, err := scanOrders(&orderRows{orders: rows})
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, rows[0], *orders[0])
+	assert.Equal(t, rows[1], *orders[1])
+
+	_, err = scanOrders(&orderRows{orders: rows, err: errors.New("connection reset")})
+	assert.EqualError(t, err, "failed to read rows: connection reset")
+}
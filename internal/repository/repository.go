@@ -1,18 +1,54 @@
 package repository
 
 import (
+	"context"
+	"errors"
+
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/kolibriee/trade-metrics/internal/domain"
 )
 
+const (
+	BackendClickHouse = "clickhouse"
+	BackendSQLite     = "sqlite"
+	BackendPostgres   = "postgres"
+	BackendMemory     = "memory"
+)
+
+// DB is the database behind a Repository.
+type DB interface {
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var (
+	// ErrNotFound is returned for order books that were never saved.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned for orders of clients the caller may not
+	// access.
+	ErrForbidden = errors.New("forbidden")
+)
+
 type Orderbook interface {
-	GetOrderBook(exchangeName, pair string) (*domain.AsksBids, error)
-	SaveOrderBook(exchangeName, pair string, asksBids *domain.AsksBids) error
+	GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error)
+	SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error
+	SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error
+	// ExportOrderBooks calls fn for every stored order book of the exchange
+	// and pair while reading them, in the order they were saved. Empty
+	// arguments match every value.
+	ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(orderBook *domain.OrderBook) error) error
 }
 
 type Orderhistory interface {
-	GetOrderHistory(client *domain.Client) ([]*domain.HistoryOrder, error)
-	SaveOrder(order *domain.HistoryOrder) error
+	GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error)
+	// GetOrderHistories returns the orders of all clients, with a positive
+	// limit only the limit most recent orders of each client.
+	GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error)
+	SaveOrder(ctx context.Context, order *domain.HistoryOrder) error
+	SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error
+	// ExportOrders calls fn for every order matching filter while reading
+	// them, empty filter fields match every value.
+	ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error
 }
 
 type Repository struct {
@@ -20,9 +56,12 @@ type Repository struct {
 	Orderhistory
 }
 
-func NewRepository(db driver.Conn) *Repository {
+// NewRepository returns the ClickHouse repository, settings are sent with
+// every query.
+func NewRepository(db driver.Conn, settings *Settings) *Repository {
+	db = tracedConn{Conn: db}
 	return &Repository{
-		Orderbook:    NewOrderBookCH(db),
-		Orderhistory: NewOrderHistoryCH(db),
+		Orderbook:    NewOrderBookCH(db, settings),
+		Orderhistory: NewOrderHistoryCH(db, settings),
 	}
 }
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	_ "modernc.org/sqlite"
+)
+
+// SQLDB is a SQLite or PostgreSQL database with the tables of
+// migrations/sqlite or migrations/postgres.
+type SQLDB struct {
+	*sql.DB
+	postgres bool
+}
+
+// NewSQLiteDB opens the SQLite file at cfg.Path, creating it and its
+// directory if needed. The file is opened in WAL mode so readers don't wait
+// for writers, concurrent writers wait for each other for up to 5 seconds.
+func NewSQLiteDB(cfg *config.SQLite) (*SQLDB, error) {
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, errors.New("failed to create SQLite directory: " + err.Error())
+		}
+	}
+	dsn := "file:" + cfg.Path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, errors.New("failed to open SQLite: " + err.Error())
+	}
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, errors.New("failed to ping SQLite: " + err.Error())
+	}
+	return &SQLDB{DB: db}, nil
+}
+
+func NewPostgresDB(cfg *config.Postgres) (*SQLDB, error) {
+	db, err := sql.Open("pgx", cfg.DSN)
+	if err != nil {
+		return nil, errors.New("failed to connect to PostgreSQL: " + err.Error())
+	}
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, errors.New("failed to ping PostgreSQL: " + err.Error())
+	}
+	return &SQLDB{DB: db, postgres: true}, nil
+}
+
+func (db *SQLDB) Ping(ctx context.Context) error {
+	return db.PingContext(ctx)
+}
+
+func NewSQLRepository(db *SQLDB) *Repository {
+	return &Repository{
+		Orderbook:    NewOrderBookSQL(db),
+		Orderhistory: NewOrderHistorySQL(db),
+	}
+}
+
+// rebind replaces the ? placeholders of query by $1, $2, ... for
+// PostgreSQL. Queries must not contain question marks elsewhere.
+func (db *SQLDB) rebind(query string) string {
+	if !db.postgres {
+		return query
+	}
+	var (
+		b strings.Builder
+		n int
+	)
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// tokenRetention is how long insert tokens are kept, longer than a write
+// waits in the spool in practice.
+const tokenRetention = 7 * 24 * time.Hour
+
+// insertOnce runs insert in a transaction which also stores the insert token
+// of ctx. When the token is already stored the insert is skipped.
+func (db *SQLDB) insertOnce(ctx context.Context, insert func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback()
+	if token := insertToken(ctx); token != "" {
+		now := time.Now().UTC()
+		query := `INSERT INTO insert_tokens (token, inserted_at) VALUES (?, ?) ON CONFLICT (token) DO NOTHING`
+		traceQuery(ctx, query)
+		res, err := tx.ExecContext(ctx, db.rebind(query), token, now)
+		if err != nil {
+			return errors.New("failed to store insert token: " + err.Error())
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		query = `DELETE FROM insert_tokens WHERE inserted_at < ?`
+		traceQuery(ctx, query)
+		if _, err := tx.ExecContext(ctx, db.rebind(query), now.Add(-tokenRetention)); err != nil {
+			return errors.New("failed to delete old insert tokens: " + err.Error())
+		}
+	}
+	if err := insert(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+	return nil
+}
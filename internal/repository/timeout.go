@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+// WithTimeouts limits every operation of repo to the timeout of its kind in
+// cfg. Errors of operations whose context ended match the context error, so
+// callers can tell a deadline from a failed query with errors.Is even when
+// the driver doesn't wrap it.
+func WithTimeouts(repo *Repository, cfg *config.Timeouts) *Repository {
+	return &Repository{
+		Orderbook:    &orderBookTimeout{Orderbook: repo.Orderbook, cfg: cfg},
+		Orderhistory: &orderHistoryTimeout{Orderhistory: repo.Orderhistory, cfg: cfg},
+	}
+}
+
+type orderBookTimeout struct {
+	Orderbook
+	cfg *config.Timeouts
+}
+
+func (o *orderBookTimeout) GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+	ctx, cancel := withTimeout(ctx, o.cfg.Read)
+	defer cancel()
+	orderBook, err := o.Orderbook.GetOrderBook(ctx, exchangeName, pair)
+	return orderBook, contextError(ctx, err)
+}
+
+func (o *orderBookTimeout) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	ctx, cancel := withTimeout(ctx, o.cfg.Write)
+	defer cancel()
+	return contextError(ctx, o.Orderbook.SaveOrderBook(ctx, exchangeName, pair, asksBids))
+}
+
+func (o *orderBookTimeout) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	ctx, cancel := withTimeout(ctx, o.cfg.Write)
+	defer cancel()
+	return contextError(ctx, o.Orderbook.SaveOrderBooks(ctx, orderBooks))
+}
+
+func (o *orderBookTimeout) ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(orderBook *domain.OrderBook) error) error {
+	ctx, cancel := withTimeout(ctx, o.cfg.Export)
+	defer cancel()
+	return contextError(ctx, o.Orderbook.ExportOrderBooks(ctx, exchangeName, pair, fn))
+}
+
+type orderHistoryTimeout struct {
+	Orderhistory
+	cfg *config.Timeouts
+}
+
+func (o *orderHistoryTimeout) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
+	ctx, cancel := withTimeout(ctx, o.cfg.Read)
+	defer cancel()
+	orders, err := o.Orderhistory.GetOrderHistory(ctx, client)
+	return orders, contextError(ctx, err)
+}
+
+func (o *orderHistoryTimeout) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	ctx, cancel := withTimeout(ctx, o.cfg.Read)
+	defer cancel()
+	orders, err := o.Orderhistory.GetOrderHistories(ctx, clients, limit)
+	return orders, contextError(ctx, err)
+}
+
+func (o *orderHistoryTimeout) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	ctx, cancel := withTimeout(ctx, o.cfg.Write)
+	defer cancel()
+	return contextError(ctx, o.Orderhistory.SaveOrder(ctx, order))
+}
+
+func (o *orderHistoryTimeout) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	ctx, cancel := withTimeout(ctx, o.cfg.Write)
+	defer cancel()
+	return contextError(ctx, o.Orderhistory.SaveOrders(ctx, orders))
+}
+
+func (o *orderHistoryTimeout) ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+	ctx, cancel := withTimeout(ctx, o.cfg.Export)
+	defer cancel()
+	return contextError(ctx, o.Orderhistory.ExportOrders(ctx, filter, fn))
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextError returns err matching the error of ctx if ctx ended.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return &endedError{err: err, ctxErr: ctx.Err()}
+}
+
+type endedError struct {
+	err    error
+	ctxErr error
+}
+
+func (e *endedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *endedError) Unwrap() []error {
+	return []error{e.err, e.ctxErr}
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWithTimeouts(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	orderBook := mock_repository.NewMockOrderbook(c)
+	orderHistory := mock_repository.NewMockOrderhistory(c)
+	repo := WithTimeouts(&Repository{Orderbook: orderBook, Orderhistory: orderHistory}, &config.Timeouts{
+		Read:  50 * time.Millisecond,
+		Write: time.Hour,
+	})
+
+	// the driver error doesn't wrap the context error
+	orderBook.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").DoAndReturn(
+		func(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+			<-ctx.Done()
+			return nil, errors.New("failed to get order book: read: i/o timeout")
+		})
+	start := time.Now()
+	_, err := repo.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, "failed to get order book: read: i/o timeout", err.Error())
+
+	orderHistory.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *domain.HistoryOrder) error {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.Greater(t, time.Until(deadline), time.Minute)
+			return nil
+		})
+	assert.NoError(t, repo.SaveOrder(context.Background(), &domain.HistoryOrder{}))
+
+	// no export timeout, the caller's context still applies
+	ctx, cancel := context.WithCancel(context.Background())
+	orderHistory.EXPECT().ExportOrders(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, filter *domain.Client, fn func(*domain.HistoryOrder) error) error {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			cancel()
+			return errors.New("failed to export order history: connection closed")
+		})
+	err = repo.ExportOrders(ctx, &domain.Client{}, func(*domain.HistoryOrder) error { return nil })
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	// errors of operations that ended in time are unchanged
+	orderBook.EXPECT().GetOrderBook(gomock.Any(), "binance", "ETHUSDT").Return(nil, ErrNotFound)
+	_, err = repo.GetOrderBook(context.Background(), "binance", "ETHUSDT")
+	assert.Equal(t, ErrNotFound, err)
+}
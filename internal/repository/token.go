@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+)
+
+type insertTokenCtx struct{}
+
+// WithInsertToken marks the writes of ctx as attempts of one insert. Every
+// backend stores an insert with a token once and drops repeated inserts of
+// the same token, so writes can be retried after an error whose outcome is
+// unknown, e.g. a timeout or a crash before the success was recorded.
+func WithInsertToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, insertTokenCtx{}, token)
+}
+
+func insertToken(ctx context.Context) string {
+	token, _ := ctx.Value(insertTokenCtx{}).(string)
+	return token
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceQuery records query on the span of ctx, which is the span of the
+// repository method when tracing is enabled.
+func traceQuery(ctx context.Context, query string) {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(semconv.DBQueryText(query))
+	}
+}
+
+// tracedConn records the text of every ClickHouse query on the span of its
+// context.
+type tracedConn struct {
+	driver.Conn
+}
+
+func (c tracedConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	traceQuery(ctx, query)
+	return c.Conn.Select(ctx, dest, query, args...)
+}
+
+func (c tracedConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	traceQuery(ctx, query)
+	return c.Conn.Query(ctx, query, args...)
+}
+
+func (c tracedConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	traceQuery(ctx, query)
+	return c.Conn.QueryRow(ctx, query, args...)
+}
+
+func (c tracedConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	traceQuery(ctx, query)
+	return c.Conn.PrepareBatch(ctx, query, opts...)
+}
+
+func (c tracedConn) Exec(ctx context.Context, query string, args ...any) error {
+	traceQuery(ctx, query)
+	return c.Conn.Exec(ctx, query, args...)
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"net"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"google.golang.org/grpc"
+)
+
+type GRPCServer struct {
+	grpcServer *grpc.Server
+}
+
+func NewGRPCServer(register func(s *grpc.Server), opts ...grpc.ServerOption) *GRPCServer {
+	s := &GRPCServer{
+		grpcServer: grpc.NewServer(opts...),
+	}
+	register(s.grpcServer)
+	return s
+}
+
+func (s *GRPCServer) Run(cfg *config.Server) error {
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		return err
+	}
+	return s.grpcServer.Serve(lis)
+}
+
+// Shutdown waits for running RPCs to finish, streams still open when ctx is
+// done are cancelled.
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,98 @@
+package spool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Wrap returns a repository whose saves fall back to the spool when the
+// database is unavailable: the write timed out or ping fails after it. Other
+// errors, and those of writes whose caller went away, are returned. While
+// older records are waiting for replay new saves go straight to the spool,
+// so the database receives writes in the order they were accepted.
+func Wrap(repo *repository.Repository, spool *Spool, ping func(ctx context.Context) error) *repository.Repository {
+	s := spooler{spool: spool, ping: ping}
+	return &repository.Repository{
+		Orderbook:    &orderBookSpool{Orderbook: repo.Orderbook, spooler: s},
+		Orderhistory: &orderHistorySpool{Orderhistory: repo.Orderhistory, spooler: s},
+	}
+}
+
+type spooler struct {
+	spool *Spool
+	ping  func(ctx context.Context) error
+}
+
+// save writes rec with save, or spools it. The record gets its id first, so
+// that a write which failed on our side but was stored is dropped on replay.
+func (s spooler) save(ctx context.Context, rec *Record, save func(ctx context.Context) error) error {
+	rec.ID = uuid.NewString()
+	spooled, err := s.spool.AppendIfPending(rec)
+	if spooled || err != nil {
+		return err
+	}
+	err = save(repository.WithInsertToken(ctx, rec.ID))
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil || !s.unavailable(ctx, err) {
+		return err
+	}
+	logrus.Warnf("spooling %s write: %s", rec.Kind, err.Error())
+	if spoolErr := s.spool.Append(rec); spoolErr != nil {
+		return err
+	}
+	return nil
+}
+
+// unavailable tells whether err is caused by the database rather than the
+// write, which a replay would only fail again.
+func (s spooler) unavailable(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return s.ping(ctx) != nil
+}
+
+type orderBookSpool struct {
+	repository.Orderbook
+	spooler
+}
+
+func (o *orderBookSpool) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	rec := &Record{Kind: KindOrderBook, Exchange: exchangeName, Pair: pair, OrderBook: asksBids}
+	return o.save(ctx, rec, func(ctx context.Context) error {
+		return o.Orderbook.SaveOrderBook(ctx, exchangeName, pair, asksBids)
+	})
+}
+
+func (o *orderBookSpool) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	rec := &Record{Kind: KindOrderBooks, OrderBooks: orderBooks}
+	return o.save(ctx, rec, func(ctx context.Context) error {
+		return o.Orderbook.SaveOrderBooks(ctx, orderBooks)
+	})
+}
+
+type orderHistorySpool struct {
+	repository.Orderhistory
+	spooler
+}
+
+func (o *orderHistorySpool) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	rec := &Record{Kind: KindOrder, Order: order}
+	return o.save(ctx, rec, func(ctx context.Context) error {
+		return o.Orderhistory.SaveOrder(ctx, order)
+	})
+}
+
+func (o *orderHistorySpool) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	rec := &Record{Kind: KindOrders, Orders: orders}
+	return o.save(ctx, rec, func(ctx context.Context) error {
+		return o.Orderhistory.SaveOrders(ctx, orders)
+	})
+}
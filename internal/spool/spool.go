@@ -0,0 +1,355 @@
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	KindOrder      = "order"
+	KindOrders     = "orders"
+	KindOrderBook  = "orderbook"
+	KindOrderBooks = "orderbooks"
+)
+
+// Record is a spooled write. ID is sent as the insert token of every
+// attempt, the one before the write was spooled included, so the database
+// stores it once however often it is retried.
+type Record struct {
+	ID         string                 `json:"id,omitempty"`
+	Kind       string                 `json:"kind"`
+	Exchange   string                 `json:"exchange,omitempty"`
+	Pair       string                 `json:"pair,omitempty"`
+	Order      *domain.HistoryOrder   `json:"order,omitempty"`
+	Orders     []*domain.HistoryOrder `json:"orders,omitempty"`
+	OrderBook  *domain.AsksBids       `json:"order_book,omitempty"`
+	OrderBooks []*domain.OrderBook    `json:"order_books,omitempty"`
+}
+
+type Stats struct {
+	Records int   `json:"records"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// Spool is a write-ahead log of writes the repository refused. Records are
+// appended to spool.log and replayed from the offset kept in spool.offset,
+// which moves after every stored record. A record stored right before a
+// crash is replayed again after the restart, the database drops it by its
+// insert token. The log is truncated once it is fully replayed.
+type Spool struct {
+	mu          sync.Mutex
+	dir         string
+	log         *os.File
+	offset      int64
+	size        int64
+	records     int
+	maxAttempts int
+	attempts    int
+}
+
+func Open(dir string, maxAttempts int) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.New("failed to create spool dir: " + err.Error())
+	}
+	log, err := os.OpenFile(filepath.Join(dir, "spool.log"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.New("failed to open spool: " + err.Error())
+	}
+	s := &Spool{dir: dir, log: log, maxAttempts: maxAttempts}
+	if s.offset, err = s.readOffset(); err != nil {
+		log.Close()
+		return nil, err
+	}
+	info, err := log.Stat()
+	if err != nil {
+		log.Close()
+		return nil, errors.New("failed to stat spool: " + err.Error())
+	}
+	s.size = info.Size()
+	if s.offset > s.size {
+		s.offset = s.size
+	}
+	end, err := s.countRecords()
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	if end < s.size {
+		// drop a record torn by a crash in the middle of a write
+		if err := log.Truncate(end); err != nil {
+			log.Close()
+			return nil, errors.New("failed to truncate spool: " + err.Error())
+		}
+		s.size = end
+	}
+	return s, nil
+}
+
+func (s *Spool) Close() error {
+	return s.log.Close()
+}
+
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{Records: s.records, Bytes: s.size - s.offset}
+}
+
+// Append persists r. It returns only after the record is synced to disk.
+func (s *Spool) Append(r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(r)
+}
+
+// AppendIfPending appends r only if older records are still waiting for
+// replay, so that writes keep their order while the spool drains.
+func (s *Spool) AppendIfPending(r *Record) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records == 0 {
+		return false, nil
+	}
+	return true, s.append(r)
+}
+
+func (s *Spool) append(r *Record) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return errors.New("failed to encode spool record: " + err.Error())
+	}
+	b = append(b, '\n')
+	if _, err := s.log.Write(b); err != nil {
+		return errors.New("failed to write spool record: " + err.Error())
+	}
+	if err := s.log.Sync(); err != nil {
+		return errors.New("failed to sync spool: " + err.Error())
+	}
+	s.size += int64(len(b))
+	s.records++
+	return nil
+}
+
+// Run replays the spool every interval once ping succeeds.
+func (s *Spool) Run(ctx context.Context, repo *repository.Repository, ping func(ctx context.Context) error, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.Stats().Records == 0 {
+			continue
+		}
+		if err := ping(ctx); err != nil {
+			continue
+		}
+		n, err := s.Replay(ctx, repo)
+		if n > 0 {
+			logrus.Infof("replayed %d spooled writes", n)
+		}
+		if err != nil {
+			logrus.Errorf("failed to replay spool: %s", err.Error())
+		}
+	}
+}
+
+// Replay writes pending records through repo in order and stops at the first
+// failure. A record that fails maxAttempts times in a row is moved to
+// spool.rejected so it can't block the records after it.
+func (s *Spool) Replay(ctx context.Context, repo *repository.Repository) (int, error) {
+	f, err := os.Open(filepath.Join(s.dir, "spool.log"))
+	if err != nil {
+		return 0, errors.New("failed to open spool: " + err.Error())
+	}
+	defer f.Close()
+
+	s.mu.Lock()
+	offset := s.offset
+	s.mu.Unlock()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, errors.New("failed to seek spool: " + err.Error())
+	}
+
+	var replayed int
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			// end of log or a record that is still being written
+			break
+		}
+		if err != nil {
+			return replayed, errors.New("failed to read spool: " + err.Error())
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			if err := s.reject(line, errors.New("invalid spool record: "+err.Error())); err != nil {
+				return replayed, err
+			}
+		} else if err := write(ctx, repo, &rec); err != nil {
+			if ctx.Err() != nil {
+				// stopped, not a failure of the record
+				return replayed, err
+			}
+			s.mu.Lock()
+			s.attempts++
+			attempts := s.attempts
+			s.mu.Unlock()
+			if s.maxAttempts <= 0 || attempts < s.maxAttempts {
+				return replayed, err
+			}
+			if err := s.reject(line, err); err != nil {
+				return replayed, err
+			}
+		} else {
+			replayed++
+		}
+		offset += int64(len(line))
+		if err := s.commit(offset); err != nil {
+			return replayed, err
+		}
+	}
+	return replayed, s.truncateIfDrained()
+}
+
+func write(ctx context.Context, repo *repository.Repository, rec *Record) error {
+	if rec.ID != "" {
+		ctx = repository.WithInsertToken(ctx, rec.ID)
+	}
+	switch rec.Kind {
+	case KindOrder:
+		return repo.SaveOrder(ctx, rec.Order)
+	case KindOrders:
+		return repo.SaveOrders(ctx, rec.Orders)
+	case KindOrderBook:
+		return repo.SaveOrderBook(ctx, rec.Exchange, rec.Pair, rec.OrderBook)
+	case KindOrderBooks:
+		return repo.SaveOrderBooks(ctx, rec.OrderBooks)
+	default:
+		return errors.New("unknown spool record kind: " + rec.Kind)
+	}
+}
+
+func (s *Spool) commit(offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset = offset
+	s.records--
+	s.attempts = 0
+	return s.writeOffset()
+}
+
+func (s *Spool) reject(line []byte, reason error) error {
+	logrus.Errorf("rejecting spooled write: %s", reason.Error())
+	f, err := os.OpenFile(filepath.Join(s.dir, "spool.rejected"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.New("failed to open spool rejected file: " + err.Error())
+	}
+	defer f.Close()
+	if _, err := f.Write(line); err != nil {
+		return errors.New("failed to write spool rejected file: " + err.Error())
+	}
+	return f.Sync()
+}
+
+func (s *Spool) truncateIfDrained() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.offset != s.size {
+		return nil
+	}
+	if err := s.log.Truncate(0); err != nil {
+		return errors.New("failed to truncate spool: " + err.Error())
+	}
+	s.offset, s.size, s.records = 0, 0, 0
+	return s.writeOffset()
+}
+
+func (s *Spool) readOffset() (int64, error) {
+	b, err := os.ReadFile(filepath.Join(s.dir, "spool.offset"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.New("failed to read spool offset: " + err.Error())
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid spool offset: " + err.Error())
+	}
+	return offset, nil
+}
+
+func (s *Spool) writeOffset() error {
+	path := filepath.Join(s.dir, "spool.offset")
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return errors.New("failed to write spool offset: " + err.Error())
+	}
+	if _, err := tmp.WriteString(strconv.FormatInt(s.offset, 10)); err != nil {
+		tmp.Close()
+		return errors.New("failed to write spool offset: " + err.Error())
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.New("failed to sync spool offset: " + err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.New("failed to write spool offset: " + err.Error())
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return errors.New("failed to write spool offset: " + err.Error())
+	}
+	return nil
+}
+
+// countRecords counts the records after the replay offset and returns where
+// the last complete record ends.
+func (s *Spool) countRecords() (int64, error) {
+	f, err := os.Open(filepath.Join(s.dir, "spool.log"))
+	if err != nil {
+		return 0, errors.New("failed to open spool: " + err.Error())
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
+		return 0, errors.New("failed to seek spool: " + err.Error())
+	}
+	end := s.offset
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			s.records++
+			end += int64(len(line))
+		}
+		if err == io.EOF {
+			return end, nil
+		}
+		if err != nil {
+			return 0, errors.New("failed to read spool: " + err.Error())
+		}
+	}
+}
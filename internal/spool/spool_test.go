@@ -0,0 +1,227 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func testOrder(price float64) *domain.HistoryOrder {
+	return &domain.HistoryOrder{
+		Client: domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "test", Pair: "BTCUSDT"},
+		Side:   "buy",
+		Price:  price,
+	}
+}
+
+func newRepository(t *testing.T) (*repository.Repository, *mock_repository.MockOrderbook, *mock_repository.MockOrderhistory) {
+	c := gomock.NewController(t)
+	orderBook := mock_repository.NewMockOrderbook(c)
+	orderHistory := mock_repository.NewMockOrderhistory(c)
+	return &repository.Repository{Orderbook: orderBook, Orderhistory: orderHistory}, orderBook, orderHistory
+}
+
+func up(context.Context) error { return nil }
+
+func down(context.Context) error { return errors.New("connection refused") }
+
+func TestSpool_SpoolsAndReplaysInOrder(t *testing.T) {
+	dir := t.TempDir()
+	sp, err := Open(dir, 0)
+	require.NoError(t, err)
+	repo, orderBook, orderHistory := newRepository(t)
+	wrapped := Wrap(repo, sp, down)
+
+	orderHistory.EXPECT().SaveOrder(gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+	require.NoError(t, wrapped.SaveOrder(context.Background(), testOrder(1)))
+	// the database is back, but older writes are still pending
+	require.NoError(t, wrapped.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &domain.AsksBids{Id: 7}))
+	require.NoError(t, wrapped.SaveOrder(context.Background(), testOrder(2)))
+	assert.Equal(t, 3, sp.Stats().Records)
+	assert.NotZero(t, sp.Stats().Bytes)
+
+	gomock.InOrder(
+		orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(1)).Return(nil),
+		orderBook.EXPECT().SaveOrderBook(gomock.Any(), "binance", "BTCUSDT", &domain.AsksBids{Id: 7}).Return(nil),
+		orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(2)).Return(nil),
+	)
+	n, err := sp.Replay(context.Background(), repo)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, Stats{}, sp.Stats())
+
+	orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(3)).Return(nil)
+	require.NoError(t, wrapped.SaveOrder(context.Background(), testOrder(3)))
+	assert.Equal(t, 0, sp.Stats().Records)
+}
+
+func TestSpool_ResumesWithoutDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	sp, err := Open(dir, 0)
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, sp.Append(&Record{Kind: KindOrder, Order: testOrder(float64(i))}))
+	}
+
+	repo, _, orderHistory := newRepository(t)
+	gomock.InOrder(
+		orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(1)).Return(nil),
+		orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(2)).Return(errors.New("connection refused")),
+	)
+	n, err := sp.Replay(context.Background(), repo)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, sp.Close())
+
+	// simulate a crash in the middle of an append
+	f, err := os.OpenFile(filepath.Join(dir, "spool.log"), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"kind":"order","ord`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	sp, err = Open(dir, 0)
+	require.NoError(t, err)
+	defer sp.Close()
+	assert.Equal(t, 2, sp.Stats().Records)
+
+	repo, _, orderHistory = newRepository(t)
+	gomock.InOrder(
+		orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(2)).Return(nil),
+		orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(3)).Return(nil),
+	)
+	n, err = sp.Replay(context.Background(), repo)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, Stats{}, sp.Stats())
+}
+
+func TestSpool_RejectsAfterMaxAttempts(t *testing.T) {
+	dir := t.TempDir()
+	sp, err := Open(dir, 2)
+	require.NoError(t, err)
+	defer sp.Close()
+	require.NoError(t, sp.Append(&Record{Kind: KindOrders, Orders: []*domain.HistoryOrder{testOrder(1)}}))
+	require.NoError(t, sp.Append(&Record{Kind: KindOrder, Order: testOrder(2)}))
+
+	repo, _, orderHistory := newRepository(t)
+	orderHistory.EXPECT().SaveOrders(gomock.Any(), gomock.Any()).Return(errors.New("type mismatch")).Times(2)
+	orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(2)).Return(nil)
+
+	_, err = sp.Replay(context.Background(), repo)
+	assert.Error(t, err)
+	n, err := sp.Replay(context.Background(), repo)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	rejected, err := os.ReadFile(filepath.Join(dir, "spool.rejected"))
+	require.NoError(t, err)
+	assert.Contains(t, string(rejected), `"kind":"orders"`)
+}
+
+func TestSpool_ReturnsWriteErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		saveErr       error
+		expectedError error
+	}{
+		{
+			name:          "Invalid write",
+			ctx:           context.Background(),
+			saveErr:       errors.New("failed to save order: type mismatch"),
+			expectedError: errors.New("failed to save order: type mismatch"),
+		},
+		{
+			name:          "Canceled request",
+			ctx:           canceled,
+			saveErr:       context.Canceled,
+			expectedError: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, err := Open(t.TempDir(), 0)
+			require.NoError(t, err)
+			defer sp.Close()
+			repo, _, orderHistory := newRepository(t)
+
+			// the database answers pings, so the write itself is at fault
+			orderHistory.EXPECT().SaveOrder(gomock.Any(), testOrder(1)).Return(tt.saveErr)
+			err = Wrap(repo, sp, up).SaveOrder(tt.ctx, testOrder(1))
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, Stats{}, sp.Stats())
+		})
+	}
+}
+
+// lostAck stores orders and then fails, like a write whose response was lost
+// to a client timeout or a crash after the database stored it.
+type lostAck struct {
+	repository.Orderhistory
+}
+
+func (l lostAck) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	if err := l.Orderhistory.SaveOrder(ctx, order); err != nil {
+		return err
+	}
+	return context.DeadlineExceeded
+}
+
+func TestSpool_ReplaysStoredWriteOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		spool func(t *testing.T, sp *Spool, failing *repository.Repository)
+	}{
+		{
+			name: "crash between write and commit",
+			spool: func(t *testing.T, sp *Spool, failing *repository.Repository) {
+				require.NoError(t, sp.Append(&Record{Kind: KindOrder, Order: testOrder(1)}))
+				// the write is stored, but the offset never moves
+				_, err := sp.Replay(context.Background(), failing)
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "timeout of a stored direct write",
+			spool: func(t *testing.T, sp *Spool, failing *repository.Repository) {
+				require.NoError(t, Wrap(failing, sp, up).SaveOrder(context.Background(), testOrder(1)))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := repository.NewMemoryRepository(repository.NewMemory())
+			failing := &repository.Repository{Orderbook: stored.Orderbook, Orderhistory: lostAck{stored.Orderhistory}}
+
+			dir := t.TempDir()
+			sp, err := Open(dir, 0)
+			require.NoError(t, err)
+			tt.spool(t, sp, failing)
+			require.NoError(t, sp.Close())
+
+			sp, err = Open(dir, 0)
+			require.NoError(t, err)
+			defer sp.Close()
+			n, err := sp.Replay(context.Background(), stored)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+
+			orders, err := stored.GetOrderHistory(context.Background(), &testOrder(1).Client)
+			require.NoError(t, err)
+			assert.Len(t, orders, 1)
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+
+	TypeSnapshot = "snapshot"
+	TypeUpdate   = "update"
+	TypeError    = "error"
+)
+
+const maxRequestSize = 64 << 10
+
+type Book struct {
+	Exchange string `json:"exchange"`
+	Pair     string `json:"pair"`
+}
+
+// Request is sent by clients to change their subscriptions.
+type Request struct {
+	Action string `json:"action"`
+	Books  []Book `json:"books"`
+}
+
+// Message is sent to clients: a snapshot of every subscribed book followed
+// by an update for each saved order book.
+type Message struct {
+	Type      string           `json:"type"`
+	Exchange  string           `json:"exchange,omitempty"`
+	Pair      string           `json:"pair,omitempty"`
+	OrderBook *domain.AsksBids `json:"order_book,omitempty"`
+	Message   string           `json:"message,omitempty"`
+}
+
+// OrderBooks streams live order books over websocket connections. Every
+// connection has its own send buffer, a connection that lets it fill up is
+// disconnected instead of slowing down the publishers.
+type OrderBooks struct {
+	repo     repository.Orderbook
+	cfg      *config.Stream
+	upgrader websocket.Upgrader
+
+	mu   sync.RWMutex
+	subs map[Book]map[*bookConn]struct{}
+}
+
+func NewOrderBooks(repo repository.Orderbook, cfg *config.Stream) *OrderBooks {
+	return &OrderBooks{
+		repo: repo,
+		cfg:  cfg,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		subs: make(map[Book]map[*bookConn]struct{}),
+	}
+}
+
+// Handle forwards saved order books to their subscribers. It is meant to be
+// subscribed to the event bus and never blocks.
+func (o *OrderBooks) Handle(e event.Event) {
+	if e.Type != event.TypeOrderBookSaved {
+		return
+	}
+	book := Book{Exchange: e.Exchange, Pair: e.Pair}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if len(o.subs[book]) == 0 {
+		return
+	}
+	msg, err := json.Marshal(Message{Type: TypeUpdate, Exchange: e.Exchange, Pair: e.Pair, OrderBook: e.OrderBook})
+	if err != nil {
+		logrus.Errorf("failed to encode order book update: %s", err.Error())
+		return
+	}
+	for c := range o.subs[book] {
+		c.deliver(book, msg)
+	}
+}
+
+// ServeHTTP upgrades the request to a websocket. Books can be subscribed to
+// right away with book=<exchange>:<pair> query parameters.
+func (o *OrderBooks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var books []Book
+	for _, v := range r.URL.Query()["book"] {
+		exchange, pair, ok := strings.Cut(v, ":")
+		if !ok || exchange == "" || pair == "" {
+			http.Error(w, "invalid book: "+v, http.StatusBadRequest)
+			return
+		}
+		books = append(books, Book{Exchange: exchange, Pair: pair})
+	}
+	ws, err := o.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &bookConn{
+		hub:   o,
+		ws:    ws,
+		addr:  r.RemoteAddr,
+		send:  make(chan []byte, max(o.cfg.SendBuffer, 1)),
+		done:  make(chan struct{}),
+		books: make(map[Book]*subscription),
+	}
+	go c.writer()
+	if len(books) > 0 {
+		c.subscribe(books)
+	}
+	c.reader()
+}
+
+func (o *OrderBooks) add(c *bookConn, book Book) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.subs[book] == nil {
+		o.subs[book] = make(map[*bookConn]struct{})
+	}
+	o.subs[book][c] = struct{}{}
+}
+
+func (o *OrderBooks) remove(c *bookConn, book Book) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.subs[book], c)
+	if len(o.subs[book]) == 0 {
+		delete(o.subs, book)
+	}
+}
+
+// subscription holds updates that arrive before the snapshot of the book was
+// queued, they are sent right after it.
+type subscription struct {
+	pending bool
+	queue   [][]byte
+}
+
+type bookConn struct {
+	hub  *OrderBooks
+	ws   *websocket.Conn
+	addr string
+	send chan []byte
+
+	closeOnce   sync.Once
+	done        chan struct{}
+	closeReason string
+
+	mu    sync.Mutex
+	books map[Book]*subscription
+}
+
+func (c *bookConn) reader() {
+	defer c.close("")
+	c.ws.SetReadLimit(maxRequestSize)
+	c.ws.SetReadDeadline(time.Now().Add(c.pongWait()))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(c.pongWait()))
+	})
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.sendError("invalid request: " + err.Error())
+			continue
+		}
+		switch req.Action {
+		case ActionSubscribe:
+			c.subscribe(req.Books)
+		case ActionUnsubscribe:
+			c.unsubscribe(req.Books)
+		default:
+			c.sendError("unknown action: " + req.Action)
+		}
+	}
+}
+
+func (c *bookConn) writer() {
+	ticker := time.NewTicker(c.pingInterval())
+	defer func() {
+		ticker.Stop()
+		c.ws.Close()
+		c.unsubscribeAll()
+	}()
+	for {
+		select {
+		case msg := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(c.writeTimeout()))
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.close("")
+				return
+			}
+		case <-ticker.C:
+			c.ws.SetWriteDeadline(time.Now().Add(c.writeTimeout()))
+			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close("")
+				return
+			}
+		case <-c.done:
+			if c.closeReason != "" {
+				c.ws.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, c.closeReason),
+					time.Now().Add(c.writeTimeout()))
+			}
+			return
+		}
+	}
+}
+
+func (c *bookConn) subscribe(books []Book) {
+	for _, book := range books {
+		if book.Exchange == "" || book.Pair == "" {
+			c.sendError("exchange and pair are required")
+			continue
+		}
+		c.mu.Lock()
+		if _, ok := c.books[book]; ok {
+			c.mu.Unlock()
+			continue
+		}
+		if limit := c.hub.cfg.MaxSubscriptions; limit > 0 && len(c.books) >= limit {
+			c.mu.Unlock()
+			c.sendError("too many subscriptions")
+			return
+		}
+		c.books[book] = &subscription{pending: true}
+		c.mu.Unlock()
+		c.hub.add(c, book)
+		select {
+		case <-c.done:
+			// the writer may have already dropped the other subscriptions
+			c.unsubscribe([]Book{book})
+			return
+		default:
+		}
+
+		snapshot := Message{Type: TypeSnapshot, Exchange: book.Exchange, Pair: book.Pair}
+		orderBook, err := c.hub.repo.GetOrderBook(context.Background(), book.Exchange, book.Pair)
+		if errors.Is(err, repository.ErrNotFound) {
+			// an empty snapshot, the first save will be sent as update
+			orderBook, err = nil, nil
+		}
+		if err != nil {
+			logrus.Errorf("failed to get order book snapshot: %s", err.Error())
+			snapshot = Message{Type: TypeError, Exchange: book.Exchange, Pair: book.Pair, Message: "failed to get order book snapshot"}
+		} else {
+			snapshot.OrderBook = orderBook
+		}
+		msg, err := json.Marshal(snapshot)
+		if err != nil {
+			logrus.Errorf("failed to encode order book snapshot: %s", err.Error())
+			continue
+		}
+
+		c.mu.Lock()
+		sub, ok := c.books[book]
+		if ok {
+			c.enqueue(msg)
+			for _, update := range sub.queue {
+				c.enqueue(update)
+			}
+			sub.pending = false
+			sub.queue = nil
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *bookConn) unsubscribe(books []Book) {
+	for _, book := range books {
+		c.mu.Lock()
+		delete(c.books, book)
+		c.mu.Unlock()
+		c.hub.remove(c, book)
+	}
+}
+
+func (c *bookConn) unsubscribeAll() {
+	c.mu.Lock()
+	books := make([]Book, 0, len(c.books))
+	for book := range c.books {
+		books = append(books, book)
+	}
+	c.mu.Unlock()
+	c.unsubscribe(books)
+}
+
+func (c *bookConn) deliver(book Book, msg []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sub, ok := c.books[book]
+	if !ok {
+		return
+	}
+	if sub.pending {
+		if len(sub.queue) >= cap(c.send) {
+			c.close("slow consumer")
+			return
+		}
+		sub.queue = append(sub.queue, msg)
+		return
+	}
+	c.enqueue(msg)
+}
+
+func (c *bookConn) sendError(message string) {
+	msg, err := json.Marshal(Message{Type: TypeError, Message: message})
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enqueue(msg)
+}
+
+// enqueue must be called with c.mu held.
+func (c *bookConn) enqueue(msg []byte) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.close("slow consumer")
+	}
+}
+
+func (c *bookConn) close(reason string) {
+	c.closeOnce.Do(func() {
+		c.closeReason = reason
+		close(c.done)
+		if reason != "" {
+			logrus.Warnf("closing order book stream %s: %s", c.addr, reason)
+		}
+	})
+}
+
+func (c *bookConn) pingInterval() time.Duration {
+	if c.hub.cfg.PingInterval > 0 {
+		return c.hub.cfg.PingInterval
+	}
+	return 30 * time.Second
+}
+
+func (c *bookConn) pongWait() time.Duration {
+	if c.hub.cfg.PongWait > 0 {
+		return c.hub.cfg.PongWait
+	}
+	return 2 * c.pingInterval()
+}
+
+func (c *bookConn) writeTimeout() time.Duration {
+	if c.hub.cfg.WriteTimeout > 0 {
+		return c.hub.cfg.WriteTimeout
+	}
+	return 10 * time.Second
+}
@@ -0,0 +1,90 @@
+package stream
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	mock_repository "github.com/kolibriee/trade-metrics/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func readMessage(t *testing.T, ws *websocket.Conn) Message {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	var msg Message
+	require.NoError(t, ws.ReadJSON(&msg))
+	return msg
+}
+
+func TestOrderBooks(t *testing.T) {
+	c := gomock.NewController(t)
+	repo := mock_repository.NewMockOrderbook(c)
+	repo.EXPECT().GetOrderBook(gomock.Any(), "binance", "BTCUSDT").Return(&domain.AsksBids{Id: 1}, nil)
+	repo.EXPECT().GetOrderBook(gomock.Any(), "binance", "ETHUSDT").Return(&domain.AsksBids{Id: 2}, nil)
+
+	hub := NewOrderBooks(repo, &config.Stream{SendBuffer: 16})
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"?book=binance:BTCUSDT", nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	msg := readMessage(t, ws)
+	assert.Equal(t, TypeSnapshot, msg.Type)
+	assert.Equal(t, uint32(1), msg.OrderBook.Id)
+
+	require.NoError(t, ws.WriteJSON(Request{Action: ActionSubscribe, Books: []Book{{Exchange: "binance", Pair: "ETHUSDT"}}}))
+	msg = readMessage(t, ws)
+	assert.Equal(t, TypeSnapshot, msg.Type)
+	assert.Equal(t, "ETHUSDT", msg.Pair)
+
+	hub.Handle(event.OrderBookSaved("binance", "SOLUSDT", &domain.AsksBids{Id: 3}))
+	hub.Handle(event.OrderBookSaved("binance", "ETHUSDT", &domain.AsksBids{Id: 4}))
+	msg = readMessage(t, ws)
+	assert.Equal(t, TypeUpdate, msg.Type)
+	assert.Equal(t, uint32(4), msg.OrderBook.Id)
+
+	require.NoError(t, ws.WriteJSON(Request{Action: ActionUnsubscribe, Books: []Book{{Exchange: "binance", Pair: "ETHUSDT"}}}))
+	require.NoError(t, ws.WriteJSON(Request{Action: "replace"}))
+	msg = readMessage(t, ws)
+	assert.Equal(t, TypeError, msg.Type)
+	assert.Equal(t, "unknown action: replace", msg.Message)
+
+	hub.Handle(event.OrderBookSaved("binance", "ETHUSDT", &domain.AsksBids{Id: 5}))
+	hub.Handle(event.OrderBookSaved("binance", "BTCUSDT", &domain.AsksBids{Id: 6}))
+	msg = readMessage(t, ws)
+	assert.Equal(t, uint32(6), msg.OrderBook.Id)
+}
+
+func TestOrderBooks_SlowConsumer(t *testing.T) {
+	hub := NewOrderBooks(nil, &config.Stream{})
+	book := Book{Exchange: "binance", Pair: "BTCUSDT"}
+	c := &bookConn{
+		hub:   hub,
+		send:  make(chan []byte, 1),
+		done:  make(chan struct{}),
+		books: map[Book]*subscription{book: {}},
+	}
+	c.deliver(book, []byte("1"))
+	select {
+	case <-c.done:
+		t.Fatal("connection closed with room in its buffer")
+	default:
+	}
+	c.deliver(book, []byte("2"))
+	select {
+	case <-c.done:
+		assert.Equal(t, "slow consumer", c.closeReason)
+	default:
+		t.Fatal("slow consumer was not disconnected")
+	}
+}
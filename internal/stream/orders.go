@@ -0,0 +1,204 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/sirupsen/logrus"
+)
+
+type OrderFilter struct {
+	ClientName string
+	Exchange   string
+	Label      string
+	Pair       string
+}
+
+func (f *OrderFilter) Matches(order *domain.HistoryOrder) bool {
+	return (f.ClientName == "" || f.ClientName == order.Client.ClientName) &&
+		(f.Exchange == "" || f.Exchange == order.Client.ExchangeName) &&
+		(f.Label == "" || f.Label == order.Client.Label) &&
+		(f.Pair == "" || f.Pair == order.Client.Pair)
+}
+
+type orderEvent struct {
+	id    uint64
+	order *domain.HistoryOrder
+	data  []byte
+}
+
+// Orders is a Server-Sent Events feed of saved orders. The last ReplayBuffer
+// orders are kept in memory so that a client reconnecting with Last-Event-ID
+// receives the orders it missed.
+type Orders struct {
+	cfg *config.Stream
+
+	mu     sync.Mutex
+	seq    uint64
+	buffer []orderEvent
+	start  int
+	subs   map[*orderSub]struct{}
+}
+
+type orderSub struct {
+	filter    OrderFilter
+	send      chan orderEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *orderSub) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func NewOrders(cfg *config.Stream) *Orders {
+	return &Orders{
+		cfg:    cfg,
+		buffer: make([]orderEvent, 0, max(cfg.ReplayBuffer, 1)),
+		subs:   make(map[*orderSub]struct{}),
+	}
+}
+
+// Handle adds saved orders to the replay buffer and sends them to the
+// matching clients. It is meant to be subscribed to the event bus and never
+// blocks, a client whose buffer is full is disconnected.
+func (o *Orders) Handle(e event.Event) {
+	if e.Type != event.TypeOrderSaved {
+		return
+	}
+	data, err := json.Marshal(e.Order)
+	if err != nil {
+		logrus.Errorf("failed to encode order: %s", err.Error())
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.seq++
+	ev := orderEvent{id: o.seq, order: e.Order, data: data}
+	if len(o.buffer) < cap(o.buffer) {
+		o.buffer = append(o.buffer, ev)
+	} else {
+		o.buffer[o.start] = ev
+		o.start = (o.start + 1) % len(o.buffer)
+	}
+	for sub := range o.subs {
+		if !sub.filter.Matches(ev.order) {
+			continue
+		}
+		select {
+		case sub.send <- ev:
+		default:
+			logrus.Warn("closing order stream: slow consumer")
+			delete(o.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// ServeHTTP streams orders matching the client, exchange, label and pair
+// query parameters, starting after Last-Event-ID when it is set. Streams
+// outlive the server's write timeout, which is lifted.
+func (o *Orders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	filter := OrderFilter{
+		ClientName: query.Get("client"),
+		Exchange:   query.Get("exchange"),
+		Label:      query.Get("label"),
+		Pair:       query.Get("pair"),
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("lastEventId")
+	}
+	var (
+		last   uint64
+		resume = lastEventID != ""
+	)
+	if resume {
+		var err error
+		if last, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	sub := &orderSub{
+		filter: filter,
+		send:   make(chan orderEvent, max(o.cfg.SendBuffer, 1)),
+		done:   make(chan struct{}),
+	}
+	o.mu.Lock()
+	var backlog []orderEvent
+	if resume {
+		if last > o.seq {
+			// ids restarted with the process, everything buffered is new
+			last = 0
+		}
+		for i := range o.buffer {
+			ev := o.buffer[(o.start+i)%len(o.buffer)]
+			if ev.id > last && filter.Matches(ev.order) {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+	o.subs[sub] = struct{}{}
+	o.mu.Unlock()
+	defer func() {
+		o.mu.Lock()
+		delete(o.subs, sub)
+		o.mu.Unlock()
+	}()
+
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	for _, ev := range backlog {
+		if err := writeOrderEvent(w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	interval := o.cfg.PingInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.done:
+			return
+		case ev := <-sub.send:
+			if err := writeOrderEvent(w, ev); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeOrderEvent(w http.ResponseWriter, ev orderEvent) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.id, event.TypeOrderSaved, ev.data)
+	return err
+}
@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOrder(client, pair string, price float64) *domain.HistoryOrder {
+	return &domain.HistoryOrder{
+		Client: domain.Client{ClientName: client, ExchangeName: "binance", Label: "test", Pair: pair},
+		Side:   "buy",
+		Price:  price,
+	}
+}
+
+// readEvent returns the id and data lines of the next event, skipping
+// comments.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var id, data string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && id != "":
+			return id, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestOrders(t *testing.T) {
+	orders := NewOrders(&config.Stream{SendBuffer: 16, ReplayBuffer: 3, PingInterval: 10 * time.Millisecond})
+	srv := httptest.NewServer(orders)
+	defer srv.Close()
+
+	orders.Handle(event.OrderSaved(testOrder("Misha", "BTCUSDT", 1)))
+	orders.Handle(event.OrderSaved(testOrder("Misha", "BTCUSDT", 2)))
+	orders.Handle(event.OrderSaved(testOrder("Sasha", "BTCUSDT", 3)))
+	orders.Handle(event.OrderSaved(testOrder("Misha", "ETHUSDT", 4)))
+	orders.Handle(event.OrderBookSaved("binance", "BTCUSDT", &domain.AsksBids{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"?client=Misha", nil)
+	require.NoError(t, err)
+	// the first order is no longer buffered
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	r := bufio.NewReader(resp.Body)
+
+	id, data := readEvent(t, r)
+	assert.Equal(t, "2", id)
+	assert.Contains(t, data, `"price":2`)
+	id, _ = readEvent(t, r)
+	assert.Equal(t, "4", id)
+
+	orders.Handle(event.OrderSaved(testOrder("Sasha", "ETHUSDT", 5)))
+	orders.Handle(event.OrderSaved(testOrder("Misha", "ETHUSDT", 6)))
+	id, data = readEvent(t, r)
+	assert.Equal(t, "6", id)
+	assert.Contains(t, data, `"client_name":"Misha"`)
+}
+
+func TestOrderFilter(t *testing.T) {
+	order := testOrder("Misha", "BTCUSDT", 1)
+	tests := []struct {
+		name     string
+		filter   OrderFilter
+		expected bool
+	}{
+		{name: "Empty", filter: OrderFilter{}, expected: true},
+		{name: "All fields", filter: OrderFilter{ClientName: "Misha", Exchange: "binance", Label: "test", Pair: "BTCUSDT"}, expected: true},
+		{name: "Other label", filter: OrderFilter{ClientName: "Misha", Label: "prod"}, expected: false},
+		{name: "Other pair", filter: OrderFilter{Pair: "ETHUSDT"}, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Matches(order))
+		})
+	}
+}
+
+func TestOrders_OutlivesWriteTimeout(t *testing.T) {
+	orders := NewOrders(&config.Stream{SendBuffer: 16, ReplayBuffer: 3, PingInterval: time.Hour})
+	srv := httptest.NewUnstartedServer(orders)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	r := bufio.NewReader(resp.Body)
+
+	time.Sleep(200 * time.Millisecond)
+	orders.Handle(event.OrderSaved(testOrder("Misha", "BTCUSDT", 1)))
+	id, _ := readEvent(t, r)
+	assert.Equal(t, "1", id)
+}
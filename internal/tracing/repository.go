@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/kolibriee/trade-metrics/internal/tracing"
+
+// rowsKey is the number of rows read or written by a repository method.
+const rowsKey = attribute.Key("db.rows")
+
+// Instrument wraps repo so that every operation runs in a span of its own
+// with the backend and the number of rows. The ClickHouse and SQL
+// repositories add the query text to it.
+func Instrument(repo *repository.Repository, backend string) *repository.Repository {
+	return &repository.Repository{
+		Orderbook:    &orderBookTracing{Orderbook: repo.Orderbook, backend: backend},
+		Orderhistory: &orderHistoryTracing{Orderhistory: repo.Orderhistory, backend: backend},
+	}
+}
+
+func start(ctx context.Context, method, backend string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "repository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemKey.String(backend)))
+}
+
+func end(span trace.Span, rows int, err error) {
+	span.SetAttributes(rowsKey.Int(rows))
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type orderBookTracing struct {
+	repository.Orderbook
+	backend string
+}
+
+func (o *orderBookTracing) GetOrderBook(ctx context.Context, exchangeName, pair string) (*domain.AsksBids, error) {
+	ctx, span := start(ctx, "GetOrderBook", o.backend)
+	orderBook, err := o.Orderbook.GetOrderBook(ctx, exchangeName, pair)
+	rows := 0
+	if orderBook != nil {
+		rows = 1
+	}
+	end(span, rows, err)
+	return orderBook, err
+}
+
+func (o *orderBookTracing) SaveOrderBook(ctx context.Context, exchangeName, pair string, asksBids *domain.AsksBids) error {
+	ctx, span := start(ctx, "SaveOrderBook", o.backend)
+	err := o.Orderbook.SaveOrderBook(ctx, exchangeName, pair, asksBids)
+	end(span, 1, err)
+	return err
+}
+
+func (o *orderBookTracing) SaveOrderBooks(ctx context.Context, orderBooks []*domain.OrderBook) error {
+	ctx, span := start(ctx, "SaveOrderBooks", o.backend)
+	err := o.Orderbook.SaveOrderBooks(ctx, orderBooks)
+	end(span, len(orderBooks), err)
+	return err
+}
+
+func (o *orderBookTracing) ExportOrderBooks(ctx context.Context, exchangeName, pair string, fn func(orderBook *domain.OrderBook) error) error {
+	ctx, span := start(ctx, "ExportOrderBooks", o.backend)
+	rows := 0
+	err := o.Orderbook.ExportOrderBooks(ctx, exchangeName, pair, func(orderBook *domain.OrderBook) error {
+		rows++
+		return fn(orderBook)
+	})
+	end(span, rows, err)
+	return err
+}
+
+type orderHistoryTracing struct {
+	repository.Orderhistory
+	backend string
+}
+
+func (o *orderHistoryTracing) GetOrderHistory(ctx context.Context, client *domain.Client) ([]*domain.HistoryOrder, error) {
+	ctx, span := start(ctx, "GetOrderHistory", o.backend)
+	orders, err := o.Orderhistory.GetOrderHistory(ctx, client)
+	end(span, len(orders), err)
+	return orders, err
+}
+
+func (o *orderHistoryTracing) GetOrderHistories(ctx context.Context, clients []*domain.Client, limit int) ([]*domain.HistoryOrder, error) {
+	ctx, span := start(ctx, "GetOrderHistories", o.backend)
+	orders, err := o.Orderhistory.GetOrderHistories(ctx, clients, limit)
+	end(span, len(orders), err)
+	return orders, err
+}
+
+func (o *orderHistoryTracing) SaveOrder(ctx context.Context, order *domain.HistoryOrder) error {
+	ctx, span := start(ctx, "SaveOrder", o.backend)
+	err := o.Orderhistory.SaveOrder(ctx, order)
+	end(span, 1, err)
+	return err
+}
+
+func (o *orderHistoryTracing) SaveOrders(ctx context.Context, orders []*domain.HistoryOrder) error {
+	ctx, span := start(ctx, "SaveOrders", o.backend)
+	err := o.Orderhistory.SaveOrders(ctx, orders)
+	end(span, len(orders), err)
+	return err
+}
+
+func (o *orderHistoryTracing) ExportOrders(ctx context.Context, filter *domain.Client, fn func(order *domain.HistoryOrder) error) error {
+	ctx, span := start(ctx, "ExportOrders", o.backend)
+	rows := 0
+	err := o.Orderhistory.ExportOrders(ctx, filter, func(order *domain.HistoryOrder) error {
+		rows++
+		return fn(order)
+	})
+	end(span, rows, err)
+	return err
+}
@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/migrate"
+	"github.com/kolibriee/trade-metrics/internal/repository"
+	"github.com/kolibriee/trade-metrics/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	res := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		res[kv.Key] = kv.Value
+	}
+	return res
+}
+
+func TestInstrument(t *testing.T) {
+	recorder := newRecorder(t)
+	db, err := repository.NewSQLiteDB(&config.SQLite{Path: filepath.Join(t.TempDir(), "test.db")})
+	require.NoError(t, err)
+	defer db.Close()
+	list, err := migrate.Load(migrations.FS, "sqlite")
+	require.NoError(t, err)
+	_, err = migrate.New(migrate.NewSQLite(db.DB), list).Up(context.Background())
+	require.NoError(t, err)
+	repo := Instrument(repository.NewSQLRepository(db), repository.BackendSQLite)
+
+	ctx := context.Background()
+	client := domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "main", Pair: "BTCUSDT"}
+	order := &domain.HistoryOrder{Client: client, Side: "buy", Type: "limit", BaseQty: 1, Price: 50000, TimePlaced: time.Now()}
+	require.NoError(t, repo.SaveOrders(ctx, []*domain.HistoryOrder{order, order}))
+	orders, err := repo.GetOrderHistory(ctx, &client)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	_, err = repo.GetOrderBook(ctx, "binance", "BTCUSDT")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	names := []string{spans[0].Name(), spans[1].Name(), spans[2].Name()}
+	assert.Equal(t, []string{"repository.SaveOrders", "repository.GetOrderHistory", "repository.GetOrderBook"}, names)
+	for i, rows := range []int64{2, 2, 0} {
+		attrs := attributes(spans[i])
+		assert.Equal(t, "sqlite", attrs["db.system"].AsString())
+		assert.Equal(t, rows, attrs["db.rows"].AsInt64())
+		assert.NotEmpty(t, attrs["db.query.text"].AsString())
+		assert.Equal(t, codes.Unset, spans[i].Status().Code)
+	}
+	assert.Contains(t, attributes(spans[1])["db.query.text"].AsString(), "FROM order_history")
+}
+
+func TestInstrument_Error(t *testing.T) {
+	recorder := newRecorder(t)
+	db, err := repository.NewSQLiteDB(&config.SQLite{Path: filepath.Join(t.TempDir(), "test.db")})
+	require.NoError(t, err)
+	defer db.Close()
+	repo := Instrument(repository.NewSQLRepository(db), repository.BackendSQLite)
+
+	_, err = repo.GetOrderHistory(context.Background(), &domain.Client{})
+	require.Error(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Len(t, spans[0].Events(), 1)
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := newRecorder(t)
+	repo := Instrument(repository.NewMemoryRepository(repository.NewMemory()), repository.BackendMemory)
+	r := gin.New()
+	r.Use(Middleware("trade-metrics"))
+	r.GET("/orderhistory/", func(c *gin.Context) {
+		repo.GetOrderHistory(c.Request.Context(), &domain.Client{})
+		c.Status(200)
+	})
+	req := httptest.NewRequest("GET", "/orderhistory/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	repoSpan, requestSpan := spans[0], spans[1]
+	assert.Equal(t, "/orderhistory/", requestSpan.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requestSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", requestSpan.Parent().SpanID().String())
+	assert.Equal(t, requestSpan.SpanContext().SpanID(), repoSpan.Parent().SpanID())
+	assert.Equal(t, requestSpan.SpanContext().TraceID(), repoSpan.SpanContext().TraceID())
+}
+
+func TestNew(t *testing.T) {
+	provider, err := New(&config.Tracing{})
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+
+	provider, err = New(&config.Tracing{Exporter: "stdout", ServiceName: "trade-metrics", SampleRatio: 1})
+	require.NoError(t, err)
+	assert.NotNil(t, provider)
+	assert.NoError(t, provider.Shutdown(context.Background()))
+
+	_, err = New(&config.Tracing{Exporter: "zipkin"})
+	assert.EqualError(t, err, "unknown tracing exporter: zipkin")
+}
@@ -0,0 +1,364 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/sirupsen/logrus"
+)
+
+// deliveryHistory is how many delivery statuses are kept for the status
+// endpoint.
+const deliveryHistory = 10000
+
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type payload struct {
+	ID string `json:"id"`
+	event.Event
+}
+
+type delivery struct {
+	webhook *domain.Webhook
+	status  *domain.WebhookDelivery
+	body    []byte
+}
+
+type deadLetter struct {
+	Delivery *domain.WebhookDelivery `json:"delivery"`
+	URL      string                  `json:"url"`
+	Payload  json.RawMessage         `json:"payload"`
+}
+
+// Dispatcher delivers events to matching webhooks. Failed deliveries are
+// retried with exponential backoff and written to the dead letter file once
+// MaxAttempts is reached, or when the dispatcher stops before delivering them.
+type Dispatcher struct {
+	store  Store
+	cfg    *config.Webhooks
+	client *http.Client
+	queue  chan *delivery
+	stop   chan struct{}
+	wg     sync.WaitGroup
+
+	deadMu sync.Mutex
+	dead   *os.File
+
+	// mu also guards the queue and retrying, so that Stop sees every pending
+	// delivery either queued or waiting for a retry.
+	mu         sync.RWMutex
+	stopped    bool
+	retrying   map[*delivery]*time.Timer
+	deliveries map[string]*domain.WebhookDelivery
+	history    []string
+}
+
+func NewDispatcher(store Store, cfg *config.Webhooks) (*Dispatcher, error) {
+	d := &Dispatcher{
+		store:      store,
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		queue:      make(chan *delivery, max(cfg.QueueSize, 1)),
+		stop:       make(chan struct{}),
+		retrying:   make(map[*delivery]*time.Timer),
+		deliveries: make(map[string]*domain.WebhookDelivery),
+	}
+	if cfg.DeadLetterFile != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.DeadLetterFile), 0o755); err != nil {
+			return nil, errors.New("failed to open dead letter file: " + err.Error())
+		}
+		f, err := os.OpenFile(cfg.DeadLetterFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, errors.New("failed to open dead letter file: " + err.Error())
+		}
+		d.dead = f
+	}
+	return d, nil
+}
+
+func (d *Dispatcher) Start() {
+	for i := 0; i < max(d.cfg.Workers, 1); i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+}
+
+// Stop waits for the running attempts and writes the deliveries still queued
+// or waiting for a retry to the dead letter file before closing it.
+func (d *Dispatcher) Stop() error {
+	d.mu.Lock()
+	d.stopped = true
+	retrying := d.retrying
+	d.retrying = nil
+	d.mu.Unlock()
+	close(d.stop)
+	d.wg.Wait()
+
+	for dl, timer := range retrying {
+		timer.Stop()
+		d.fail(dl, 0, "dispatcher stopped")
+	}
+	for len(d.queue) > 0 {
+		d.fail(<-d.queue, 0, "dispatcher stopped")
+	}
+
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	if d.dead == nil {
+		return nil
+	}
+	err := d.dead.Close()
+	d.dead = nil
+	return err
+}
+
+// Handle queues a delivery of e to every matching webhook. It is meant to be
+// subscribed to the event bus and never blocks.
+func (d *Dispatcher) Handle(e event.Event) {
+	webhooks, err := d.store.List()
+	if err != nil {
+		logrus.Errorf("failed to list webhooks: %s", err.Error())
+		return
+	}
+	for _, webhook := range webhooks {
+		if !Matches(webhook, e) {
+			continue
+		}
+		now := time.Now()
+		status := &domain.WebhookDelivery{
+			ID:        uuid.NewString(),
+			WebhookID: webhook.ID,
+			EventType: e.Type,
+			Status:    domain.DeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		body, err := json.Marshal(payload{ID: status.ID, Event: e})
+		if err != nil {
+			logrus.Errorf("failed to encode webhook payload: %s", err.Error())
+			continue
+		}
+		d.mu.Lock()
+		d.track(status)
+		d.enqueue(&delivery{webhook: webhook, status: status, body: body}, 0)
+		d.mu.Unlock()
+	}
+}
+
+func Matches(webhook *domain.Webhook, e event.Event) bool {
+	if len(webhook.Events) > 0 {
+		found := false
+		for _, t := range webhook.Events {
+			if t == e.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if webhook.Exchange != "" && webhook.Exchange != e.Exchange {
+		return false
+	}
+	if webhook.Pair != "" && webhook.Pair != e.Pair {
+		return false
+	}
+	if webhook.ClientName != "" && (e.Order == nil || e.Order.Client.ClientName != webhook.ClientName) {
+		return false
+	}
+	return true
+}
+
+// Sign returns the signature of a payload sent at timestamp, receivers
+// compare it with the X-Webhook-Signature header.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case dl := <-d.queue:
+			d.attempt(dl)
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(dl *delivery) {
+	code, err := d.send(dl)
+	d.mu.Lock()
+	dl.status.Attempts++
+	dl.status.LastStatusCode = code
+	dl.status.UpdatedAt = time.Now()
+	attempts := dl.status.Attempts
+	if err == nil {
+		dl.status.Status = domain.DeliveryDelivered
+		dl.status.LastError = ""
+		d.mu.Unlock()
+		return
+	}
+	dl.status.LastError = err.Error()
+	if attempts >= max(d.cfg.MaxAttempts, 1) {
+		d.mu.Unlock()
+		d.fail(dl, code, err.Error())
+		return
+	}
+	if d.stopped {
+		d.mu.Unlock()
+		d.fail(dl, code, "dispatcher stopped")
+		return
+	}
+	dl.status.Status = domain.DeliveryRetrying
+	d.retrying[dl] = time.AfterFunc(d.backoff(attempts), func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		// Stop took over the deliveries waiting for a retry
+		if _, ok := d.retrying[dl]; !ok {
+			return
+		}
+		delete(d.retrying, dl)
+		d.enqueue(dl, code)
+	})
+	d.mu.Unlock()
+}
+
+// enqueue queues dl for the workers, it fails when the queue is full or the
+// dispatcher is stopped. It must be called with mu held.
+func (d *Dispatcher) enqueue(dl *delivery, code int) {
+	reason := "dispatcher stopped"
+	if !d.stopped {
+		select {
+		case d.queue <- dl:
+			return
+		default:
+			reason = "delivery queue is full"
+		}
+	}
+	d.writeDeadLetter(dl, d.markFailed(dl, code, reason))
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryInitial
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if d.cfg.RetryMax > 0 && delay >= d.cfg.RetryMax {
+			return d.cfg.RetryMax
+		}
+	}
+	return delay
+}
+
+func (d *Dispatcher) send(dl *delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, dl.webhook.URL, bytes.NewReader(dl.body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, dl.webhook.ID)
+	req.Header.Set(HeaderDelivery, dl.status.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if dl.webhook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(dl.webhook.Secret, timestamp, dl.body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) fail(dl *delivery, code int, reason string) {
+	d.mu.Lock()
+	status := d.markFailed(dl, code, reason)
+	d.mu.Unlock()
+	d.writeDeadLetter(dl, status)
+}
+
+// markFailed must be called with mu held.
+func (d *Dispatcher) markFailed(dl *delivery, code int, reason string) domain.WebhookDelivery {
+	dl.status.Status = domain.DeliveryFailed
+	dl.status.LastError = reason
+	if code != 0 {
+		dl.status.LastStatusCode = code
+	}
+	dl.status.UpdatedAt = time.Now()
+	return *dl.status
+}
+
+// writeDeadLetter logs the failed delivery and appends it to the dead letter
+// file, unless the file is closed by Stop.
+func (d *Dispatcher) writeDeadLetter(dl *delivery, status domain.WebhookDelivery) {
+	logrus.Errorf("webhook %s delivery %s failed: %s", dl.webhook.ID, status.ID, status.LastError)
+	b, err := json.Marshal(deadLetter{Delivery: &status, URL: dl.webhook.URL, Payload: dl.body})
+	if err != nil {
+		return
+	}
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	if d.dead == nil {
+		return
+	}
+	if _, err := d.dead.Write(append(b, '\n')); err != nil {
+		logrus.Errorf("failed to write dead letter: %s", err.Error())
+	}
+}
+
+// track must be called with mu held.
+func (d *Dispatcher) track(status *domain.WebhookDelivery) {
+	d.deliveries[status.ID] = status
+	d.history = append(d.history, status.ID)
+	if len(d.history) > deliveryHistory {
+		delete(d.deliveries, d.history[0])
+		d.history = d.history[1:]
+	}
+}
+
+// Deliveries returns the most recent delivery statuses of a webhook, newest
+// first.
+func (d *Dispatcher) Deliveries(webhookID string) []domain.WebhookDelivery {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	res := []domain.WebhookDelivery{}
+	for i := len(d.history) - 1; i >= 0; i-- {
+		if status := d.deliveries[d.history[i]]; status.WebhookID == webhookID {
+			res = append(res, *status)
+		}
+	}
+	return res
+}
@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/internal/config"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/internal/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testOrder = &domain.HistoryOrder{
+	Client: domain.Client{ClientName: "Misha", ExchangeName: "binance", Label: "111", Pair: "BTCUSDT"},
+	Side:   "buy",
+	Price:  50000,
+}
+
+func TestMatches(t *testing.T) {
+	orderSaved := event.OrderSaved(testOrder)
+	bookSaved := event.OrderBookSaved("binance", "ETHUSDT", &domain.AsksBids{})
+
+	tests := []struct {
+		name     string
+		webhook  domain.Webhook
+		event    event.Event
+		expected bool
+	}{
+		{name: "No filters", webhook: domain.Webhook{}, event: bookSaved, expected: true},
+		{name: "Event type", webhook: domain.Webhook{Events: []string{event.TypeOrderSaved}}, event: bookSaved, expected: false},
+		{name: "Exchange and pair", webhook: domain.Webhook{Exchange: "binance", Pair: "BTCUSDT"}, event: orderSaved, expected: true},
+		{name: "Other pair", webhook: domain.Webhook{Pair: "BTCUSDT"}, event: bookSaved, expected: false},
+		{name: "Client", webhook: domain.Webhook{ClientName: "Misha"}, event: orderSaved, expected: true},
+		{name: "Client on order book", webhook: domain.Webhook{ClientName: "Misha"}, event: bookSaved, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Matches(&tt.webhook, tt.event))
+		})
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int32
+		expectedStatus   string
+		expectedAttempts int
+		expectedDead     int
+	}{
+		{
+			name:             "Delivered after retry",
+			failures:         1,
+			expectedStatus:   domain.DeliveryDelivered,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Dead letter",
+			failures:         10,
+			expectedStatus:   domain.DeliveryFailed,
+			expectedAttempts: 3,
+			expectedDead:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			received := make(chan struct{}, 10)
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, Sign("secret", r.Header.Get(HeaderTimestamp), body), r.Header.Get(HeaderSignature))
+				assert.Equal(t, "wh1", r.Header.Get(HeaderID))
+				assert.Contains(t, string(body), `"type":"order.saved"`)
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				received <- struct{}{}
+			}))
+			defer receiver.Close()
+
+			dir := t.TempDir()
+			store, err := NewFileStore(filepath.Join(dir, "webhooks.json"))
+			require.NoError(t, err)
+			require.NoError(t, store.Create(&domain.Webhook{ID: "wh1", URL: receiver.URL, Secret: "secret"}))
+			require.NoError(t, store.Create(&domain.Webhook{ID: "wh2", URL: receiver.URL, Pair: "ETHUSDT"}))
+
+			cfg := &config.Webhooks{
+				DeadLetterFile: filepath.Join(dir, "dead.ndjson"),
+				Workers:        1,
+				QueueSize:      10,
+				Timeout:        time.Second,
+				MaxAttempts:    3,
+				RetryInitial:   10 * time.Millisecond,
+				RetryMax:       20 * time.Millisecond,
+			}
+			dispatcher, err := NewDispatcher(store, cfg)
+			require.NoError(t, err)
+			dispatcher.Start()
+			dispatcher.Handle(event.OrderSaved(testOrder))
+
+			for i := 0; i < tt.expectedAttempts; i++ {
+				select {
+				case <-received:
+				case <-time.After(time.Second):
+					t.Fatal("webhook was not called")
+				}
+			}
+			assert.Eventually(t, func() bool {
+				deliveries := dispatcher.Deliveries("wh1")
+				return len(deliveries) == 1 && deliveries[0].Status == tt.expectedStatus
+			}, time.Second, 10*time.Millisecond)
+			require.NoError(t, dispatcher.Stop())
+
+			deliveries := dispatcher.Deliveries("wh1")
+			assert.Equal(t, tt.expectedAttempts, deliveries[0].Attempts)
+			assert.Empty(t, dispatcher.Deliveries("wh2"))
+			dead, err := os.ReadFile(cfg.DeadLetterFile)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedDead, strings.Count(string(dead), "\n"))
+		})
+	}
+}
+
+func TestDispatcher_Stop(t *testing.T) {
+	tests := []struct {
+		name           string
+		start          bool
+		expectedStatus int
+	}{
+		{name: "Queued", start: false},
+		{name: "Waiting for retry", start: true, expectedStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer receiver.Close()
+
+			dir := t.TempDir()
+			store, err := NewFileStore(filepath.Join(dir, "webhooks.json"))
+			require.NoError(t, err)
+			require.NoError(t, store.Create(&domain.Webhook{ID: "wh1", URL: receiver.URL}))
+
+			cfg := &config.Webhooks{
+				DeadLetterFile: filepath.Join(dir, "dead.ndjson"),
+				Workers:        1,
+				QueueSize:      10,
+				Timeout:        time.Second,
+				MaxAttempts:    3,
+				RetryInitial:   time.Hour,
+			}
+			dispatcher, err := NewDispatcher(store, cfg)
+			require.NoError(t, err)
+			if tt.start {
+				dispatcher.Start()
+			}
+			dispatcher.Handle(event.OrderSaved(testOrder))
+			if tt.start {
+				assert.Eventually(t, func() bool {
+					return dispatcher.Deliveries("wh1")[0].Status == domain.DeliveryRetrying
+				}, time.Second, 10*time.Millisecond)
+			}
+			require.NoError(t, dispatcher.Stop())
+			// events after Stop fail without touching the closed file
+			dispatcher.Handle(event.OrderSaved(testOrder))
+
+			deliveries := dispatcher.Deliveries("wh1")
+			require.Len(t, deliveries, 2)
+			for _, delivery := range deliveries {
+				assert.Equal(t, domain.DeliveryFailed, delivery.Status)
+				assert.Equal(t, "dispatcher stopped", delivery.LastError)
+			}
+			assert.Equal(t, tt.expectedStatus, deliveries[1].LastStatusCode)
+			dead, err := os.ReadFile(cfg.DeadLetterFile)
+			require.NoError(t, err)
+			assert.Equal(t, 1, strings.Count(string(dead), "\n"))
+			assert.Contains(t, string(dead), `"last_error":"dispatcher stopped"`)
+		})
+	}
+}
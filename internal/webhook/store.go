@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/kolibriee/trade-metrics/internal/domain"
+)
+
+var ErrNotFound = errors.New("webhook not found")
+
+type Store interface {
+	Create(webhook *domain.Webhook) error
+	List() ([]*domain.Webhook, error)
+	Get(id string) (*domain.Webhook, error)
+	Delete(id string) error
+}
+
+// fileStore keeps subscriptions in memory and rewrites the whole JSON file on
+// every change.
+type fileStore struct {
+	mu       sync.RWMutex
+	path     string
+	webhooks map[string]*domain.Webhook
+}
+
+func NewFileStore(path string) (*fileStore, error) {
+	s := &fileStore{
+		path:     path,
+		webhooks: make(map[string]*domain.Webhook),
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to read webhooks: " + err.Error())
+	}
+	var webhooks []*domain.Webhook
+	if err := json.Unmarshal(b, &webhooks); err != nil {
+		return nil, errors.New("invalid webhooks file " + path + ": " + err.Error())
+	}
+	for _, webhook := range webhooks {
+		s.webhooks[webhook.ID] = webhook
+	}
+	return s, nil
+}
+
+func (s *fileStore) Create(webhook *domain.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[webhook.ID] = webhook
+	if err := s.save(); err != nil {
+		delete(s.webhooks, webhook.ID)
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) List() ([]*domain.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list(), nil
+}
+
+func (s *fileStore) Get(id string) (*domain.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return webhook, nil
+}
+
+func (s *fileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.webhooks, id)
+	if err := s.save(); err != nil {
+		s.webhooks[id] = webhook
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) list() []*domain.Webhook {
+	webhooks := make([]*domain.Webhook, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks
+}
+
+func (s *fileStore) save() error {
+	b, err := json.MarshalIndent(s.list(), "", "  ")
+	if err != nil {
+		return errors.New("failed to encode webhooks: " + err.Error())
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return errors.New("failed to save webhooks: " + err.Error())
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return errors.New("failed to save webhooks: " + err.Error())
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.New("failed to save webhooks: " + err.Error())
+	}
+	return nil
+}
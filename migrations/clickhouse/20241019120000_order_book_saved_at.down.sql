@@ -0,0 +1 @@
+ALTER TABLE order_book DROP COLUMN IF EXISTS saved_at;
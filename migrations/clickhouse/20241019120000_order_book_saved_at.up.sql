@@ -0,0 +1 @@
+ALTER TABLE order_book ADD COLUMN IF NOT EXISTS saved_at DateTime64(9) DEFAULT now64(9);
@@ -0,0 +1,2 @@
+ALTER TABLE order_history RESET SETTING non_replicated_deduplication_window;
+ALTER TABLE order_book RESET SETTING non_replicated_deduplication_window;
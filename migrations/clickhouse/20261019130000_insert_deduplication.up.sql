@@ -0,0 +1,4 @@
+-- keep the tokens of the last inserts, so that inserts repeated with
+-- insert_deduplication_token are dropped by tables that aren't replicated
+ALTER TABLE order_book MODIFY SETTING non_replicated_deduplication_window = 1000;
+ALTER TABLE order_history MODIFY SETTING non_replicated_deduplication_window = 1000;
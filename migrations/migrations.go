@@ -0,0 +1,8 @@
+// Package migrations embeds the schema migrations of the repository
+// backends, one directory per backend, in the layout of golang-migrate.
+package migrations
+
+import "embed"
+
+//go:embed clickhouse/*.sql sqlite/*.sql postgres/*.sql
+var FS embed.FS
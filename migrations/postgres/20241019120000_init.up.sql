@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS order_book
+(
+    seq       BIGSERIAL PRIMARY KEY,
+    id        BIGINT NOT NULL,
+    exchange  TEXT   NOT NULL,
+    pair      TEXT   NOT NULL,
+    asks      TEXT   NOT NULL,
+    bids      TEXT   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_book_exchange_pair ON order_book (exchange, pair, seq);
+
+CREATE TABLE IF NOT EXISTS order_history
+(
+    client_name            TEXT             NOT NULL,
+    exchange_name          TEXT             NOT NULL,
+    label                  TEXT             NOT NULL,
+    pair                   TEXT             NOT NULL,
+    side                   TEXT             NOT NULL,
+    type                   TEXT             NOT NULL,
+    base_qty               DOUBLE PRECISION NOT NULL,
+    price                  DOUBLE PRECISION NOT NULL,
+    algorithm_name_placed  TEXT             NOT NULL,
+    lowest_sell_prc        DOUBLE PRECISION NOT NULL,
+    highest_buy_prc        DOUBLE PRECISION NOT NULL,
+    commission_quote_qty   DOUBLE PRECISION NOT NULL,
+    time_placed            TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_history_client ON order_history (client_name, exchange_name, label, pair);
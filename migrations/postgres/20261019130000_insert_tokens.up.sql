@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS insert_tokens
+(
+    token        TEXT        PRIMARY KEY,
+    inserted_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS insert_tokens_inserted_at ON insert_tokens (inserted_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS order_book;
+DROP TABLE IF EXISTS order_history;
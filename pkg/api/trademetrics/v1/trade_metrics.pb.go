@@ -0,0 +1,1181 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: trademetrics/v1/trade_metrics.proto
+
+package trademetricsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DepthOrder struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Price   float64 `protobuf:"fixed64,1,opt,name=price,proto3" json:"price,omitempty"`
+	BaseQty float64 `protobuf:"fixed64,2,opt,name=base_qty,json=baseQty,proto3" json:"base_qty,omitempty"`
+}
+
+func (x *DepthOrder) Reset() {
+	*x = DepthOrder{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DepthOrder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepthOrder) ProtoMessage() {}
+
+func (x *DepthOrder) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepthOrder.ProtoReflect.Descriptor instead.
+func (*DepthOrder) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DepthOrder) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *DepthOrder) GetBaseQty() float64 {
+	if x != nil {
+		return x.BaseQty
+	}
+	return 0
+}
+
+type OrderBook struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   uint32        `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Asks []*DepthOrder `protobuf:"bytes,2,rep,name=asks,proto3" json:"asks,omitempty"`
+	Bids []*DepthOrder `protobuf:"bytes,3,rep,name=bids,proto3" json:"bids,omitempty"`
+}
+
+func (x *OrderBook) Reset() {
+	*x = OrderBook{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OrderBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBook) ProtoMessage() {}
+
+func (x *OrderBook) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBook.ProtoReflect.Descriptor instead.
+func (*OrderBook) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OrderBook) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *OrderBook) GetAsks() []*DepthOrder {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+func (x *OrderBook) GetBids() []*DepthOrder {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+// PackedOrderBook is an OrderBook with its levels as parallel arrays, which
+// are encoded as packed doubles. The HTTP API returns it for the packed
+// layout of application/x-protobuf.
+type PackedOrderBook struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          uint32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	AskPrices   []float64 `protobuf:"fixed64,2,rep,packed,name=ask_prices,json=askPrices,proto3" json:"ask_prices,omitempty"`
+	AskBaseQtys []float64 `protobuf:"fixed64,3,rep,packed,name=ask_base_qtys,json=askBaseQtys,proto3" json:"ask_base_qtys,omitempty"`
+	BidPrices   []float64 `protobuf:"fixed64,4,rep,packed,name=bid_prices,json=bidPrices,proto3" json:"bid_prices,omitempty"`
+	BidBaseQtys []float64 `protobuf:"fixed64,5,rep,packed,name=bid_base_qtys,json=bidBaseQtys,proto3" json:"bid_base_qtys,omitempty"`
+}
+
+func (x *PackedOrderBook) Reset() {
+	*x = PackedOrderBook{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PackedOrderBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackedOrderBook) ProtoMessage() {}
+
+func (x *PackedOrderBook) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackedOrderBook.ProtoReflect.Descriptor instead.
+func (*PackedOrderBook) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PackedOrderBook) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PackedOrderBook) GetAskPrices() []float64 {
+	if x != nil {
+		return x.AskPrices
+	}
+	return nil
+}
+
+func (x *PackedOrderBook) GetAskBaseQtys() []float64 {
+	if x != nil {
+		return x.AskBaseQtys
+	}
+	return nil
+}
+
+func (x *PackedOrderBook) GetBidPrices() []float64 {
+	if x != nil {
+		return x.BidPrices
+	}
+	return nil
+}
+
+func (x *PackedOrderBook) GetBidBaseQtys() []float64 {
+	if x != nil {
+		return x.BidBaseQtys
+	}
+	return nil
+}
+
+type Client struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClientName   string `protobuf:"bytes,1,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`
+	ExchangeName string `protobuf:"bytes,2,opt,name=exchange_name,json=exchangeName,proto3" json:"exchange_name,omitempty"`
+	Label        string `protobuf:"bytes,3,opt,name=label,proto3" json:"label,omitempty"`
+	Pair         string `protobuf:"bytes,4,opt,name=pair,proto3" json:"pair,omitempty"`
+}
+
+func (x *Client) Reset() {
+	*x = Client{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Client) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client) ProtoMessage() {}
+
+func (x *Client) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client.ProtoReflect.Descriptor instead.
+func (*Client) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Client) GetClientName() string {
+	if x != nil {
+		return x.ClientName
+	}
+	return ""
+}
+
+func (x *Client) GetExchangeName() string {
+	if x != nil {
+		return x.ExchangeName
+	}
+	return ""
+}
+
+func (x *Client) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Client) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+type HistoryOrder struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Client              *Client                `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	Side                string                 `protobuf:"bytes,2,opt,name=side,proto3" json:"side,omitempty"`
+	Type                string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	BaseQty             float64                `protobuf:"fixed64,4,opt,name=base_qty,json=baseQty,proto3" json:"base_qty,omitempty"`
+	Price               float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	AlgorithmNamePlaced string                 `protobuf:"bytes,6,opt,name=algorithm_name_placed,json=algorithmNamePlaced,proto3" json:"algorithm_name_placed,omitempty"`
+	LowestSellPrc       float64                `protobuf:"fixed64,7,opt,name=lowest_sell_prc,json=lowestSellPrc,proto3" json:"lowest_sell_prc,omitempty"`
+	HighestBuyPrc       float64                `protobuf:"fixed64,8,opt,name=highest_buy_prc,json=highestBuyPrc,proto3" json:"highest_buy_prc,omitempty"`
+	CommissionQuoteQty  float64                `protobuf:"fixed64,9,opt,name=commission_quote_qty,json=commissionQuoteQty,proto3" json:"commission_quote_qty,omitempty"`
+	TimePlaced          *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=time_placed,json=timePlaced,proto3" json:"time_placed,omitempty"`
+}
+
+func (x *HistoryOrder) Reset() {
+	*x = HistoryOrder{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryOrder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryOrder) ProtoMessage() {}
+
+func (x *HistoryOrder) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryOrder.ProtoReflect.Descriptor instead.
+func (*HistoryOrder) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HistoryOrder) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *HistoryOrder) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *HistoryOrder) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HistoryOrder) GetBaseQty() float64 {
+	if x != nil {
+		return x.BaseQty
+	}
+	return 0
+}
+
+func (x *HistoryOrder) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *HistoryOrder) GetAlgorithmNamePlaced() string {
+	if x != nil {
+		return x.AlgorithmNamePlaced
+	}
+	return ""
+}
+
+func (x *HistoryOrder) GetLowestSellPrc() float64 {
+	if x != nil {
+		return x.LowestSellPrc
+	}
+	return 0
+}
+
+func (x *HistoryOrder) GetHighestBuyPrc() float64 {
+	if x != nil {
+		return x.HighestBuyPrc
+	}
+	return 0
+}
+
+func (x *HistoryOrder) GetCommissionQuoteQty() float64 {
+	if x != nil {
+		return x.CommissionQuoteQty
+	}
+	return 0
+}
+
+func (x *HistoryOrder) GetTimePlaced() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TimePlaced
+	}
+	return nil
+}
+
+type GetOrderBookRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExchangeName string `protobuf:"bytes,1,opt,name=exchange_name,json=exchangeName,proto3" json:"exchange_name,omitempty"`
+	Pair         string `protobuf:"bytes,2,opt,name=pair,proto3" json:"pair,omitempty"`
+}
+
+func (x *GetOrderBookRequest) Reset() {
+	*x = GetOrderBookRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetOrderBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderBookRequest) ProtoMessage() {}
+
+func (x *GetOrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderBookRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetOrderBookRequest) GetExchangeName() string {
+	if x != nil {
+		return x.ExchangeName
+	}
+	return ""
+}
+
+func (x *GetOrderBookRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+type SaveOrderBookRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExchangeName string     `protobuf:"bytes,1,opt,name=exchange_name,json=exchangeName,proto3" json:"exchange_name,omitempty"`
+	Pair         string     `protobuf:"bytes,2,opt,name=pair,proto3" json:"pair,omitempty"`
+	OrderBook    *OrderBook `protobuf:"bytes,3,opt,name=order_book,json=orderBook,proto3" json:"order_book,omitempty"`
+}
+
+func (x *SaveOrderBookRequest) Reset() {
+	*x = SaveOrderBookRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SaveOrderBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveOrderBookRequest) ProtoMessage() {}
+
+func (x *SaveOrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveOrderBookRequest.ProtoReflect.Descriptor instead.
+func (*SaveOrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SaveOrderBookRequest) GetExchangeName() string {
+	if x != nil {
+		return x.ExchangeName
+	}
+	return ""
+}
+
+func (x *SaveOrderBookRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *SaveOrderBookRequest) GetOrderBook() *OrderBook {
+	if x != nil {
+		return x.OrderBook
+	}
+	return nil
+}
+
+type SaveOrderBookResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *SaveOrderBookResponse) Reset() {
+	*x = SaveOrderBookResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SaveOrderBookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveOrderBookResponse) ProtoMessage() {}
+
+func (x *SaveOrderBookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveOrderBookResponse.ProtoReflect.Descriptor instead.
+func (*SaveOrderBookResponse) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SaveOrderBookResponse) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetOrderHistoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Client *Client `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+}
+
+func (x *GetOrderHistoryRequest) Reset() {
+	*x = GetOrderHistoryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetOrderHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderHistoryRequest) ProtoMessage() {}
+
+func (x *GetOrderHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetOrderHistoryRequest) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+type GetOrderHistoryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Orders []*HistoryOrder `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+}
+
+func (x *GetOrderHistoryResponse) Reset() {
+	*x = GetOrderHistoryResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetOrderHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderHistoryResponse) ProtoMessage() {}
+
+func (x *GetOrderHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetOrderHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetOrderHistoryResponse) GetOrders() []*HistoryOrder {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+type StreamOrderHistoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Client *Client `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+}
+
+func (x *StreamOrderHistoryRequest) Reset() {
+	*x = StreamOrderHistoryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamOrderHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamOrderHistoryRequest) ProtoMessage() {}
+
+func (x *StreamOrderHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamOrderHistoryRequest.ProtoReflect.Descriptor instead.
+func (*StreamOrderHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StreamOrderHistoryRequest) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+type SaveOrderRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Order *HistoryOrder `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+}
+
+func (x *SaveOrderRequest) Reset() {
+	*x = SaveOrderRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SaveOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveOrderRequest) ProtoMessage() {}
+
+func (x *SaveOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveOrderRequest.ProtoReflect.Descriptor instead.
+func (*SaveOrderRequest) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SaveOrderRequest) GetOrder() *HistoryOrder {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type SaveOrderResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SaveOrderResponse) Reset() {
+	*x = SaveOrderResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SaveOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveOrderResponse) ProtoMessage() {}
+
+func (x *SaveOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trademetrics_v1_trade_metrics_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveOrderResponse.ProtoReflect.Descriptor instead.
+func (*SaveOrderResponse) Descriptor() ([]byte, []int) {
+	return file_trademetrics_v1_trade_metrics_proto_rawDescGZIP(), []int{12}
+}
+
+var File_trademetrics_v1_trade_metrics_proto protoreflect.FileDescriptor
+
+var file_trademetrics_v1_trade_metrics_proto_rawDesc = []byte{
+	0x0a, 0x23, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x76,
+	0x31, 0x2f, 0x74, 0x72, 0x61, 0x64, 0x65, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0f, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x3d, 0x0a, 0x0a, 0x44, 0x65, 0x70, 0x74, 0x68,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x62,
+	0x61, 0x73, 0x65, 0x5f, 0x71, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x62,
+	0x61, 0x73, 0x65, 0x51, 0x74, 0x79, 0x22, 0x7d, 0x0a, 0x09, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42,
+	0x6f, 0x6f, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x2f, 0x0a, 0x04, 0x61, 0x73, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1b, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x70, 0x74, 0x68, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x04,
+	0x61, 0x73, 0x6b, 0x73, 0x12, 0x2f, 0x0a, 0x04, 0x62, 0x69, 0x64, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x70, 0x74, 0x68, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52,
+	0x04, 0x62, 0x69, 0x64, 0x73, 0x22, 0xa7, 0x01, 0x0a, 0x0f, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x64,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x73, 0x6b,
+	0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x01, 0x52, 0x09, 0x61,
+	0x73, 0x6b, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x61, 0x73, 0x6b, 0x5f,
+	0x62, 0x61, 0x73, 0x65, 0x5f, 0x71, 0x74, 0x79, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x01, 0x52,
+	0x0b, 0x61, 0x73, 0x6b, 0x42, 0x61, 0x73, 0x65, 0x51, 0x74, 0x79, 0x73, 0x12, 0x1d, 0x0a, 0x0a,
+	0x62, 0x69, 0x64, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x01,
+	0x52, 0x09, 0x62, 0x69, 0x64, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x62,
+	0x69, 0x64, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x71, 0x74, 0x79, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x01, 0x52, 0x0b, 0x62, 0x69, 0x64, 0x42, 0x61, 0x73, 0x65, 0x51, 0x74, 0x79, 0x73, 0x22,
+	0x78, 0x0a, 0x06, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78,
+	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x69, 0x72, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x69, 0x72, 0x22, 0x8b, 0x03, 0x0a, 0x0c, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x2f, 0x0a, 0x06, 0x63, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x74, 0x72, 0x61,
+	0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x52, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73,
+	0x69, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x69, 0x64, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x71, 0x74, 0x79, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x62, 0x61, 0x73, 0x65, 0x51, 0x74, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x15, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68,
+	0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x13, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x4e, 0x61,
+	0x6d, 0x65, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x12, 0x26, 0x0a, 0x0f, 0x6c, 0x6f, 0x77, 0x65,
+	0x73, 0x74, 0x5f, 0x73, 0x65, 0x6c, 0x6c, 0x5f, 0x70, 0x72, 0x63, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x0d, 0x6c, 0x6f, 0x77, 0x65, 0x73, 0x74, 0x53, 0x65, 0x6c, 0x6c, 0x50, 0x72, 0x63,
+	0x12, 0x26, 0x0a, 0x0f, 0x68, 0x69, 0x67, 0x68, 0x65, 0x73, 0x74, 0x5f, 0x62, 0x75, 0x79, 0x5f,
+	0x70, 0x72, 0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x68, 0x69, 0x67, 0x68, 0x65,
+	0x73, 0x74, 0x42, 0x75, 0x79, 0x50, 0x72, 0x63, 0x12, 0x30, 0x0a, 0x14, 0x63, 0x6f, 0x6d, 0x6d,
+	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x5f, 0x71, 0x74, 0x79,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52, 0x12, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x51, 0x74, 0x79, 0x12, 0x3b, 0x0a, 0x0b, 0x74, 0x69,
+	0x6d, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x74, 0x69, 0x6d,
+	0x65, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x22, 0x4e, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x4f, 0x72,
+	0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23,
+	0x0a, 0x0d, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x69, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x70, 0x61, 0x69, 0x72, 0x22, 0x8a, 0x01, 0x0a, 0x14, 0x53, 0x61, 0x76, 0x65,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x69, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x69, 0x72, 0x12, 0x39, 0x0a, 0x0a, 0x6f, 0x72, 0x64,
+	0x65, 0x72, 0x5f, 0x62, 0x6f, 0x6f, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x52, 0x09, 0x6f, 0x72, 0x64, 0x65, 0x72,
+	0x42, 0x6f, 0x6f, 0x6b, 0x22, 0x27, 0x0a, 0x15, 0x53, 0x61, 0x76, 0x65, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x0e, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x69, 0x64, 0x22, 0x49, 0x0a,
+	0x16, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d,
+	0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+	0x52, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x22, 0x50, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x06, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x52, 0x06, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x22, 0x4c, 0x0a, 0x19, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d,
+	0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+	0x52, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x22, 0x47, 0x0a, 0x10, 0x53, 0x61, 0x76, 0x65,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x33, 0x0a, 0x05,
+	0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x74, 0x72,
+	0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x05, 0x6f, 0x72, 0x64, 0x65,
+	0x72, 0x22, 0x13, 0x0a, 0x11, 0x53, 0x61, 0x76, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xe4, 0x03, 0x0a, 0x13, 0x54, 0x72, 0x61, 0x64, 0x65,
+	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x50,
+	0x0a, 0x0c, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x12, 0x24,
+	0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b,
+	0x12, 0x5e, 0x0a, 0x0d, 0x53, 0x61, 0x76, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f,
+	0x6b, 0x12, 0x25, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f,
+	0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65,
+	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x64, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x48, 0x69, 0x73, 0x74,
+	0x6f, 0x72, 0x79, 0x12, 0x27, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x74,
+	0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x52, 0x0a, 0x09, 0x53, 0x61, 0x76, 0x65, 0x4f, 0x72,
+	0x64, 0x65, 0x72, 0x12, 0x21, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65,
+	0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x61, 0x0a, 0x12, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
+	0x12, 0x2a, 0x2e, 0x74, 0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x74,
+	0x72, 0x61, 0x64, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x48,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x30, 0x01, 0x42, 0x4b, 0x5a,
+	0x49, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b, 0x6f, 0x6c, 0x69,
+	0x62, 0x72, 0x69, 0x65, 0x65, 0x2f, 0x74, 0x72, 0x61, 0x64, 0x65, 0x2d, 0x6d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x73, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x74, 0x72, 0x61, 0x64,
+	0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x76, 0x31, 0x3b, 0x74, 0x72, 0x61, 0x64,
+	0x65, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_trademetrics_v1_trade_metrics_proto_rawDescOnce sync.Once
+	file_trademetrics_v1_trade_metrics_proto_rawDescData = file_trademetrics_v1_trade_metrics_proto_rawDesc
+)
+
+func file_trademetrics_v1_trade_metrics_proto_rawDescGZIP() []byte {
+	file_trademetrics_v1_trade_metrics_proto_rawDescOnce.Do(func() {
+		file_trademetrics_v1_trade_metrics_proto_rawDescData = protoimpl.X.CompressGZIP(file_trademetrics_v1_trade_metrics_proto_rawDescData)
+	})
+	return file_trademetrics_v1_trade_metrics_proto_rawDescData
+}
+
+var file_trademetrics_v1_trade_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_trademetrics_v1_trade_metrics_proto_goTypes = []any{
+	(*DepthOrder)(nil),                // 0: trademetrics.v1.DepthOrder
+	(*OrderBook)(nil),                 // 1: trademetrics.v1.OrderBook
+	(*PackedOrderBook)(nil),           // 2: trademetrics.v1.PackedOrderBook
+	(*Client)(nil),                    // 3: trademetrics.v1.Client
+	(*HistoryOrder)(nil),              // 4: trademetrics.v1.HistoryOrder
+	(*GetOrderBookRequest)(nil),       // 5: trademetrics.v1.GetOrderBookRequest
+	(*SaveOrderBookRequest)(nil),      // 6: trademetrics.v1.SaveOrderBookRequest
+	(*SaveOrderBookResponse)(nil),     // 7: trademetrics.v1.SaveOrderBookResponse
+	(*GetOrderHistoryRequest)(nil),    // 8: trademetrics.v1.GetOrderHistoryRequest
+	(*GetOrderHistoryResponse)(nil),   // 9: trademetrics.v1.GetOrderHistoryResponse
+	(*StreamOrderHistoryRequest)(nil), // 10: trademetrics.v1.StreamOrderHistoryRequest
+	(*SaveOrderRequest)(nil),          // 11: trademetrics.v1.SaveOrderRequest
+	(*SaveOrderResponse)(nil),         // 12: trademetrics.v1.SaveOrderResponse
+	(*timestamppb.Timestamp)(nil),     // 13: google.protobuf.Timestamp
+}
+var file_trademetrics_v1_trade_metrics_proto_depIdxs = []int32{
+	0,  // 0: trademetrics.v1.OrderBook.asks:type_name -> trademetrics.v1.DepthOrder
+	0,  // 1: trademetrics.v1.OrderBook.bids:type_name -> trademetrics.v1.DepthOrder
+	3,  // 2: trademetrics.v1.HistoryOrder.client:type_name -> trademetrics.v1.Client
+	13, // 3: trademetrics.v1.HistoryOrder.time_placed:type_name -> google.protobuf.Timestamp
+	1,  // 4: trademetrics.v1.SaveOrderBookRequest.order_book:type_name -> trademetrics.v1.OrderBook
+	3,  // 5: trademetrics.v1.GetOrderHistoryRequest.client:type_name -> trademetrics.v1.Client
+	4,  // 6: trademetrics.v1.GetOrderHistoryResponse.orders:type_name -> trademetrics.v1.HistoryOrder
+	3,  // 7: trademetrics.v1.StreamOrderHistoryRequest.client:type_name -> trademetrics.v1.Client
+	4,  // 8: trademetrics.v1.SaveOrderRequest.order:type_name -> trademetrics.v1.HistoryOrder
+	5,  // 9: trademetrics.v1.TradeMetricsService.GetOrderBook:input_type -> trademetrics.v1.GetOrderBookRequest
+	6,  // 10: trademetrics.v1.TradeMetricsService.SaveOrderBook:input_type -> trademetrics.v1.SaveOrderBookRequest
+	8,  // 11: trademetrics.v1.TradeMetricsService.GetOrderHistory:input_type -> trademetrics.v1.GetOrderHistoryRequest
+	11, // 12: trademetrics.v1.TradeMetricsService.SaveOrder:input_type -> trademetrics.v1.SaveOrderRequest
+	10, // 13: trademetrics.v1.TradeMetricsService.StreamOrderHistory:input_type -> trademetrics.v1.StreamOrderHistoryRequest
+	1,  // 14: trademetrics.v1.TradeMetricsService.GetOrderBook:output_type -> trademetrics.v1.OrderBook
+	7,  // 15: trademetrics.v1.TradeMetricsService.SaveOrderBook:output_type -> trademetrics.v1.SaveOrderBookResponse
+	9,  // 16: trademetrics.v1.TradeMetricsService.GetOrderHistory:output_type -> trademetrics.v1.GetOrderHistoryResponse
+	12, // 17: trademetrics.v1.TradeMetricsService.SaveOrder:output_type -> trademetrics.v1.SaveOrderResponse
+	4,  // 18: trademetrics.v1.TradeMetricsService.StreamOrderHistory:output_type -> trademetrics.v1.HistoryOrder
+	14, // [14:19] is the sub-list for method output_type
+	9,  // [9:14] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_trademetrics_v1_trade_metrics_proto_init() }
+func file_trademetrics_v1_trade_metrics_proto_init() {
+	if File_trademetrics_v1_trade_metrics_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*DepthOrder); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*OrderBook); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*PackedOrderBook); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Client); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*HistoryOrder); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*GetOrderBookRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*SaveOrderBookRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*SaveOrderBookResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*GetOrderHistoryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*GetOrderHistoryResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamOrderHistoryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*SaveOrderRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_trademetrics_v1_trade_metrics_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*SaveOrderResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_trademetrics_v1_trade_metrics_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_trademetrics_v1_trade_metrics_proto_goTypes,
+		DependencyIndexes: file_trademetrics_v1_trade_metrics_proto_depIdxs,
+		MessageInfos:      file_trademetrics_v1_trade_metrics_proto_msgTypes,
+	}.Build()
+	File_trademetrics_v1_trade_metrics_proto = out.File
+	file_trademetrics_v1_trade_metrics_proto_rawDesc = nil
+	file_trademetrics_v1_trade_metrics_proto_goTypes = nil
+	file_trademetrics_v1_trade_metrics_proto_depIdxs = nil
+}
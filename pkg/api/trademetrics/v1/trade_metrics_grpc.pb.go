@@ -0,0 +1,296 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: trademetrics/v1/trade_metrics.proto
+
+package trademetricsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	TradeMetricsService_GetOrderBook_FullMethodName       = "/trademetrics.v1.TradeMetricsService/GetOrderBook"
+	TradeMetricsService_SaveOrderBook_FullMethodName      = "/trademetrics.v1.TradeMetricsService/SaveOrderBook"
+	TradeMetricsService_GetOrderHistory_FullMethodName    = "/trademetrics.v1.TradeMetricsService/GetOrderHistory"
+	TradeMetricsService_SaveOrder_FullMethodName          = "/trademetrics.v1.TradeMetricsService/SaveOrder"
+	TradeMetricsService_StreamOrderHistory_FullMethodName = "/trademetrics.v1.TradeMetricsService/StreamOrderHistory"
+)
+
+// TradeMetricsServiceClient is the client API for TradeMetricsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TradeMetrics exposes the operations of the REST API.
+type TradeMetricsServiceClient interface {
+	GetOrderBook(ctx context.Context, in *GetOrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error)
+	SaveOrderBook(ctx context.Context, in *SaveOrderBookRequest, opts ...grpc.CallOption) (*SaveOrderBookResponse, error)
+	GetOrderHistory(ctx context.Context, in *GetOrderHistoryRequest, opts ...grpc.CallOption) (*GetOrderHistoryResponse, error)
+	SaveOrder(ctx context.Context, in *SaveOrderRequest, opts ...grpc.CallOption) (*SaveOrderResponse, error)
+	// StreamOrderHistory sends the orders of a GetOrderHistory query one by one.
+	StreamOrderHistory(ctx context.Context, in *StreamOrderHistoryRequest, opts ...grpc.CallOption) (TradeMetricsService_StreamOrderHistoryClient, error)
+}
+
+type tradeMetricsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTradeMetricsServiceClient(cc grpc.ClientConnInterface) TradeMetricsServiceClient {
+	return &tradeMetricsServiceClient{cc}
+}
+
+func (c *tradeMetricsServiceClient) GetOrderBook(ctx context.Context, in *GetOrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderBook)
+	err := c.cc.Invoke(ctx, TradeMetricsService_GetOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeMetricsServiceClient) SaveOrderBook(ctx context.Context, in *SaveOrderBookRequest, opts ...grpc.CallOption) (*SaveOrderBookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SaveOrderBookResponse)
+	err := c.cc.Invoke(ctx, TradeMetricsService_SaveOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeMetricsServiceClient) GetOrderHistory(ctx context.Context, in *GetOrderHistoryRequest, opts ...grpc.CallOption) (*GetOrderHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrderHistoryResponse)
+	err := c.cc.Invoke(ctx, TradeMetricsService_GetOrderHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeMetricsServiceClient) SaveOrder(ctx context.Context, in *SaveOrderRequest, opts ...grpc.CallOption) (*SaveOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SaveOrderResponse)
+	err := c.cc.Invoke(ctx, TradeMetricsService_SaveOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeMetricsServiceClient) StreamOrderHistory(ctx context.Context, in *StreamOrderHistoryRequest, opts ...grpc.CallOption) (TradeMetricsService_StreamOrderHistoryClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TradeMetricsService_ServiceDesc.Streams[0], TradeMetricsService_StreamOrderHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tradeMetricsServiceStreamOrderHistoryClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TradeMetricsService_StreamOrderHistoryClient interface {
+	Recv() (*HistoryOrder, error)
+	grpc.ClientStream
+}
+
+type tradeMetricsServiceStreamOrderHistoryClient struct {
+	grpc.ClientStream
+}
+
+func (x *tradeMetricsServiceStreamOrderHistoryClient) Recv() (*HistoryOrder, error) {
+	m := new(HistoryOrder)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TradeMetricsServiceServer is the server API for TradeMetricsService service.
+// All implementations must embed UnimplementedTradeMetricsServiceServer
+// for forward compatibility
+//
+// TradeMetrics exposes the operations of the REST API.
+type TradeMetricsServiceServer interface {
+	GetOrderBook(context.Context, *GetOrderBookRequest) (*OrderBook, error)
+	SaveOrderBook(context.Context, *SaveOrderBookRequest) (*SaveOrderBookResponse, error)
+	GetOrderHistory(context.Context, *GetOrderHistoryRequest) (*GetOrderHistoryResponse, error)
+	SaveOrder(context.Context, *SaveOrderRequest) (*SaveOrderResponse, error)
+	// StreamOrderHistory sends the orders of a GetOrderHistory query one by one.
+	StreamOrderHistory(*StreamOrderHistoryRequest, TradeMetricsService_StreamOrderHistoryServer) error
+	mustEmbedUnimplementedTradeMetricsServiceServer()
+}
+
+// UnimplementedTradeMetricsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTradeMetricsServiceServer struct {
+}
+
+func (UnimplementedTradeMetricsServiceServer) GetOrderBook(context.Context, *GetOrderBookRequest) (*OrderBook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderBook not implemented")
+}
+func (UnimplementedTradeMetricsServiceServer) SaveOrderBook(context.Context, *SaveOrderBookRequest) (*SaveOrderBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveOrderBook not implemented")
+}
+func (UnimplementedTradeMetricsServiceServer) GetOrderHistory(context.Context, *GetOrderHistoryRequest) (*GetOrderHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderHistory not implemented")
+}
+func (UnimplementedTradeMetricsServiceServer) SaveOrder(context.Context, *SaveOrderRequest) (*SaveOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveOrder not implemented")
+}
+func (UnimplementedTradeMetricsServiceServer) StreamOrderHistory(*StreamOrderHistoryRequest, TradeMetricsService_StreamOrderHistoryServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamOrderHistory not implemented")
+}
+func (UnimplementedTradeMetricsServiceServer) mustEmbedUnimplementedTradeMetricsServiceServer() {}
+
+// UnsafeTradeMetricsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TradeMetricsServiceServer will
+// result in compilation errors.
+type UnsafeTradeMetricsServiceServer interface {
+	mustEmbedUnimplementedTradeMetricsServiceServer()
+}
+
+func RegisterTradeMetricsServiceServer(s grpc.ServiceRegistrar, srv TradeMetricsServiceServer) {
+	s.RegisterService(&TradeMetricsService_ServiceDesc, srv)
+}
+
+func _TradeMetricsService_GetOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeMetricsServiceServer).GetOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeMetricsService_GetOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeMetricsServiceServer).GetOrderBook(ctx, req.(*GetOrderBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeMetricsService_SaveOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveOrderBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeMetricsServiceServer).SaveOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeMetricsService_SaveOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeMetricsServiceServer).SaveOrderBook(ctx, req.(*SaveOrderBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeMetricsService_GetOrderHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeMetricsServiceServer).GetOrderHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeMetricsService_GetOrderHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeMetricsServiceServer).GetOrderHistory(ctx, req.(*GetOrderHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeMetricsService_SaveOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeMetricsServiceServer).SaveOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeMetricsService_SaveOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeMetricsServiceServer).SaveOrder(ctx, req.(*SaveOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeMetricsService_StreamOrderHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamOrderHistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TradeMetricsServiceServer).StreamOrderHistory(m, &tradeMetricsServiceStreamOrderHistoryServer{ServerStream: stream})
+}
+
+type TradeMetricsService_StreamOrderHistoryServer interface {
+	Send(*HistoryOrder) error
+	grpc.ServerStream
+}
+
+type tradeMetricsServiceStreamOrderHistoryServer struct {
+	grpc.ServerStream
+}
+
+func (x *tradeMetricsServiceStreamOrderHistoryServer) Send(m *HistoryOrder) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// TradeMetricsService_ServiceDesc is the grpc.ServiceDesc for TradeMetricsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TradeMetricsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "trademetrics.v1.TradeMetricsService",
+	HandlerType: (*TradeMetricsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOrderBook",
+			Handler:    _TradeMetricsService_GetOrderBook_Handler,
+		},
+		{
+			MethodName: "SaveOrderBook",
+			Handler:    _TradeMetricsService_SaveOrderBook_Handler,
+		},
+		{
+			MethodName: "GetOrderHistory",
+			Handler:    _TradeMetricsService_GetOrderHistory_Handler,
+		},
+		{
+			MethodName: "SaveOrder",
+			Handler:    _TradeMetricsService_SaveOrder_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamOrderHistory",
+			Handler:       _TradeMetricsService_StreamOrderHistory_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "trademetrics/v1/trade_metrics.proto",
+}
@@ -0,0 +1,275 @@
+// Package client is the Go client of the trade-metrics HTTP API.
+//
+//	c := client.New("http://localhost:8080", client.WithTimeout(5*time.Second))
+//	book, err := c.GetOrderBook(ctx, "binance", "BTCUSDT")
+//
+// Failed requests are retried with exponential backoff and full jitter: GET
+// and DELETE requests on network errors and 429, 502, 503 and 504 responses,
+// POST requests only on 429 and 503, which the server rejects before saving
+// anything. Non-2xx responses are returned as *APIError.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	timeout    time.Duration
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	header     http.Header
+}
+
+type Option func(c *Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout limits every attempt of a request, 0 disables the limit. The
+// context passed to a method bounds the request including its retries.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets how often a failed request is retried and the range of
+// the backoff between attempts.
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithHeader adds a header to every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		timeout:    10 * time.Second,
+		maxRetries: 3,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 2 * time.Second,
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var res Health
+	if err := c.do(ctx, http.MethodGet, "/health", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) GetOrderBook(ctx context.Context, exchange, pair string) (*AsksBids, error) {
+	var res AsksBids
+	if err := c.do(ctx, http.MethodGet, orderBookPath(exchange, pair), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SaveOrderBook saves the asks and bids of orderBook and returns the id the
+// server assigned to it.
+func (c *Client) SaveOrderBook(ctx context.Context, exchange, pair string, orderBook *AsksBids) (uint32, error) {
+	var res struct {
+		Id uint32 `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, orderBookPath(exchange, pair), nil, orderBook, &res); err != nil {
+		return 0, err
+	}
+	return res.Id, nil
+}
+
+func (c *Client) GetOrderHistory(ctx context.Context, client *TradingClient) ([]*HistoryOrder, error) {
+	query := url.Values{
+		"client-name":   {client.ClientName},
+		"exchange-name": {client.ExchangeName},
+		"label":         {client.Label},
+		"pair":          {client.Pair},
+	}
+	var res []*HistoryOrder
+	if err := c.do(ctx, http.MethodGet, "/orderhistory/", query, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SaveOrder saves order, its TimePlaced is set by the server.
+func (c *Client) SaveOrder(ctx context.Context, order *HistoryOrder) error {
+	return c.do(ctx, http.MethodPost, "/orderhistory/", nil, order, nil)
+}
+
+// CreateWebhook returns the created webhook including its secret, which is
+// not returned by ListWebhooks.
+func (c *Client) CreateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	var res Webhook
+	if err := c.do(ctx, http.MethodPost, "/webhooks/", nil, webhook, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var res []*Webhook
+	if err := c.do(ctx, http.MethodGet, "/webhooks/", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) GetWebhookDeliveries(ctx context.Context, id string) ([]*WebhookDelivery, error) {
+	var res []*WebhookDelivery
+	if err := c.do(ctx, http.MethodGet, "/webhooks/"+url.PathEscape(id)+"/deliveries", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func orderBookPath(exchange, pair string) string {
+	return "/orderbook/" + url.PathEscape(exchange) + "/" + url.PathEscape(pair) + "/"
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return errors.New("failed to encode request: " + err.Error())
+		}
+		body = b
+	}
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, method, target, body, out)
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.maxRetries || !retryable(method, err) || ctx.Err() != nil {
+			return err
+		}
+		wait := retryAfter
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends one request. It returns the delay the server asked for in
+// Retry-After, if any.
+func (c *Client) attempt(ctx context.Context, method, target string, body []byte, out any) (time.Duration, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.New("failed to create request: " + err.Error())
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		if b, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil && json.Unmarshal(b, &errResp) == nil {
+			apiErr.Message = errResp.Message
+		}
+		var retryAfter time.Duration
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+			retryAfter = time.Duration(s) * time.Second
+		}
+		return retryAfter, apiErr
+	}
+	if out == nil {
+		return 0, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, errors.New("failed to decode response: " + err.Error())
+	}
+	return 0, nil
+}
+
+func retryable(method string, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if method == http.MethodGet || method == http.MethodDelete {
+			return apiErr.Temporary()
+		}
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && (method == http.MethodGet || method == http.MethodDelete)
+}
+
+// backoff returns a random delay between minBackoff and the exponentially
+// growing cap for the attempt.
+func (c *Client) backoff(attempt int) time.Duration {
+	ceil := c.maxBackoff
+	if attempt < 30 {
+		if d := c.minBackoff << attempt; d > 0 && d < ceil {
+			ceil = d
+		}
+	}
+	if ceil <= c.minBackoff {
+		return c.minBackoff
+	}
+	return c.minBackoff + time.Duration(rand.Int63n(int64(ceil-c.minBackoff)))
+}
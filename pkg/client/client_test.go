@@ -0,0 +1,178 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/trade-metrics/pkg/client"
+	"github.com/kolibriee/trade-metrics/pkg/client/clienttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(srv *clienttest.Server) *client.Client {
+	return client.New(srv.URL, client.WithRetries(3, time.Millisecond, 5*time.Millisecond))
+}
+
+func TestClient(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := newClient(srv)
+	ctx := context.Background()
+
+	id, err := c.SaveOrderBook(ctx, "binance", "BTCUSDT", &client.AsksBids{
+		Asks: []client.DepthOrder{{Price: 100, BaseQty: 1}},
+		Bids: []client.DepthOrder{{Price: 99, BaseQty: 2}},
+	})
+	require.NoError(t, err)
+	book, err := c.GetOrderBook(ctx, "binance", "BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, id, book.Id)
+	assert.Equal(t, []client.DepthOrder{{Price: 100, BaseQty: 1}}, book.Asks)
+
+	trader := client.TradingClient{ClientName: "Misha", ExchangeName: "binance", Label: "main", Pair: "BTCUSDT"}
+	require.NoError(t, c.SaveOrder(ctx, &client.HistoryOrder{
+		Client:              trader,
+		Side:                "buy",
+		Type:                "limit",
+		BaseQty:             1,
+		Price:               100,
+		AlgorithmNamePlaced: "twap",
+		LowestSellPrice:     101,
+		HighestBuyPrice:     99,
+		CommissionQuoteQty:  0.1,
+	}))
+	orders, err := c.GetOrderHistory(ctx, &trader)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "twap", orders[0].AlgorithmNamePlaced)
+	assert.False(t, orders[0].TimePlaced.IsZero())
+
+	err = c.SaveOrder(ctx, &client.HistoryOrder{Client: trader})
+	assert.True(t, client.IsBadRequest(err))
+	assert.EqualError(t, err, "trade-metrics: 400 invalid input body")
+
+	wh, err := c.CreateWebhook(ctx, &client.Webhook{URL: "http://risk.local/hook"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, wh.Secret)
+	webhooks, err := c.ListWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Empty(t, webhooks[0].Secret)
+	require.NoError(t, c.DeleteWebhook(ctx, wh.ID))
+	assert.True(t, client.IsNotFound(c.DeleteWebhook(ctx, wh.ID)))
+}
+
+func TestServer_Fixtures(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := newClient(srv)
+	ctx := context.Background()
+
+	srv.SetOrderBook("binance", "BTCUSDT", &client.AsksBids{Id: 7, Asks: []client.DepthOrder{{Price: 100, BaseQty: 1}}})
+	book, err := c.GetOrderBook(ctx, "binance", "BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, &client.AsksBids{Id: 7, Asks: []client.DepthOrder{{Price: 100, BaseQty: 1}}, Bids: []client.DepthOrder{}}, book)
+
+	trader := client.TradingClient{ClientName: "Misha", ExchangeName: "binance", Label: "main", Pair: "BTCUSDT"}
+	placed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	srv.AddOrder(&client.HistoryOrder{Client: trader, Side: "sell", Price: 100, TimePlaced: placed})
+	orders, err := c.GetOrderHistory(ctx, &trader)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, client.HistoryOrder{Client: trader, Side: "sell", Price: 100, TimePlaced: placed}, *orders[0])
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		status           int
+		save             bool
+		expectedRequests int
+		expectedStatus   int
+	}{
+		{
+			name:             "GET retried until success",
+			failures:         2,
+			status:           http.StatusBadGateway,
+			expectedRequests: 3,
+		},
+		{
+			name:             "GET gives up after max retries",
+			failures:         5,
+			status:           http.StatusServiceUnavailable,
+			expectedRequests: 4,
+			expectedStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name:             "GET not retried on 500",
+			failures:         1,
+			status:           http.StatusInternalServerError,
+			expectedRequests: 1,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:             "POST retried on 429",
+			failures:         1,
+			status:           http.StatusTooManyRequests,
+			save:             true,
+			expectedRequests: 2,
+		},
+		{
+			name:             "POST not retried on 502",
+			failures:         1,
+			status:           http.StatusBadGateway,
+			save:             true,
+			expectedRequests: 1,
+			expectedStatus:   http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := clienttest.NewServer()
+			defer srv.Close()
+			srv.FailNext(tt.failures, tt.status, "unavailable", 0)
+			c := newClient(srv)
+
+			var err error
+			if tt.save {
+				_, err = c.SaveOrderBook(context.Background(), "binance", "BTCUSDT", &client.AsksBids{
+					Asks: []client.DepthOrder{{Price: 100, BaseQty: 1}},
+					Bids: []client.DepthOrder{{Price: 99, BaseQty: 2}},
+				})
+			} else {
+				_, err = c.GetOrderBook(context.Background(), "binance", "BTCUSDT")
+			}
+
+			assert.Equal(t, tt.expectedRequests, srv.Requests())
+			if tt.expectedStatus == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var apiErr *client.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.expectedStatus, apiErr.StatusCode)
+			assert.Equal(t, "unavailable", apiErr.Message)
+		})
+	}
+}
+
+func TestClient_ContextCancel(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.FailNext(10, http.StatusServiceUnavailable, "unavailable", 0)
+	c := client.New(srv.URL, client.WithRetries(10, time.Second, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetOrderBook(ctx, "binance", "BTCUSDT")
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, srv.Requests())
+}
@@ -0,0 +1,292 @@
+// Package clienttest provides an in-memory fake of the trade-metrics HTTP
+// API for tests of code using the client package.
+//
+//	srv := clienttest.NewServer()
+//	defer srv.Close()
+//	c := client.New(srv.URL)
+package clienttest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/kolibriee/trade-metrics/internal/domain"
+	"github.com/kolibriee/trade-metrics/pkg/client"
+)
+
+type failure struct {
+	status     int
+	message    string
+	retryAfter int
+}
+
+// Server answers like the real API, with the latest order book per exchange
+// pair, the saved orders and the webhooks kept in memory. Webhooks are stored
+// but never called, so they have no deliveries.
+type Server struct {
+	URL string
+
+	srv      *httptest.Server
+	mu       sync.Mutex
+	books    map[string]*domain.AsksBids
+	orders   []*domain.HistoryOrder
+	webhooks map[string]*domain.Webhook
+	failures []failure
+	requests int
+	nextID   uint32
+}
+
+func NewServer() *Server {
+	s := &Server{
+		books:    make(map[string]*domain.AsksBids),
+		webhooks: make(map[string]*domain.Webhook),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.health)
+	mux.HandleFunc("GET /orderbook/{exchange}/{pair}/", s.getOrderBook)
+	mux.HandleFunc("POST /orderbook/{exchange}/{pair}/", s.saveOrderBook)
+	mux.HandleFunc("GET /orderhistory/", s.getOrderHistory)
+	mux.HandleFunc("POST /orderhistory/", s.saveOrder)
+	mux.HandleFunc("POST /webhooks/", s.createWebhook)
+	mux.HandleFunc("GET /webhooks/", s.listWebhooks)
+	mux.HandleFunc("DELETE /webhooks/{id}", s.deleteWebhook)
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", s.getWebhookDeliveries)
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.fail(w) {
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	s.URL = s.srv.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// FailNext makes the next n requests fail with status and message, with a
+// Retry-After header when retryAfter is positive.
+func (s *Server) FailNext(n, status int, message string, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{status: status, message: message, retryAfter: retryAfter})
+	}
+}
+
+// Requests returns the number of requests served, including failed ones.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) SetOrderBook(exchange, pair string, orderBook *client.AsksBids) {
+	book := &domain.AsksBids{Id: orderBook.Id, Asks: depthOrders(orderBook.Asks), Bids: depthOrders(orderBook.Bids)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books[exchange+"/"+pair] = book
+}
+
+// AddOrder stores order as it is, without setting TimePlaced.
+func (s *Server) AddOrder(order *client.HistoryOrder) {
+	o := &domain.HistoryOrder{
+		Client:              domain.Client(order.Client),
+		Side:                order.Side,
+		Type:                order.Type,
+		BaseQty:             order.BaseQty,
+		Price:               order.Price,
+		AlgorithmNamePlaced: order.AlgorithmNamePlaced,
+		LowestSellPrice:     order.LowestSellPrice,
+		HighestBuyPrice:     order.HighestBuyPrice,
+		CommissionQuoteQty:  order.CommissionQuoteQty,
+		TimePlaced:          order.TimePlaced,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders = append(s.orders, o)
+}
+
+func depthOrders(levels []client.DepthOrder) []domain.DepthOrder {
+	res := make([]domain.DepthOrder, len(levels))
+	for i, level := range levels {
+		res[i] = domain.DepthOrder(level)
+	}
+	return res
+}
+
+func (s *Server) fail(w http.ResponseWriter) bool {
+	s.mu.Lock()
+	s.requests++
+	if len(s.failures) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	f := s.failures[0]
+	s.failures = s.failures[1:]
+	s.mu.Unlock()
+	if f.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(f.retryAfter))
+	}
+	writeError(w, f.status, f.message)
+	return true
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) getOrderBook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	book, ok := s.books[r.PathValue("exchange")+"/"+r.PathValue("pair")]
+	s.mu.Unlock()
+	if !ok {
+		book = &domain.AsksBids{}
+	}
+	writeJSON(w, http.StatusOK, book)
+}
+
+func (s *Server) saveOrderBook(w http.ResponseWriter, r *http.Request) {
+	var book domain.AsksBids
+	if !bind(w, r, &book) {
+		return
+	}
+	s.mu.Lock()
+	s.nextID++
+	book.Id = s.nextID
+	s.books[r.PathValue("exchange")+"/"+r.PathValue("pair")] = &book
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"id": book.Id})
+}
+
+func (s *Server) getOrderHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.Client{
+		ClientName:   query.Get("client-name"),
+		ExchangeName: query.Get("exchange-name"),
+		Label:        query.Get("label"),
+		Pair:         query.Get("pair"),
+	}
+	if filter.ClientName == "" || filter.ExchangeName == "" || filter.Label == "" || filter.Pair == "" {
+		writeError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	s.mu.Lock()
+	res := []*domain.HistoryOrder{}
+	for _, order := range s.orders {
+		if order.Client == filter {
+			res = append(res, order)
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) saveOrder(w http.ResponseWriter, r *http.Request) {
+	var order domain.HistoryOrder
+	if !bind(w, r, &order) {
+		return
+	}
+	order.TimePlaced = time.Now()
+	s.mu.Lock()
+	s.orders = append(s.orders, &order)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var wh domain.Webhook
+	if !bind(w, r, &wh) {
+		return
+	}
+	for _, t := range wh.Events {
+		if t != "order.saved" && t != "orderbook.saved" {
+			writeError(w, http.StatusBadRequest, "unknown event type: "+t)
+			return
+		}
+	}
+	wh.ID = randomHex(16)
+	wh.CreatedAt = time.Now()
+	if wh.Secret == "" {
+		wh.Secret = randomHex(32)
+	}
+	s.mu.Lock()
+	stored := wh
+	s.webhooks[wh.ID] = &stored
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, wh)
+}
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	res := make([]domain.Webhook, 0, len(s.webhooks))
+	for _, wh := range s.webhooks {
+		res = append(res, *wh)
+		res[len(res)-1].Secret = ""
+	}
+	s.mu.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	_, ok := s.webhooks[r.PathValue("id")]
+	delete(s.webhooks, r.PathValue("id"))
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	_, ok := s.webhooks[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, []domain.WebhookDelivery{})
+}
+
+func bind(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid input body")
+		return false
+	}
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid input body")
+		return false
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,72 @@
+package client
+
+import "time"
+
+// The API types mirror the JSON of the HTTP API. They are defined here, not
+// shared with the server, so the client only changes with the API.
+
+type AsksBids struct {
+	Id   uint32       `json:"id"`
+	Asks []DepthOrder `json:"asks"`
+	Bids []DepthOrder `json:"bids"`
+}
+
+type DepthOrder struct {
+	Price   float64 `json:"price"`
+	BaseQty float64 `json:"base_qty"`
+}
+
+type HistoryOrder struct {
+	Client              TradingClient `json:"client"`
+	Side                string        `json:"side"`
+	Type                string        `json:"type"`
+	BaseQty             float64       `json:"base_qty"`
+	Price               float64       `json:"price"`
+	AlgorithmNamePlaced string        `json:"algorithm_name_placed"`
+	LowestSellPrice     float64       `json:"lowest_sell_prc"`
+	HighestBuyPrice     float64       `json:"highest_buy_prc"`
+	CommissionQuoteQty  float64       `json:"commission_quote_qty"`
+	TimePlaced          time.Time     `json:"time_placed"`
+}
+
+// TradingClient identifies the orders of a trading client on an exchange
+// pair.
+type TradingClient struct {
+	ClientName   string `json:"client_name"`
+	ExchangeName string `json:"exchange_name"`
+	Label        string `json:"label"`
+	Pair         string `json:"pair"`
+}
+
+// Webhook is a subscription to saved orders and order books. Empty filters
+// match everything, ClientName only matches order events.
+type Webhook struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	Events     []string  `json:"events"`
+	ClientName string    `json:"client_name,omitempty"`
+	Exchange   string    `json:"exchange,omitempty"`
+	Pair       string    `json:"pair,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string    `json:"id"`
+	WebhookID      string    `json:"webhook_id"`
+	EventType      string    `json:"event_type"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type Health struct {
+	Status string `json:"status"`
+	Spool  *struct {
+		Records int   `json:"records"`
+		Bytes   int64 `json:"bytes"`
+	} `json:"spool,omitempty"`
+}